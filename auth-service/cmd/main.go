@@ -9,34 +9,51 @@ import (
 	httpHandler "auth-service/internal/auth/delivery/http"
 	"auth-service/internal/auth/infra"
 	"auth-service/pkg/hasher"
+	"auth-service/pkg/health"
 	"auth-service/pkg/jwt"
+	"auth-service/pkg/metrics"
+	"auth-service/pkg/tlsconfig"
+	"auth-service/pkg/tracing"
+	"context"
 	"fmt"
 	"log"
 	"net"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
 	echoMiddleware "github.com/labstack/echo/v4/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	echoSwagger "github.com/swaggo/echo-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 func main() {
 
-	docs.SwaggerInfo.Host = "34.101.41.221:8084"
-	docs.SwaggerInfo.Schemes = []string{"http"}
-	// docs.SwaggerInfo.Host = "localhost:8084"
-	// docs.SwaggerInfo.Schemes = []string{"http"}
-
 	// 1. Load env file
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found")
 	}
 
+	// Empty host: Swagger UI calls whatever host served it (e.g. through the gateway)
+	docs.SwaggerInfo.Host = os.Getenv("SWAGGER_HOST")
+
+	shutdownTracing, err := tracing.Init(context.Background(), "auth-service")
+	if err != nil {
+		log.Fatalf("Failed to init tracing: %v", err)
+	}
+
 	db := config.PostgresInit()
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, "auth"))
 	userRepo := infra.NewPostgresUserRepository(db)
 
 	jwtSecret := os.Getenv("JWT_SECRET")
@@ -48,20 +65,41 @@ func main() {
 
 	authApp := app.NewAuthApp(userRepo, passwordHasher, jwtManager)
 
+	checker := health.NewChecker(2*time.Second).
+		Add("postgres", db.PingContext)
+
 	// HTTP handler
 	authHTTP := httpHandler.NewAuthHandler(authApp)
 
 	// gRPC handler
 	authGRPC := grpcHandler.NewAuthGRPCServer(authApp)
 
+	// TLS_CERT_FILE and TLS_KEY_FILE turn on TLS for HTTP and gRPC; with
+	// TLS_CA_FILE clients must present a certificate signed by that CA
+	serverTLS, err := tlsconfig.FromEnv("TLS").Server()
+	if err != nil {
+		log.Fatalf("Invalid TLS config: %v", err)
+	}
+	// Swagger UI calls back with the scheme the service is served over
+	docs.SwaggerInfo.Schemes = []string{"http"}
+	if serverTLS != nil {
+		docs.SwaggerInfo.Schemes = []string{"https"}
+	}
+
 	// === START HTTP SERVER ===
 	go func() {
 		e := echo.New()
 		e.Use(echoMiddleware.CORS()) // ini WAJIB untuk Swagger!
+		e.Use(otelecho.Middleware("auth-service"))
+		e.Use(tracing.RequestID())
+		e.Use(metrics.Middleware())
 
 		e.GET("/auth/swagger/*", echoSwagger.WrapHandler)
 		e.POST("/register", authHTTP.Register)
 		e.POST("/login", authHTTP.Login)
+		e.GET("/metrics", metrics.Handler())
+		e.GET("/healthz", checker.Liveness)
+		e.GET("/readyz", checker.Readiness)
 
 		port := os.Getenv("PORT")
 		if port == "" {
@@ -71,11 +109,17 @@ func main() {
 		fmt.Println("🚀 HTTP running at http://localhost:" + port)
 		fmt.Println("📑 Swagger: http://localhost:" + port + "/swagger/index.html")
 
-		if err := e.Start(":" + port); err != nil {
+		if err := tlsconfig.StartEcho(e, ":"+port, serverTLS); err != nil {
 			log.Fatal(err)
 		}
 	}()
 
+	// grpc.health.v1 reports NOT_SERVING while Postgres is unreachable
+	healthServer := grpchealth.NewServer()
+	healthCtx, stopHealth := context.WithCancel(context.Background())
+	defer stopHealth()
+	checker.ServeGRPC(healthCtx, healthServer, 10*time.Second, pb.AuthService_ServiceDesc.ServiceName)
+
 	// === START gRPC SERVER ===
 	go func() {
 		grpcPort := os.Getenv("GRPC_PORT")
@@ -88,8 +132,17 @@ func main() {
 			log.Fatalf("failed to listen: %v", err)
 		}
 
-		grpcServer := grpc.NewServer()
+		opts := []grpc.ServerOption{
+			grpc.StatsHandler(otelgrpc.NewServerHandler()),
+			grpc.ChainUnaryInterceptor(metrics.GRPCServer.UnaryServerInterceptor()),
+		}
+		if serverTLS != nil {
+			opts = append(opts, grpc.Creds(credentials.NewTLS(serverTLS)))
+		}
+		grpcServer := grpc.NewServer(opts...)
 		pb.RegisterAuthServiceServer(grpcServer, authGRPC)
+		healthpb.RegisterHealthServer(grpcServer, healthServer)
+		metrics.GRPCServer.InitializeMetrics(grpcServer)
 
 		fmt.Println("🚀 gRPC running at : " + grpcPort)
 
@@ -105,4 +158,10 @@ func main() {
 
 	log.Println("Gracefully shutting down...")
 	db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		log.Printf("Tracing shutdown: %v", err)
+	}
 }
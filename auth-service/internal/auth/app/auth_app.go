@@ -2,6 +2,7 @@ package app
 
 import (
 	"auth-service/internal/auth/domain"
+	"auth-service/pkg/metrics"
 	"context"
 )
 
@@ -22,14 +23,17 @@ func NewAuthApp(repo domain.UserRepository, hasher PasswordHasher, jwt JWTManage
 // Register user baru
 func (a *AuthApp) Register(ctx context.Context, name, email, password string) (*domain.User, error) {
 	if name == "" || email == "" || password == "" {
+		metrics.Registrations.WithLabelValues("invalid").Inc()
 		return nil, ErrValidation
 	}
 	existing, _ := a.UserRepo.GetByEmail(ctx, email)
 	if existing != nil && existing.ID != "" {
+		metrics.Registrations.WithLabelValues("email_exists").Inc()
 		return nil, ErrEmailExist
 	}
 	hashed, err := a.PasswordHasher.Hash(password)
 	if err != nil {
+		metrics.Registrations.WithLabelValues("error").Inc()
 		return nil, err
 	}
 	user := &domain.User{
@@ -39,8 +43,10 @@ func (a *AuthApp) Register(ctx context.Context, name, email, password string) (*
 	}
 	createdUser, err := a.UserRepo.Create(ctx, user)
 	if err != nil {
+		metrics.Registrations.WithLabelValues("error").Inc()
 		return nil, err
 	}
+	metrics.Registrations.WithLabelValues("success").Inc()
 	return createdUser, nil
 }
 
@@ -49,15 +55,19 @@ func (a *AuthApp) Login(ctx context.Context, email, password string) (string, er
 	user, err := a.UserRepo.GetByEmail(ctx, email)
 
 	if err != nil || user == nil {
+		metrics.Logins.WithLabelValues("unauthorized").Inc()
 		return "", ErrUnauthorized
 	}
 
 	if !a.PasswordHasher.Check(password, user.Password) {
+		metrics.Logins.WithLabelValues("unauthorized").Inc()
 		return "", ErrUnauthorized
 	}
 	token, err := a.JWTManager.GenerateToken(user.ID, user.Email)
 	if err != nil {
+		metrics.Logins.WithLabelValues("error").Inc()
 		return "", err
 	}
+	metrics.Logins.WithLabelValues("success").Inc()
 	return token, nil
 }
@@ -7,7 +7,9 @@ import (
 	"os"
 	"time"
 
+	"github.com/XSAM/otelsql"
 	_ "github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
 )
 
 func PostgresInit() *sql.DB {
@@ -22,7 +24,8 @@ func PostgresInit() *sql.DB {
 		host, port, user, password, dbname,
 	)
 
-	db, err := sql.Open("postgres", psqlInfo)
+	// Every query gets a span under the caller's trace
+	db, err := otelsql.Open("postgres", psqlInfo, otelsql.WithAttributes(semconv.DBSystemNamePostgreSQL))
 	if err != nil {
 		log.Fatal("Cannot connect to PostgreSQL:", err)
 	}
@@ -0,0 +1,20 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// Logins counts login attempts by result (success/unauthorized/error).
+	Logins = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_logins_total",
+		Help: "Login attempts by result.",
+	}, []string{"result"})
+
+	// Registrations counts sign-ups by result (success/email_exists/invalid/error).
+	Registrations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_registrations_total",
+		Help: "Registrations by result.",
+	}, []string{"result"})
+)
@@ -0,0 +1,95 @@
+// Command devcerts creates a local CA and a certificate for every service of
+// docker-compose, for running the stack with TLS and mTLS in development:
+//
+//	cd gateway-service && go run ./cmd/devcerts -out ../certs
+//
+// An existing CA in -out is reused, so running it again renews the service
+// certificates without having to trust a new CA.
+package main
+
+import (
+	"errors"
+	"flag"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gateway-service/internal/tlsconfig"
+)
+
+// services are the docker-compose service names; each is its certificate's
+// host name.
+var services = []string{
+	"gateway-service",
+	"product-service",
+	"transaction-service",
+	"payment-service",
+	"payment-grpc",
+	"auth-service",
+}
+
+func main() {
+	out := flag.String("out", "../certs", "directory to write the certificates to")
+	days := flag.Int("days", 365, "validity of the service certificates in days")
+	hosts := flag.String("hosts", "localhost,127.0.0.1", "comma-separated names added to every certificate")
+	flag.Parse()
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		log.Fatal(err)
+	}
+
+	ca, err := loadOrCreateCA(*out)
+	if err != nil {
+		log.Fatalf("CA: %v", err)
+	}
+
+	validFor := time.Duration(*days) * 24 * time.Hour
+	for _, service := range services {
+		names := []string{service}
+		for _, host := range strings.Split(*hosts, ",") {
+			if host = strings.TrimSpace(host); host != "" {
+				names = append(names, host)
+			}
+		}
+
+		certPEM, keyPEM, err := ca.Issue(names, validFor)
+		if err != nil {
+			log.Fatalf("%s: %v", service, err)
+		}
+		write(filepath.Join(*out, service+".pem"), certPEM, 0o644)
+		write(filepath.Join(*out, service+"-key.pem"), keyPEM, 0o600)
+		log.Printf("issued %s for %s", service+".pem", strings.Join(names, ", "))
+	}
+}
+
+func loadOrCreateCA(dir string) (*tlsconfig.CA, error) {
+	certFile, keyFile := filepath.Join(dir, "ca.pem"), filepath.Join(dir, "ca-key.pem")
+
+	certPEM, certErr := os.ReadFile(certFile)
+	keyPEM, keyErr := os.ReadFile(keyFile)
+	if certErr == nil && keyErr == nil {
+		log.Printf("reusing CA %s", certFile)
+		return tlsconfig.LoadCA(certPEM, keyPEM)
+	}
+	if !errors.Is(certErr, fs.ErrNotExist) || !errors.Is(keyErr, fs.ErrNotExist) {
+		return nil, errors.Join(certErr, keyErr)
+	}
+
+	ca, err := tlsconfig.NewCA("phase3gc2 dev CA", 10*365*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	write(certFile, ca.CertPEM, 0o644)
+	write(keyFile, ca.KeyPEM, 0o600)
+	log.Printf("created CA %s", certFile)
+	return ca, nil
+}
+
+func write(path string, data []byte, perm os.FileMode) {
+	if err := os.WriteFile(path, data, perm); err != nil {
+		log.Fatal(err)
+	}
+}
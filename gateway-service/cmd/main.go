@@ -1,16 +1,32 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"gateway-service/config"
 	"gateway-service/handler"
+	"gateway-service/internal/cache"
+	"gateway-service/internal/gql"
+	"gateway-service/internal/health"
+	"gateway-service/internal/idempotency"
+	"gateway-service/internal/metrics"
+	"gateway-service/internal/ratelimit"
+	"gateway-service/internal/resilience"
+	"gateway-service/internal/tlsconfig"
+	"gateway-service/internal/tracing"
 	"gateway-service/middleware"
 
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
 	echoMiddleware "github.com/labstack/echo/v4/middleware"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
 )
 
 func main() {
@@ -18,41 +34,189 @@ func main() {
 		log.Println("No .env file found, using env variables")
 	}
 
-	grpcClients := config.NewGRPCClients()
-	h := handler.NewGatewayHandler(grpcClients)
+	shutdownTracing, err := tracing.Init(context.Background(), "gateway-service")
+	if err != nil {
+		log.Fatalf("Failed to init tracing: %v", err)
+	}
+
+	// TLS_* is the certificate of the public listener, UPSTREAM_TLS_* the
+	// CA and client certificate for mTLS to the services behind the gateway
+	serverTLS, err := tlsconfig.FromEnv("TLS").Server()
+	if err != nil {
+		log.Fatalf("Invalid TLS config: %v", err)
+	}
+	upstreamTLS, err := tlsconfig.FromEnv("UPSTREAM_TLS").Client()
+	if err != nil {
+		log.Fatalf("Invalid upstream TLS config: %v", err)
+	}
+	adminTLS, err := tlsconfig.FromEnv("ADMIN_TLS").Server()
+	if err != nil {
+		log.Fatalf("Invalid admin TLS config: %v", err)
+	}
+
+	breakers := resilience.NewBreakerRegistry()
+	grpcClients := config.NewGRPCClients(breakers, upstreamTLS)
+	h := handler.NewGatewayHandler(grpcClients, breakers)
+
+	rateLimits := config.NewRateLimitConfig()
+	rateLimitStore := ratelimit.NewMemoryStore(10 * time.Minute)
+	log.Printf("Rate limits: auth=%s api=%s", rateLimits.Auth, rateLimits.API)
+
+	// Retried POSTs with an Idempotency-Key get the first response again
+	idempotent := middleware.Idempotency(idempotency.NewMemoryStore(time.Minute), config.IdempotencyTTL())
 
 	e := echo.New()
+	// Clients reach the gateway directly: the client IP (for rate limits and
+	// the access log) is the peer address, not a forwarding header it can set
+	e.IPExtractor = echo.ExtractIPDirect()
+	e.Use(otelecho.Middleware("gateway-service"))
+	e.Use(tracing.RequestID())
+	e.Use(metrics.Middleware())
+	if out := config.AccessLogOutput(); out != nil {
+		e.Use(middleware.AccessLog(out))
+	}
 	// e.Use(echoMiddleware.CORS())
 	e.Use(echoMiddleware.CORSWithConfig(echoMiddleware.CORSConfig{
-		AllowOrigins: []string{"*"},
-		AllowMethods: []string{echo.GET, echo.HEAD, echo.PUT, echo.PATCH, echo.POST, echo.DELETE, echo.OPTIONS},
-		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization},
+		AllowOrigins:  []string{"*"},
+		AllowMethods:  []string{echo.GET, echo.HEAD, echo.PUT, echo.PATCH, echo.POST, echo.DELETE, echo.OPTIONS},
+		AllowHeaders:  []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, middleware.HeaderAPIKey, "traceparent", "tracestate", middleware.HeaderIdempotencyKey, echo.HeaderCacheControl, "If-None-Match", "Last-Event-ID", handler.HeaderXCanary},
+		ExposeHeaders: []string{"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", echo.HeaderRetryAfter, echo.HeaderXRequestID, middleware.HeaderIdempotentReplayed, "ETag", handler.HeaderXCache, handler.HeaderXRouteVersion, handler.HeaderXAPIVersion, "Deprecation", "Sunset", "Link"},
 	}))
 
+	// REST → gRPC, mapped by the google.api.http options in internal/pb
+	transcoder, err := handler.NewTranscoder(context.Background(), grpcClients)
+	if err != nil {
+		log.Fatalf("Failed to register gRPC transcoder: %v", err)
+	}
+	grpcREST := echo.WrapHandler(transcoder)
+
 	// === Public ===
-	e.POST("/register", h.RegisterGRPC)
-	e.POST("/login", h.LoginGRPC)
+	authLimit := middleware.RateLimit(rateLimitStore, "auth", rateLimits.Auth, middleware.IPKey)
+	e.POST("/register", grpcREST, authLimit)
+	e.POST("/login", grpcREST, authLimit)
 	e.Static("/docs", "docs")
+	e.GET("/metrics", metrics.Handler())
 
 	// === Protected ===
+	// Middleware per route instead of protected.Use(): a group with
+	// middleware claims every /api/* path and would hide the config routes.
 	protected := e.Group("/api")
-	protected.Use(middleware.JWTMiddleware)
+	auth := []echo.MiddlewareFunc{
+		middleware.JWTMiddleware,
+		middleware.RateLimit(rateLimitStore, "api", rateLimits.API, middleware.IdentityKey),
+	}
 
-	protected.Any("/products*", h.ProxyToProductService)
-	protected.Any("/transactions*", h.ProxyToTransactionService)
-	protected.Any("/payments*", h.ProxyToPaymentService)
+	grpcAuth := append(auth, idempotent)
+	protected.Any("/payments-grpc", grpcREST, grpcAuth...)
+	protected.Any("/payments-grpc/*", grpcREST, grpcAuth...)
+
+	// === Proxied (gateway.yaml) ===
+	configPath := os.Getenv("GATEWAY_CONFIG")
+	if configPath == "" {
+		configPath = "gateway.yaml"
+	}
+	router := handler.NewRouter(configPath, breakers, rateLimitStore, idempotent, cache.NewMemoryStore(10000, time.Minute))
+	// Routes can mirror traffic to the REST → gRPC path to compare the two
+	router.SetShadowHandler("grpc", transcoder)
+	router.SetUpstreamTLS(upstreamTLS)
+	if err := router.Reload(); err != nil {
+		log.Fatalf("Invalid gateway config: %v", err)
+	}
+	config.WatchGatewayConfig(configPath, 5*time.Second, func() {
+		if err := router.Reload(); err != nil {
+			log.Printf("[CONFIG] reload failed, keeping current routes: %v", err)
+		}
+	})
 
-	protected.POST("/payments-grpc", h.CreatePaymentGRPC)
-	protected.GET("/payments-grpc", h.GetAllPaymentsGRPC)
-	protected.GET("/payments-grpc/:id", h.GetPaymentByIDGRPC)
-	protected.PUT("/payments-grpc/:id", h.UpdatePaymentGRPC)
-	protected.DELETE("/payments-grpc/:id", h.DeletePaymentGRPC)
+	// === Health ===
+	checker := health.NewChecker(2*time.Second).
+		Add("upstreams", router.CheckUpstreams).
+		Add("payment-grpc", health.GRPC(grpcClients.PaymentHealth, "")).
+		Add("auth-grpc", health.GRPC(grpcClients.AuthHealth, ""))
+	e.GET("/healthz", checker.Liveness)
+	e.GET("/readyz", checker.Readiness)
+	e.GET("/status", handler.NewStatusHandler(router, grpcClients, breakers).Status)
+
+	// Swagger UI at /docs reads the spec merged from every service
+	docs := handler.NewDocsHandler(router, "docs/openapi.yaml", os.Getenv("DOCS_SERVER_URL"))
+	docs.Refresh()
+	e.GET("/docs/openapi.json", docs.Serve)
+	// Routes with validate_requests are checked against that same spec
+	router.SetRequestValidator(handler.NewRequestValidator(docs.Spec))
+
+	// Composite order view: transaction + product + payment in one call
+	protected.GET("/orders/:id", handler.NewOrderHandler(router, grpcClients).Get, auth...)
+
+	// Status changes of the caller's transactions and payments, as SSE
+	protected.GET("/events", handler.NewEventsHandler(router).Stream, auth...)
+
+	// GraphQL over the same upstreams, limited in depth and complexity
+	maxDepth, maxComplexity := config.GraphQLLimits()
+	graphqlHandler, err := handler.NewGraphQLHandler(router, grpcClients, gql.Limits{MaxDepth: maxDepth, MaxComplexity: maxComplexity})
+	if err != nil {
+		log.Fatalf("Failed to build GraphQL schema: %v", err)
+	}
+	protected.POST("/graphql", graphqlHandler.Serve, auth...)
+	protected.GET("/graphql", graphqlHandler.Serve, auth...)
+
+	e.Any("/*", router.Handle)
+
+	// === Admin ===
+	// A listener of its own, with its own credentials, so it can stay off
+	// the public network
+	adminConfig := config.NewAdminConfig()
+	adminHandler := handler.NewAdminHandler(router, rateLimitStore)
+	admin := echo.New()
+	admin.HideBanner = true
+	admin.Use(middleware.AdminAuth(adminConfig.User, adminConfig.Password))
+	admin.GET("/routes", adminHandler.Routes)
+	admin.GET("/upstreams", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, router.UpstreamStatus())
+	})
+	admin.GET("/breakers", h.BreakerStatus)
+	admin.GET("/ratelimits", adminHandler.RateLimits)
+	admin.GET("/deprecations", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, router.DeprecatedUsage())
+	})
+	admin.POST("/reload", adminHandler.Reload)
 
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8084"
 	}
 
-	log.Println("Gateway running at port", port)
-	e.Logger.Fatal(e.Start(":" + port))
+	go func() {
+		log.Println("Gateway running at port", port, "TLS:", serverTLS != nil)
+		if err := tlsconfig.StartEcho(e, ":"+port, serverTLS); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			e.Logger.Fatal(err)
+		}
+	}()
+
+	if adminConfig.Enabled() {
+		go func() {
+			log.Println("Admin API running at port", adminConfig.Port)
+			if err := tlsconfig.StartEcho(admin, ":"+adminConfig.Port, adminTLS); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				admin.Logger.Fatal(err)
+			}
+		}()
+	} else {
+		log.Println("ADMIN_PASSWORD not set, admin API disabled")
+	}
+
+	// Drain requests and flush pending spans before exiting
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	<-quit
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := e.Shutdown(ctx); err != nil {
+		log.Printf("Shutdown: %v", err)
+	}
+	if err := admin.Shutdown(ctx); err != nil {
+		log.Printf("Admin shutdown: %v", err)
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		log.Printf("Tracing shutdown: %v", err)
+	}
 }
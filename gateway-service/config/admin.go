@@ -0,0 +1,57 @@
+package config
+
+import (
+	"io"
+	"log"
+	"os"
+)
+
+// AdminConfig is the admin listener: its own port, kept off the public one,
+// and its own basic auth credentials.
+type AdminConfig struct {
+	Port     string
+	User     string
+	Password string
+}
+
+// Enabled reports whether the admin listener should start. Without a
+// password it stays off rather than open.
+func (a AdminConfig) Enabled() bool {
+	return a.Password != ""
+}
+
+// NewAdminConfig reads ADMIN_PORT (8090 by default), ADMIN_USER (admin by
+// default) and ADMIN_PASSWORD.
+func NewAdminConfig() AdminConfig {
+	cfg := AdminConfig{
+		Port:     os.Getenv("ADMIN_PORT"),
+		User:     os.Getenv("ADMIN_USER"),
+		Password: os.Getenv("ADMIN_PASSWORD"),
+	}
+	if cfg.Port == "" {
+		cfg.Port = "8090"
+	}
+	if cfg.User == "" {
+		cfg.User = "admin"
+	}
+	return cfg
+}
+
+// AccessLogOutput reads ACCESS_LOG: stdout (the default), stderr, off, or
+// the path of a file to append to. nil means off.
+func AccessLogOutput() io.Writer {
+	switch value := os.Getenv("ACCESS_LOG"); value {
+	case "", "stdout":
+		return os.Stdout
+	case "stderr":
+		return os.Stderr
+	case "off":
+		return nil
+	default:
+		f, err := os.OpenFile(value, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			log.Fatalf("Invalid ACCESS_LOG %q: %v", value, err)
+		}
+		return f
+	}
+}
@@ -0,0 +1,596 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gateway-service/internal/balancer"
+	"gateway-service/internal/ratelimit"
+	"gateway-service/internal/split"
+
+	"gopkg.in/yaml.v3"
+)
+
+// GatewayConfig is the declarative route table loaded from GATEWAY_CONFIG.
+// YAML and JSON are both accepted; ${VAR} references are expanded from the
+// environment before parsing.
+type GatewayConfig struct {
+	Defaults   RouteDefaults           `yaml:"defaults" json:"defaults"`
+	Versioning VersioningSpec          `yaml:"versioning" json:"versioning"`
+	Upstreams  map[string]UpstreamSpec `yaml:"upstreams" json:"upstreams"`
+	Routes     []RouteConfig           `yaml:"routes" json:"routes"`
+}
+
+// VersioningSpec lets clients pick an API version, in the path
+// (/api/v2/products) or in Accept (application/vnd.gateway.v2+json). It is
+// on when default is set or a route has a version.
+type VersioningSpec struct {
+	// Prefix is where the version segment goes, /api by default
+	Prefix string `yaml:"prefix" json:"prefix"`
+	// Default is the version of requests that name none, v1 by default
+	Default string `yaml:"default" json:"default"`
+	// MediaType is the vendor type of Accept, application/vnd.gateway by
+	// default
+	MediaType string `yaml:"media_type" json:"media_type"`
+}
+
+// VersioningEnabled reports whether requests are routed by version.
+func (c *GatewayConfig) VersioningEnabled() bool {
+	if c.Versioning.Default != "" {
+		return true
+	}
+	for _, r := range c.Routes {
+		if r.Version != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolvedVersioning returns Versioning with its defaults filled in.
+func (c *GatewayConfig) ResolvedVersioning() VersioningSpec {
+	v := c.Versioning
+	if v.Prefix == "" {
+		v.Prefix = "/api"
+	}
+	v.Prefix = strings.TrimSuffix(v.Prefix, "/")
+	if v.Default == "" {
+		v.Default = "v1"
+	}
+	if v.MediaType == "" {
+		v.MediaType = "application/vnd.gateway"
+	}
+	return v
+}
+
+// Versions returns every API version the routes serve, sorted.
+func (c *GatewayConfig) Versions() []string {
+	if !c.VersioningEnabled() {
+		return nil
+	}
+	seen := map[string]bool{c.ResolvedVersioning().Default: true}
+	for _, r := range c.Routes {
+		if r.Version != "" {
+			seen[r.Version] = true
+		}
+	}
+	out := make([]string, 0, len(seen))
+	for v := range seen {
+		out = append(out, v)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// RouteDefaults apply to every route that doesn't set its own value.
+type RouteDefaults struct {
+	Auth             *bool  `yaml:"auth" json:"auth"`
+	RateLimit        string `yaml:"rate_limit" json:"rate_limit"`
+	MaxBodySize      string `yaml:"max_body_size" json:"max_body_size"`
+	ValidateRequests *bool  `yaml:"validate_requests" json:"validate_requests"`
+}
+
+// UpstreamSpec is the file form of UpstreamConfig. Zero values fall back to
+// DefaultUpstream. Either url (one instance) or targets (several) is set.
+type UpstreamSpec struct {
+	URL            string          `yaml:"url" json:"url"`
+	Targets        []string        `yaml:"targets" json:"targets"`
+	Strategy       string          `yaml:"strategy" json:"strategy"`
+	HealthCheck    HealthCheckSpec `yaml:"health_check" json:"health_check"`
+	ConnectTimeout time.Duration   `yaml:"connect_timeout" json:"connect_timeout"`
+	ReadTimeout    time.Duration   `yaml:"read_timeout" json:"read_timeout"`
+	Retries        *int            `yaml:"retries" json:"retries"`
+	Docs           string          `yaml:"docs" json:"docs"` // path of the upstream's Swagger 2.0 JSON, merged into /docs
+	Breaker        struct {
+		Failures    int           `yaml:"failures" json:"failures"`
+		OpenTimeout time.Duration `yaml:"open_timeout" json:"open_timeout"`
+	} `yaml:"breaker" json:"breaker"`
+}
+
+type HealthCheckSpec struct {
+	Path               string        `yaml:"path" json:"path"`
+	Interval           time.Duration `yaml:"interval" json:"interval"`
+	Timeout            time.Duration `yaml:"timeout" json:"timeout"`
+	HealthyThreshold   int           `yaml:"healthy_threshold" json:"healthy_threshold"`
+	UnhealthyThreshold int           `yaml:"unhealthy_threshold" json:"unhealthy_threshold"`
+}
+
+// targets returns url and targets combined.
+func (s UpstreamSpec) targets() []string {
+	var out []string
+	if s.URL != "" {
+		out = append(out, s.URL)
+	}
+	return append(out, s.Targets...)
+}
+
+type RouteConfig struct {
+	Name             string        `yaml:"name" json:"name"`
+	PathPrefix       string        `yaml:"path_prefix" json:"path_prefix"`
+	Methods          []string      `yaml:"methods" json:"methods"`
+	Upstream         string        `yaml:"upstream" json:"upstream"`
+	StripPrefix      string        `yaml:"strip_prefix" json:"strip_prefix"`
+	AddPrefix        string        `yaml:"add_prefix" json:"add_prefix"` // put in front of the path after strip_prefix
+	Auth             *bool         `yaml:"auth" json:"auth"`
+	Roles            []string      `yaml:"roles" json:"roles"`
+	Timeout          time.Duration `yaml:"timeout" json:"timeout"`
+	RateLimit        string        `yaml:"rate_limit" json:"rate_limit"`
+	MaxBodySize      string        `yaml:"max_body_size" json:"max_body_size"`
+	ValidateRequests *bool         `yaml:"validate_requests" json:"validate_requests"`
+	Cache            *CacheSpec    `yaml:"cache" json:"cache"`
+	Split            *SplitSpec    `yaml:"split" json:"split"`
+	Shadow           *ShadowSpec   `yaml:"shadow" json:"shadow"`
+	// Version ("v2") is the API version the route serves; routes without
+	// one serve every version
+	Version     string           `yaml:"version" json:"version"`
+	Deprecation *DeprecationSpec `yaml:"deprecation" json:"deprecation"`
+}
+
+// DeprecationSpec marks a route as deprecated: its responses get the
+// Deprecation, Sunset and Link headers and its use is counted per client.
+type DeprecationSpec struct {
+	Since  time.Time `yaml:"since" json:"since"`   // when it was deprecated
+	Sunset time.Time `yaml:"sunset" json:"sunset"` // when it goes away, optional
+	Link   string    `yaml:"link" json:"link"`     // migration guide, optional
+}
+
+// SplitSpec spreads a route's traffic over several versions of its upstream
+// by weight. upstream still names the one whose docs describe the route.
+type SplitSpec struct {
+	// Sticky is user (default), cookie or none; see internal/split
+	Sticky   string        `yaml:"sticky" json:"sticky"`
+	Versions []VersionSpec `yaml:"versions" json:"versions"`
+}
+
+// VersionSpec is one version of a split route. List the new version last:
+// raising its weight then only moves users onto it.
+type VersionSpec struct {
+	Name     string `yaml:"name" json:"name"`
+	Upstream string `yaml:"upstream" json:"upstream"`
+	Weight   int    `yaml:"weight" json:"weight"`
+	// Canary is the version X-Canary: true asks for, weight 0 or not
+	Canary bool `yaml:"canary" json:"canary"`
+}
+
+// ShadowSpec mirrors a sample of a route's requests to a second backend and
+// compares its answers with the primary's. Shadow responses are only
+// compared, never returned.
+type ShadowSpec struct {
+	// Upstream is the REST upstream the copies go to; Handler instead names
+	// a handler inside the gateway ("grpc": the REST to gRPC transcoder)
+	Upstream string `yaml:"upstream" json:"upstream"`
+	Handler  string `yaml:"handler" json:"handler"`
+	// PathPrefix replaces the route's path_prefix in the copy's path. By
+	// default the copy gets the path the primary gets.
+	PathPrefix string  `yaml:"path_prefix" json:"path_prefix"`
+	Percent    float64 `yaml:"percent" json:"percent"`
+	// Methods mirrored, GET and HEAD by default: a mirrored POST is carried
+	// out twice when both backends share a database
+	Methods []string `yaml:"methods" json:"methods"`
+	// IgnoreFields are JSON fields left out of the body comparison (IDs,
+	// timestamps), at any depth
+	IgnoreFields []string      `yaml:"ignore_fields" json:"ignore_fields"`
+	Timeout      time.Duration `yaml:"timeout" json:"timeout"`
+}
+
+// ShadowMethods returns the methods mirrored.
+func (s ShadowSpec) ShadowMethods() []string {
+	if len(s.Methods) == 0 {
+		return []string{http.MethodGet, http.MethodHead}
+	}
+	return s.Methods
+}
+
+// CacheSpec turns on the response cache for a route's GETs. Responses are
+// fresh for ttl (or the upstream's max-age), then served stale for up to
+// stale_while_revalidate while one request refreshes them.
+type CacheSpec struct {
+	TTL                  time.Duration `yaml:"ttl" json:"ttl"`
+	StaleWhileRevalidate time.Duration `yaml:"stale_while_revalidate" json:"stale_while_revalidate"`
+	// PerUser keys entries by user as well, for responses that depend on who asks
+	PerUser bool `yaml:"per_user" json:"per_user"`
+}
+
+// UpstreamPath is the path the upstream gets for a request to path.
+func (r RouteConfig) UpstreamPath(path string) string {
+	return r.AddPrefix + strings.TrimPrefix(path, r.StripPrefix)
+}
+
+// RequiresAuth reports whether the route needs a valid JWT (default: yes).
+func (r RouteConfig) RequiresAuth(defaults RouteDefaults) bool {
+	if r.Auth != nil {
+		return *r.Auth
+	}
+	if defaults.Auth != nil {
+		return *defaults.Auth
+	}
+	return true
+}
+
+// ValidatesRequests reports whether requests are checked against the OpenAPI
+// spec before proxying (default: no).
+func (r RouteConfig) ValidatesRequests(defaults RouteDefaults) bool {
+	if r.ValidateRequests != nil {
+		return *r.ValidateRequests
+	}
+	if defaults.ValidateRequests != nil {
+		return *defaults.ValidateRequests
+	}
+	return false
+}
+
+// RateLimitPolicy returns the route's policy, falling back to the default.
+// Validate has already checked that it parses.
+func (r RouteConfig) RateLimitPolicy(defaults RouteDefaults) ratelimit.Policy {
+	spec := r.RateLimit
+	if spec == "" {
+		spec = defaults.RateLimit
+	}
+	policy, _ := ratelimit.ParsePolicy(spec)
+	return policy
+}
+
+// DefaultMaxBodySize applies when neither the route nor the defaults set
+// max_body_size.
+const DefaultMaxBodySize = 10 << 20
+
+// MaxBodyBytes returns the request body limit in bytes; 0 means unlimited.
+// Validate has already checked that it parses.
+func (r RouteConfig) MaxBodyBytes(defaults RouteDefaults) int64 {
+	spec := r.MaxBodySize
+	if spec == "" {
+		spec = defaults.MaxBodySize
+	}
+	if spec == "" {
+		return DefaultMaxBodySize
+	}
+	n, _ := ParseSize(spec)
+	return n
+}
+
+// ParseSize parses sizes like "512KB", "10MB" or "1048576" (bytes). "0"
+// disables the limit.
+func ParseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	units := []struct {
+		suffix string
+		factor int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}}
+
+	factor := int64(1)
+	for _, u := range units {
+		if strings.HasSuffix(s, u.suffix) {
+			s, factor = strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), u.factor
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n * factor, nil
+}
+
+// Upstream returns the resolved UpstreamConfig for name.
+func (c *GatewayConfig) Upstream(name string) UpstreamConfig {
+	spec := c.Upstreams[name]
+	cfg := DefaultUpstream(name, spec.targets()...)
+
+	if spec.Strategy != "" {
+		cfg.Strategy = spec.Strategy
+	}
+	cfg.HealthCheck = balancer.HealthCheck(spec.HealthCheck)
+
+	if spec.ConnectTimeout > 0 {
+		cfg.ConnectTimeout = spec.ConnectTimeout
+	}
+	if spec.ReadTimeout > 0 {
+		cfg.ReadTimeout = spec.ReadTimeout
+	}
+	if spec.Retries != nil {
+		cfg.Retry.MaxAttempts = *spec.Retries + 1
+	}
+	if spec.Breaker.Failures > 0 {
+		cfg.Breaker.FailureThreshold = spec.Breaker.Failures
+	}
+	if spec.Breaker.OpenTimeout > 0 {
+		cfg.Breaker.OpenTimeout = spec.Breaker.OpenTimeout
+	}
+	return cfg
+}
+
+// LoadGatewayConfig reads and validates the config file at path.
+func LoadGatewayConfig(path string) (*GatewayConfig, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg GatewayConfig
+	// JSON is valid YAML, so one decoder handles both
+	if err := yaml.Unmarshal([]byte(os.ExpandEnv(string(raw))), &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return &cfg, nil
+}
+
+// DefaultGatewayConfig reproduces the original hard-coded routes from the
+// PRODUCT_URL, TRANSACTION_URL and PAYMENT_URL env vars. Used when no config
+// file exists.
+func DefaultGatewayConfig() *GatewayConfig {
+	cfg := &GatewayConfig{
+		Defaults:  RouteDefaults{RateLimit: os.Getenv("RATE_LIMIT_API")},
+		Upstreams: map[string]UpstreamSpec{},
+	}
+	if cfg.Defaults.RateLimit == "" {
+		cfg.Defaults.RateLimit = "120/1m"
+	}
+
+	for _, name := range []string{"product", "transaction", "payment"} {
+		cfg.Upstreams[name] = UpstreamSpec{
+			URL:  os.Getenv(strings.ToUpper(name) + "_URL"),
+			Docs: "/" + name + "s/swagger/doc.json",
+		}
+		cfg.Routes = append(cfg.Routes, RouteConfig{
+			Name:        name + "s",
+			PathPrefix:  "/api/" + name + "s",
+			Upstream:    name,
+			StripPrefix: "/api",
+		})
+	}
+	return cfg
+}
+
+var validMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true,
+	http.MethodPatch: true, http.MethodDelete: true, http.MethodOptions: true,
+}
+
+// Validate checks the whole config and reports every problem at once.
+func (c *GatewayConfig) Validate() error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if _, err := ratelimit.ParsePolicy(c.Defaults.RateLimit); err != nil {
+		fail("defaults: %v", err)
+	}
+	if c.Defaults.MaxBodySize != "" {
+		if _, err := ParseSize(c.Defaults.MaxBodySize); err != nil {
+			fail("defaults: max_body_size: %v", err)
+		}
+	}
+
+	names := make([]string, 0, len(c.Upstreams))
+	for name := range c.Upstreams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		spec := c.Upstreams[name]
+		targets := spec.targets()
+		if len(targets) == 0 {
+			fail("upstream %q: url or targets is required", name)
+		}
+		for _, target := range targets {
+			u, err := url.Parse(target)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				fail("upstream %q: invalid url %q", name, target)
+			}
+		}
+		if !balancer.ValidStrategy(spec.Strategy) {
+			fail("upstream %q: unknown strategy %q", name, spec.Strategy)
+		}
+		if hc := spec.HealthCheck; hc.Path != "" && (hc.Interval <= 0 || !strings.HasPrefix(hc.Path, "/")) {
+			fail("upstream %q: health_check needs a path starting with / and an interval", name)
+		}
+		if spec.Retries != nil && *spec.Retries < 0 {
+			fail("upstream %q: retries must be >= 0", name)
+		}
+		if spec.ConnectTimeout < 0 || spec.ReadTimeout < 0 || spec.Breaker.OpenTimeout < 0 || spec.Breaker.Failures < 0 {
+			fail("upstream %q: timeouts and thresholds must not be negative", name)
+		}
+	}
+
+	if len(c.Routes) == 0 {
+		fail("no routes defined")
+	}
+	if v := c.Versioning; v.Default != "" && !IsVersion(v.Default) {
+		fail("versioning: default %q must look like v1", v.Default)
+	}
+	if v := c.Versioning; v.Prefix != "" && !strings.HasPrefix(v.Prefix, "/") {
+		fail("versioning: prefix must start with /")
+	}
+
+	seenNames := map[string]bool{}
+	seenPrefixes := map[string]bool{}
+	for i, r := range c.Routes {
+		label := fmt.Sprintf("route #%d", i+1)
+		if r.Name != "" {
+			label = fmt.Sprintf("route %q", r.Name)
+		}
+
+		switch {
+		case r.Name == "":
+			fail("%s: name is required", label)
+		case seenNames[r.Name]:
+			fail("%s: duplicate name", label)
+		}
+		seenNames[r.Name] = true
+
+		// The same prefix may be served once per version
+		prefixKey := r.Version + " " + r.PathPrefix
+		if !strings.HasPrefix(r.PathPrefix, "/") {
+			fail("%s: path_prefix must start with /", label)
+		} else if seenPrefixes[prefixKey] {
+			fail("%s: duplicate path_prefix %q", label, r.PathPrefix)
+		}
+		seenPrefixes[prefixKey] = true
+
+		if r.Version != "" {
+			if !IsVersion(r.Version) {
+				fail("%s: version %q must look like v1", label, r.Version)
+			}
+			if prefix := c.ResolvedVersioning().Prefix; !matchesPrefix(r.PathPrefix, prefix) {
+				fail("%s: versioned path_prefix must be under %s", label, prefix)
+			}
+		}
+		if d := r.Deprecation; d != nil {
+			if d.Since.IsZero() {
+				fail("%s: deprecation needs since", label)
+			}
+			if !d.Sunset.IsZero() && d.Sunset.Before(d.Since) {
+				fail("%s: deprecation sunset is before since", label)
+			}
+		}
+
+		if r.StripPrefix != "" && !strings.HasPrefix(r.PathPrefix, r.StripPrefix) {
+			fail("%s: strip_prefix %q is not a prefix of %q", label, r.StripPrefix, r.PathPrefix)
+		}
+		if r.AddPrefix != "" && (!strings.HasPrefix(r.AddPrefix, "/") || strings.HasSuffix(r.AddPrefix, "/")) {
+			fail("%s: add_prefix must start and not end with /", label)
+		}
+		if _, ok := c.Upstreams[r.Upstream]; !ok {
+			fail("%s: unknown upstream %q", label, r.Upstream)
+		}
+		for _, m := range r.Methods {
+			if !validMethods[strings.ToUpper(m)] {
+				fail("%s: invalid method %q", label, m)
+			}
+		}
+		if r.Timeout < 0 {
+			fail("%s: timeout must not be negative", label)
+		}
+		if _, err := ratelimit.ParsePolicy(r.RateLimit); err != nil {
+			fail("%s: %v", label, err)
+		}
+		if r.MaxBodySize != "" {
+			if _, err := ParseSize(r.MaxBodySize); err != nil {
+				fail("%s: max_body_size: %v", label, err)
+			}
+		}
+		if len(r.Roles) > 0 && !r.RequiresAuth(c.Defaults) {
+			fail("%s: roles require auth", label)
+		}
+		if r.Cache != nil && (r.Cache.TTL <= 0 || r.Cache.StaleWhileRevalidate < 0) {
+			fail("%s: cache needs a positive ttl", label)
+		}
+		if r.Split != nil {
+			c.validateSplit(label, r.Split, fail)
+		}
+		if r.Shadow != nil {
+			c.validateShadow(label, r.Shadow, fail)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *GatewayConfig) validateSplit(label string, spec *SplitSpec, fail func(string, ...interface{})) {
+	if !split.ValidSticky(spec.Sticky) {
+		fail("%s: split: unknown sticky mode %q", label, spec.Sticky)
+	}
+	if len(spec.Versions) == 0 {
+		fail("%s: split needs versions", label)
+		return
+	}
+
+	total := 0
+	seen := map[string]bool{}
+	for _, v := range spec.Versions {
+		switch {
+		case v.Name == "":
+			fail("%s: split: version name is required", label)
+		case seen[v.Name]:
+			fail("%s: split: duplicate version %q", label, v.Name)
+		}
+		seen[v.Name] = true
+
+		if _, ok := c.Upstreams[v.Upstream]; !ok {
+			fail("%s: split: version %q: unknown upstream %q", label, v.Name, v.Upstream)
+		}
+		if v.Weight < 0 {
+			fail("%s: split: version %q: weight must not be negative", label, v.Name)
+		}
+		total += v.Weight
+	}
+	if total <= 0 {
+		fail("%s: split: weights must add up to more than 0", label)
+	}
+}
+
+func (c *GatewayConfig) validateShadow(label string, spec *ShadowSpec, fail func(string, ...interface{})) {
+	switch {
+	case (spec.Upstream == "") == (spec.Handler == ""):
+		fail("%s: shadow needs either upstream or handler", label)
+	case spec.Upstream != "":
+		if _, ok := c.Upstreams[spec.Upstream]; !ok {
+			fail("%s: shadow: unknown upstream %q", label, spec.Upstream)
+		}
+	}
+	if spec.PathPrefix != "" && !strings.HasPrefix(spec.PathPrefix, "/") {
+		fail("%s: shadow: path_prefix must start with /", label)
+	}
+	if spec.Percent <= 0 || spec.Percent > 100 {
+		fail("%s: shadow: percent must be in (0, 100]", label)
+	}
+	for _, m := range spec.Methods {
+		if !validMethods[strings.ToUpper(m)] {
+			fail("%s: shadow: invalid method %q", label, m)
+		}
+	}
+	if spec.Timeout < 0 {
+		fail("%s: shadow: timeout must not be negative", label)
+	}
+}
+
+// IsVersion reports whether s is an API version: v followed by digits.
+func IsVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+	for _, r := range s[1:] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesPrefix reports whether path is prefix or below it.
+func matchesPrefix(path, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
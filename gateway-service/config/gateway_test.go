@@ -0,0 +1,309 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, name, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadGatewayConfigYAML(t *testing.T) {
+	t.Setenv("TEST_PRODUCT_URL", "http://product:8081")
+	path := writeConfig(t, "gateway.yaml", `
+defaults:
+  rate_limit: 60/1m
+  validate_requests: true
+upstreams:
+  product:
+    url: ${TEST_PRODUCT_URL}
+    read_timeout: 3s
+    retries: 0
+routes:
+  - name: products
+    path_prefix: /api/products
+    methods: [get]
+    upstream: product
+    strip_prefix: /api
+    auth: false
+`)
+
+	cfg, err := LoadGatewayConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	up := cfg.Upstream("product")
+	if len(up.Targets) != 1 || up.Targets[0] != "http://product:8081" || up.ReadTimeout != 3*time.Second || up.Retry.MaxAttempts != 1 {
+		t.Errorf("unexpected upstream %+v", up)
+	}
+	if up.ConnectTimeout != DefaultUpstream("").ConnectTimeout {
+		t.Errorf("connect timeout should fall back to the default, got %v", up.ConnectTimeout)
+	}
+
+	route := cfg.Routes[0]
+	if route.RequiresAuth(cfg.Defaults) {
+		t.Error("route should be public")
+	}
+	if got := route.RateLimitPolicy(cfg.Defaults); got.Limit != 60 {
+		t.Errorf("rate limit should come from defaults, got %v", got)
+	}
+	if !route.ValidatesRequests(cfg.Defaults) {
+		t.Error("request validation should come from defaults")
+	}
+	if (RouteConfig{}).ValidatesRequests(RouteDefaults{}) {
+		t.Error("request validation should be off unless enabled")
+	}
+}
+
+func TestLoadGatewayConfigJSON(t *testing.T) {
+	path := writeConfig(t, "gateway.json", `{
+		"upstreams": {"payment": {"url": "http://payment:8083"}},
+		"routes": [{"name": "payments", "path_prefix": "/api/payments", "upstream": "payment", "timeout": "5s"}]
+	}`)
+
+	cfg, err := LoadGatewayConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Routes[0].Timeout != 5*time.Second {
+		t.Errorf("timeout = %v", cfg.Routes[0].Timeout)
+	}
+}
+
+func TestValidateReportsAllErrors(t *testing.T) {
+	path := writeConfig(t, "gateway.yaml", `
+upstreams:
+  product:
+    url: product:8081
+routes:
+  - name: products
+    path_prefix: api/products
+    upstream: missing
+    methods: [FETCH]
+    rate_limit: lots
+  - name: products
+    path_prefix: /x
+    upstream: product
+    auth: false
+    roles: [admin]
+`)
+
+	_, err := LoadGatewayConfig(path)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+
+	for _, want := range []string{
+		"invalid url",
+		"path_prefix must start with /",
+		"unknown upstream",
+		"invalid method",
+		"rate limit",
+		"duplicate name",
+		"roles require auth",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error should mention %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestUpstreamTargets(t *testing.T) {
+	path := writeConfig(t, "gateway.yaml", `
+upstreams:
+  product:
+    targets: [http://product-1:8081, http://product-2:8081]
+    strategy: least_connections
+    health_check:
+      path: /products
+      interval: 5s
+routes:
+  - name: products
+    path_prefix: /api/products
+    upstream: product
+`)
+
+	cfg, err := LoadGatewayConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	up := cfg.Upstream("product")
+	if len(up.Targets) != 2 || up.Strategy != "least_connections" || up.HealthCheck.Interval != 5*time.Second {
+		t.Errorf("unexpected upstream %+v", up)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	cases := map[string]int64{"0": 0, "512": 512, "1KB": 1024, "10MB": 10 << 20, "2 gb": 2 << 30}
+	for in, want := range cases {
+		got, err := ParseSize(in)
+		if err != nil || got != want {
+			t.Errorf("ParseSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+
+	if _, err := ParseSize("ten MB"); err == nil {
+		t.Error("expected error for invalid size")
+	}
+}
+
+func TestValidateSplit(t *testing.T) {
+	path := writeConfig(t, "gateway.yaml", `
+upstreams:
+  product:
+    url: http://product:8081
+routes:
+  - name: products
+    path_prefix: /api/products
+    upstream: product
+    split:
+      sticky: session
+      versions:
+        - name: stable
+          upstream: product
+          weight: 0
+        - name: stable
+          upstream: product-v2
+          weight: -1
+`)
+
+	_, err := LoadGatewayConfig(path)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{
+		"unknown sticky mode",
+		"duplicate version",
+		`unknown upstream "product-v2"`,
+		"weight must not be negative",
+		"weights must add up",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error should mention %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestValidateShadow(t *testing.T) {
+	path := writeConfig(t, "gateway.yaml", `
+upstreams:
+  payment:
+    url: http://payment:8082
+routes:
+  - name: payments
+    path_prefix: /api/payments
+    upstream: payment
+    shadow:
+      upstream: payment
+      handler: grpc
+      percent: 150
+      methods: [FETCH]
+  - name: others
+    path_prefix: /api/others
+    upstream: payment
+    shadow:
+      upstream: payment-grpc
+      path_prefix: payments
+      percent: 10
+`)
+
+	_, err := LoadGatewayConfig(path)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{
+		"either upstream or handler",
+		"percent must be",
+		"shadow: invalid method",
+		`shadow: unknown upstream "payment-grpc"`,
+		"shadow: path_prefix must start with /",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error should mention %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestLoadVersionedRoutes(t *testing.T) {
+	path := writeConfig(t, "gateway.yaml", `
+versioning:
+  default: v1
+upstreams:
+  product:
+    url: http://product:8081
+routes:
+  - name: products-v1
+    path_prefix: /api/products
+    upstream: product
+    version: v1
+    deprecation:
+      since: 2026-01-01
+      sunset: 2026-12-31T00:00:00Z
+      link: https://example.com/migrate
+  - name: products-v2
+    path_prefix: /api/products
+    upstream: product
+    version: v2
+  - name: transactions
+    path_prefix: /api/transactions
+    upstream: product
+`)
+
+	cfg, err := LoadGatewayConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Versions(); len(got) != 2 || got[0] != "v1" || got[1] != "v2" {
+		t.Errorf("unexpected versions %v", got)
+	}
+	d := cfg.Routes[0].Deprecation
+	if !d.Since.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) || d.Sunset.Year() != 2026 || d.Link == "" {
+		t.Errorf("unexpected deprecation %+v", d)
+	}
+	if v := cfg.ResolvedVersioning(); v.Prefix != "/api" || v.MediaType != "application/vnd.gateway" {
+		t.Errorf("unexpected versioning defaults %+v", v)
+	}
+}
+
+func TestValidateVersioning(t *testing.T) {
+	path := writeConfig(t, "gateway.yaml", `
+versioning:
+  default: latest
+upstreams:
+  product:
+    url: http://product:8081
+routes:
+  - name: products
+    path_prefix: /products
+    upstream: product
+    version: two
+    deprecation:
+      sunset: 2026-12-31
+`)
+
+	_, err := LoadGatewayConfig(path)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{
+		`default "latest" must look like v1`,
+		`version "two" must look like v1`,
+		"versioned path_prefix must be under /api",
+		"deprecation needs since",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error should mention %q:\n%v", want, err)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+)
+
+// Defaults for the GraphQL query limits when GRAPHQL_MAX_DEPTH and
+// GRAPHQL_MAX_COMPLEXITY aren't set.
+const (
+	DefaultGraphQLMaxDepth      = 6
+	DefaultGraphQLMaxComplexity = 1000
+)
+
+// GraphQLLimits reads GRAPHQL_MAX_DEPTH and GRAPHQL_MAX_COMPLEXITY. 0 turns a
+// limit off.
+func GraphQLLimits() (maxDepth, maxComplexity int) {
+	return intEnv("GRAPHQL_MAX_DEPTH", DefaultGraphQLMaxDepth),
+		intEnv("GRAPHQL_MAX_COMPLEXITY", DefaultGraphQLMaxComplexity)
+}
+
+func intEnv(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		log.Fatalf("Invalid %s %q", name, value)
+	}
+	return n
+}
@@ -1,20 +1,43 @@
 package config
 
 import (
+	"crypto/tls"
+	"fmt"
 	"log"
+	"net"
 	"os"
 
+	"gateway-service/internal/metrics"
 	pb "gateway-service/internal/pb"
+	"gateway-service/internal/resilience"
 
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	_ "google.golang.org/grpc/health" // enables client-side health checking
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
 )
 
 type GRPCClients struct {
 	PaymentClient pb.PaymentServiceClient
 	AuthClient    pb.AuthServiceClient // ⏪ tambahkan ini
+
+	// grpc.health.v1 on the same connections, for /readyz and /status
+	PaymentHealth healthpb.HealthClient
+	AuthHealth    healthpb.HealthClient
 }
 
-func NewGRPCClients() *GRPCClients {
+// PAYMENTGRPC_URL and AUTHGRPC_URL accept a comma-separated list of
+// addresses. Calls are spread over them (or over every IP a single DNS name
+// resolves to) with the GRPC_LB_POLICY balancer, round_robin by default.
+//
+// With tlsConfig the connections use TLS (mTLS when it carries a client
+// certificate), otherwise plain text.
+func NewGRPCClients(breakers *resilience.BreakerRegistry, tlsConfig *tls.Config) *GRPCClients {
 	paymentGrpcAddr := os.Getenv("PAYMENTGRPC_URL")
 	authGrpcAddr := os.Getenv("AUTHGRPC_URL")
 
@@ -25,12 +48,22 @@ func NewGRPCClients() *GRPCClients {
 		authGrpcAddr = "auth-service:50052"
 	}
 
-	paymentConn, err := grpc.Dial(paymentGrpcAddr, grpc.WithInsecure())
+	lbPolicy := os.Getenv("GRPC_LB_POLICY")
+	if lbPolicy == "" {
+		lbPolicy = "round_robin"
+	}
+
+	creds := insecure.NewCredentials()
+	if tlsConfig != nil {
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	paymentConn, err := dialUpstream("payment-grpc", LoadUpstream("PAYMENTGRPC", paymentGrpcAddr), lbPolicy, breakers, creds)
 	if err != nil {
 		log.Fatalf("Failed to connect to Payment gRPC: %v", err)
 	}
 
-	authConn, err := grpc.Dial(authGrpcAddr, grpc.WithInsecure())
+	authConn, err := dialUpstream("auth-grpc", LoadUpstream("AUTHGRPC", authGrpcAddr), lbPolicy, breakers, creds)
 	if err != nil {
 		log.Fatalf("Failed to connect to Auth gRPC: %v", err)
 	}
@@ -38,5 +71,49 @@ func NewGRPCClients() *GRPCClients {
 	return &GRPCClients{
 		PaymentClient: pb.NewPaymentServiceClient(paymentConn),
 		AuthClient:    pb.NewAuthServiceClient(authConn), // ⏪ ini penting
+		PaymentHealth: healthpb.NewHealthClient(paymentConn),
+		AuthHealth:    healthpb.NewHealthClient(authConn),
+	}
+}
+
+// dialUpstream dials every target of cfg; each call goes through the
+// upstream's breaker, retry policy and read timeout. Backends that report
+// NOT_SERVING on grpc.health.v1 are skipped by the balancer.
+func dialUpstream(name string, cfg UpstreamConfig, lbPolicy string, breakers *resilience.BreakerRegistry, creds credentials.TransportCredentials) (*grpc.ClientConn, error) {
+	if len(cfg.Targets) == 0 {
+		return nil, fmt.Errorf("%s: no address configured", name)
 	}
+
+	breaker := breakers.Get(name, cfg.Breaker)
+	serviceConfig := fmt.Sprintf(`{"loadBalancingConfig": [{%q: {}}], "healthCheckConfig": {"serviceName": ""}}`, lbPolicy)
+
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultServiceConfig(serviceConfig),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+		grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff:           backoff.DefaultConfig,
+			MinConnectTimeout: cfg.ConnectTimeout,
+		}),
+		grpc.WithUnaryInterceptor(resilience.UnaryClientInterceptor(
+			breaker, cfg.Retry, cfg.ReadTimeout, resilience.ReadOnlyMethods,
+		)),
+		grpc.WithChainUnaryInterceptor(metrics.GRPCClient.UnaryClientInterceptor()),
+	}
+
+	// A single address goes through DNS so every A record becomes a backend
+	if len(cfg.Targets) == 1 {
+		return grpc.Dial("dns:///"+cfg.Targets[0], opts...)
+	}
+
+	r := manual.NewBuilderWithScheme("static-" + name)
+	addrs := make([]resolver.Address, 0, len(cfg.Targets))
+	for _, target := range cfg.Targets {
+		// Certificates are checked against each target's host, not name
+		host, _, _ := net.SplitHostPort(target)
+		addrs = append(addrs, resolver.Address{Addr: target, ServerName: host})
+	}
+	r.InitialState(resolver.State{Addresses: addrs})
+
+	return grpc.Dial(r.Scheme()+":///"+name, append(opts, grpc.WithResolvers(r))...)
 }
@@ -0,0 +1,24 @@
+package config
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// DefaultIdempotencyTTL is how long a response is kept for replay when
+// IDEMPOTENCY_TTL isn't set.
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// IdempotencyTTL reads IDEMPOTENCY_TTL, a duration like "24h" or "30m".
+func IdempotencyTTL() time.Duration {
+	value := os.Getenv("IDEMPOTENCY_TTL")
+	if value == "" {
+		return DefaultIdempotencyTTL
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl <= 0 {
+		log.Fatalf("Invalid IDEMPOTENCY_TTL %q", value)
+	}
+	return ttl
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"log"
+	"os"
+
+	"gateway-service/internal/ratelimit"
+)
+
+// RateLimitConfig holds the token-bucket policy for each route group.
+type RateLimitConfig struct {
+	Auth ratelimit.Policy // /login & /register, per client IP
+	API  ratelimit.Policy // /api/*, per user / API key / IP
+}
+
+// NewRateLimitConfig reads RATE_LIMIT_AUTH and RATE_LIMIT_API, formatted as
+// "<limit>/<period>[,<burst>]" (e.g. "120/1m") or "off".
+func NewRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		Auth: loadPolicy("RATE_LIMIT_AUTH", "10/1m"),
+		API:  loadPolicy("RATE_LIMIT_API", "120/1m"),
+	}
+}
+
+func loadPolicy(env, fallback string) ratelimit.Policy {
+	value, ok := os.LookupEnv(env)
+	if !ok {
+		value = fallback
+	}
+
+	policy, err := ratelimit.ParsePolicy(value)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", env, err)
+	}
+	return policy
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gateway-service/internal/balancer"
+	"gateway-service/internal/resilience"
+)
+
+// UpstreamConfig holds the targets, timeouts, retry policy and breaker
+// settings for one upstream service.
+type UpstreamConfig struct {
+	Name           string
+	Targets        []string
+	Strategy       string
+	HealthCheck    balancer.HealthCheck
+	ConnectTimeout time.Duration
+	ReadTimeout    time.Duration
+	Retry          resilience.RetryPolicy
+	Breaker        resilience.BreakerSettings
+}
+
+// DefaultUpstream returns the settings used when nothing is configured.
+func DefaultUpstream(name string, targets ...string) UpstreamConfig {
+	return UpstreamConfig{
+		Name:           name,
+		Targets:        targets,
+		Strategy:       balancer.RoundRobin,
+		ConnectTimeout: 2 * time.Second,
+		ReadTimeout:    10 * time.Second,
+		Retry: resilience.RetryPolicy{
+			MaxAttempts: 3,
+			BaseDelay:   100 * time.Millisecond,
+			MaxDelay:    time.Second,
+		},
+		Breaker: resilience.BreakerSettings{
+			FailureThreshold: 5,
+			OpenTimeout:      30 * time.Second,
+			HalfOpenRequests: 1,
+		},
+	}
+}
+
+// LoadUpstream reads the config for upstream name (e.g. "PAYMENTGRPC") from
+// env. targets is a comma-separated list of addresses. Every setting can be
+// overridden with <NAME>_CONNECT_TIMEOUT, <NAME>_READ_TIMEOUT,
+// <NAME>_RETRIES, <NAME>_BREAKER_FAILURES and <NAME>_BREAKER_OPEN_TIMEOUT.
+func LoadUpstream(name, targets string) UpstreamConfig {
+	cfg := DefaultUpstream(name, splitList(targets)...)
+	cfg.ConnectTimeout = envDuration(name+"_CONNECT_TIMEOUT", cfg.ConnectTimeout)
+	cfg.ReadTimeout = envDuration(name+"_READ_TIMEOUT", cfg.ReadTimeout)
+	cfg.Retry.MaxAttempts = envInt(name+"_RETRIES", cfg.Retry.MaxAttempts-1) + 1
+	cfg.Breaker.FailureThreshold = envInt(name+"_BREAKER_FAILURES", cfg.Breaker.FailureThreshold)
+	cfg.Breaker.OpenTimeout = envDuration(name+"_BREAKER_OPEN_TIMEOUT", cfg.Breaker.OpenTimeout)
+	return cfg
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", key, err)
+	}
+	return d
+}
+
+func envInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		log.Fatalf("Invalid %s: %q", key, value)
+	}
+	return n
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// WatchGatewayConfig calls reload on SIGHUP and whenever the file at path
+// changes. The file is polled every interval rather than watched with
+// inotify, which misses the symlink swaps used by mounted config volumes.
+func WatchGatewayConfig(path string, interval time.Duration, reload func()) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		last := fileVersion(path)
+		for {
+			select {
+			case <-hup:
+				log.Println("[CONFIG] SIGHUP received, reloading", path)
+				last = fileVersion(path)
+				reload()
+			case <-ticker.C:
+				if current := fileVersion(path); current != last {
+					log.Println("[CONFIG] change detected, reloading", path)
+					last = current
+					reload()
+				}
+			}
+		}
+	}()
+}
+
+type version struct {
+	modTime time.Time
+	size    int64
+}
+
+func fileVersion(path string) version {
+	info, err := os.Stat(path)
+	if err != nil {
+		return version{}
+	}
+	return version{modTime: info.ModTime(), size: info.Size()}
+}
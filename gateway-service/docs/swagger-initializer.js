@@ -1,6 +1,6 @@
 window.onload = () => {
   window.ui = SwaggerUIBundle({
-    url: "openapi.yaml",
+    url: "openapi.json",
     dom_id: '#swagger-ui',
     presets: [
       SwaggerUIBundle.presets.apis,
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"gateway-service/internal/ratelimit"
+	"gateway-service/middleware"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AdminHandler serves the parts of the admin API that look into the route
+// table and the rate limiter.
+type AdminHandler struct {
+	router  *Router
+	limiter *ratelimit.MemoryStore
+}
+
+func NewAdminHandler(router *Router, limiter *ratelimit.MemoryStore) *AdminHandler {
+	return &AdminHandler{router: router, limiter: limiter}
+}
+
+// Routes lists the active routes as configured.
+func (h *AdminHandler) Routes(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]interface{}{"routes": h.router.Routes()})
+}
+
+// RateLimits reports the decisions of every rate limit group so far and how
+// many client buckets are being tracked.
+func (h *AdminHandler) RateLimits(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"buckets": h.limiter.Len(),
+		"groups":  middleware.RateLimitCounts(),
+	})
+}
+
+// Reload reads the gateway config again, as SIGHUP does. A config that
+// doesn't load is reported and the current routes stay.
+func (h *AdminHandler) Reload(c echo.Context) error {
+	log.Println("[ADMIN] reload requested")
+	if err := h.router.Reload(); err != nil {
+		log.Printf("[CONFIG] reload failed, keeping current routes: %v", err)
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"routes": len(h.router.Routes())})
+}
@@ -0,0 +1,318 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gateway-service/config"
+	"gateway-service/internal/cache"
+	"gateway-service/internal/metrics"
+	"gateway-service/middleware"
+
+	"github.com/labstack/echo/v4"
+)
+
+// cacheMaxBody is the largest response worth caching; bigger ones are
+// passed through.
+const cacheMaxBody = 1 << 20
+
+// HeaderXCache tells clients how a GET was answered: HIT, STALE, MISS or
+// BYPASS.
+const HeaderXCache = "X-Cache"
+
+const headerETag = "ETag"
+
+// cacheResponses serves the route's GETs from the response cache when it has
+// cache configured, and invalidates the cache after every successful
+// mutation through it.
+func (r *Router) cacheResponses(rt *route, spec *config.CacheSpec) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			switch req.Method {
+			case http.MethodGet:
+			case http.MethodHead, http.MethodOptions:
+				return next(c)
+			default:
+				err := next(c)
+				if err == nil && c.Response().Status < http.StatusBadRequest {
+					if ierr := r.cache.Invalidate(req.Context(), req.URL.Path); ierr != nil {
+						log.Printf("[CACHE] invalidate %s: %v", req.URL.Path, ierr)
+					}
+				}
+				return err
+			}
+			if spec == nil {
+				return next(c)
+			}
+
+			directives := parseCacheControl(req.Header.Get(echo.HeaderCacheControl))
+			if _, ok := directives["no-store"]; ok {
+				metrics.CacheRequests.WithLabelValues(rt.Name, "bypass").Inc()
+				c.Response().Header().Set(HeaderXCache, "BYPASS")
+				return next(c)
+			}
+
+			key := rt.Name + "|" + req.URL.Path + "?" + req.URL.Query().Encode()
+			if spec.PerUser {
+				key += "|" + middleware.IdentityKey(c)
+			}
+			if v := versionOf(req); v != nil {
+				key += "|version:" + v.name
+			}
+			balanceKey := middleware.UserID(c)
+
+			// no-cache: the client wants a response checked with the upstream
+			if _, ok := directives["no-cache"]; !ok {
+				entry, err := r.cache.Get(req.Context(), key)
+				if err != nil {
+					log.Printf("[CACHE] get %s: %v", key, err)
+				}
+				if entry != nil {
+					if entry.Fresh(time.Now()) {
+						return serveCached(c, rt, spec, entry, "HIT")
+					}
+					r.refreshInBackground(rt, spec, key, req, balanceKey)
+					return serveCached(c, rt, spec, entry, "STALE")
+				}
+			}
+
+			rec, entry := r.fetchForCache(rt, spec, key, req, balanceKey, c.Response())
+			if entry != nil {
+				return serveCached(c, rt, spec, entry, "MISS")
+			}
+			metrics.CacheRequests.WithLabelValues(rt.Name, "miss").Inc()
+			if rec.overflow {
+				// Already passed through to the client
+				return nil
+			}
+			rec.sendHeader(c.Response())
+			_, err := c.Response().Write(rec.body.Bytes())
+			return err
+		}
+	}
+}
+
+// fetchForCache gets a full response from the upstream and stores it when
+// it may be cached. entry is nil when it wasn't stored. A response too big to
+// cache goes on to client as it comes, or nowhere when client is nil.
+func (r *Router) fetchForCache(rt *route, spec *config.CacheSpec, key string, req *http.Request, balanceKey string, client http.ResponseWriter) (*recordedResponse, *cache.Entry) {
+	// The client's validators are ours to answer; the upstream has to send
+	// the body
+	req = req.Clone(req.Context())
+	req.Header.Del("If-None-Match")
+	req.Header.Del("If-Modified-Since")
+
+	rec := &recordedResponse{header: make(http.Header), status: http.StatusOK, client: client}
+	rt.serve(rec, req, balanceKey)
+
+	entry := newCacheEntry(rec, spec, req.URL.Path, time.Now())
+	if entry == nil {
+		return rec, nil
+	}
+	if err := r.cache.Set(req.Context(), key, entry); err != nil {
+		log.Printf("[CACHE] set %s: %v", key, err)
+	}
+	return rec, entry
+}
+
+// refreshInBackground re-fetches a stale entry unless a refresh of it is
+// already running. The stale entry stays in place if the refresh fails.
+func (r *Router) refreshInBackground(rt *route, spec *config.CacheSpec, key string, req *http.Request, balanceKey string) {
+	if _, busy := r.refreshing.LoadOrStore(key, struct{}{}); busy {
+		return
+	}
+	req = req.Clone(context.WithoutCancel(req.Context()))
+	go func() {
+		defer r.refreshing.Delete(key)
+		r.fetchForCache(rt, spec, key, req, balanceKey, nil)
+	}()
+}
+
+// newCacheEntry decides whether rec may be cached, honouring the upstream's
+// Cache-Control, and for how long.
+func newCacheEntry(rec *recordedResponse, spec *config.CacheSpec, path string, now time.Time) *cache.Entry {
+	if rec.status != http.StatusOK || rec.overflow || rec.header.Get("Set-Cookie") != "" {
+		return nil
+	}
+	directives := parseCacheControl(rec.header.Get(echo.HeaderCacheControl))
+	if _, ok := directives["no-store"]; ok {
+		return nil
+	}
+	if _, ok := directives["no-cache"]; ok {
+		return nil
+	}
+	if _, ok := directives["private"]; ok && !spec.PerUser {
+		return nil
+	}
+
+	ttl, swr := spec.TTL, spec.StaleWhileRevalidate
+	if seconds, ok := directiveSeconds(directives, "s-maxage", "max-age"); ok {
+		ttl = seconds
+	}
+	if seconds, ok := directiveSeconds(directives, "stale-while-revalidate"); ok {
+		swr = seconds
+	}
+	if ttl <= 0 {
+		return nil
+	}
+
+	body := append([]byte(nil), rec.body.Bytes()...)
+	etag := rec.header.Get(headerETag)
+	if etag == "" {
+		sum := sha256.Sum256(body)
+		etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+	}
+
+	header := rec.header.Clone()
+	for _, name := range []string{echo.HeaderCacheControl, headerETag, "Age", echo.HeaderContentLength} {
+		header.Del(name)
+	}
+	return &cache.Entry{
+		Status:     rec.status,
+		Header:     header,
+		Body:       body,
+		ETag:       etag,
+		Path:       path,
+		StoredAt:   now,
+		FreshUntil: now.Add(ttl),
+		StaleUntil: now.Add(ttl + swr),
+	}
+}
+
+func serveCached(c echo.Context, rt *route, spec *config.CacheSpec, entry *cache.Entry, result string) error {
+	metrics.CacheRequests.WithLabelValues(rt.Name, strings.ToLower(result)).Inc()
+
+	now := time.Now()
+	maxAge := 0
+	if entry.Fresh(now) {
+		maxAge = int(entry.FreshUntil.Sub(now).Seconds())
+	}
+	scope := "public"
+	if spec.PerUser {
+		scope = "private"
+	}
+
+	h := c.Response().Header()
+	h.Set(headerETag, entry.ETag)
+	h.Set(echo.HeaderCacheControl, scope+", max-age="+strconv.Itoa(maxAge))
+	h.Set("Age", strconv.Itoa(int(now.Sub(entry.StoredAt).Seconds())))
+	h.Set(HeaderXCache, result)
+
+	if etagMatches(c.Request().Header.Get("If-None-Match"), entry.ETag) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	for name, values := range entry.Header {
+		h[name] = append([]string(nil), values...)
+	}
+	return c.Blob(entry.Status, entry.Header.Get(echo.HeaderContentType), entry.Body)
+}
+
+// etagMatches implements the weak comparison If-None-Match asks for.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCacheControl splits a Cache-Control header into lower-case
+// directives and their (unquoted) values.
+func parseCacheControl(header string) map[string]string {
+	out := make(map[string]string)
+	for _, part := range strings.Split(header, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name == "" {
+			continue
+		}
+		out[strings.ToLower(name)] = strings.Trim(value, `"`)
+	}
+	return out
+}
+
+// directiveSeconds returns the first of names that is set to a number of
+// seconds.
+func directiveSeconds(directives map[string]string, names ...string) (time.Duration, bool) {
+	for _, name := range names {
+		if value, ok := directives[name]; ok {
+			if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+				return time.Duration(n) * time.Second, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// recordedResponse is an in-memory http.ResponseWriter for responses that
+// are cached before anyone sees them. Past cacheMaxBody it stops buffering
+// and passes the response through to client.
+type recordedResponse struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+	overflow    bool
+	client      http.ResponseWriter // nil drops what can't be cached
+}
+
+func (r *recordedResponse) Header() http.Header { return r.header }
+
+func (r *recordedResponse) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status, r.wroteHeader = status, true
+	}
+}
+
+func (r *recordedResponse) Write(b []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	if !r.overflow && r.body.Len()+len(b) > cacheMaxBody {
+		r.overflow = true
+		buffered := r.body.Bytes()
+		r.body = bytes.Buffer{}
+		if r.client != nil {
+			r.sendHeader(r.client)
+			if _, err := r.client.Write(buffered); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if r.overflow {
+		if r.client == nil {
+			return len(b), nil
+		}
+		return r.client.Write(b)
+	}
+	return r.body.Write(b)
+}
+
+// Flush passes through once the response goes to the client; until then
+// there is nothing to flush.
+func (r *recordedResponse) Flush() {
+	if r.overflow && r.client != nil {
+		http.NewResponseController(r.client).Flush()
+	}
+}
+
+// sendHeader writes the recorded header and status to w as a cache miss.
+func (r *recordedResponse) sendHeader(w http.ResponseWriter) {
+	h := w.Header()
+	for name, values := range r.header {
+		h[name] = append([]string(nil), values...)
+	}
+	h.Set(HeaderXCache, "MISS")
+	w.WriteHeader(r.status)
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecordedResponseOverflow(t *testing.T) {
+	chunk := bytes.Repeat([]byte("x"), cacheMaxBody/2+1)
+
+	client := httptest.NewRecorder()
+	rec := &recordedResponse{header: make(http.Header), status: http.StatusOK, client: client}
+	rec.Header().Set("Content-Type", "text/plain")
+	for i := 0; i < 3; i++ {
+		if n, err := rec.Write(chunk); err != nil || n != len(chunk) {
+			t.Fatalf("write %d: %d, %v", i, n, err)
+		}
+	}
+	if !rec.overflow || rec.body.Len() != 0 {
+		t.Errorf("overflow %v, %d bytes still buffered", rec.overflow, rec.body.Len())
+	}
+	if client.Body.Len() != 3*len(chunk) {
+		t.Errorf("client got %d bytes, want %d", client.Body.Len(), 3*len(chunk))
+	}
+	if client.Header().Get(HeaderXCache) != "MISS" || client.Header().Get("Content-Type") != "text/plain" {
+		t.Errorf("client header %v", client.Header())
+	}
+
+	// A background refresh has no one to pass to
+	rec = &recordedResponse{header: make(http.Header), status: http.StatusOK}
+	for i := 0; i < 3; i++ {
+		rec.Write(chunk)
+	}
+	if !rec.overflow || rec.body.Len() != 0 {
+		t.Errorf("overflow %v, %d bytes still buffered", rec.overflow, rec.body.Len())
+	}
+}
@@ -0,0 +1,263 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gateway-service/internal/apidocs"
+	pb "gateway-service/internal/pb"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// docsTTL is how long a merged spec is served before the services are
+	// asked again; docsRetry is used instead when one of them didn't answer.
+	docsTTL   = 5 * time.Minute
+	docsRetry = 15 * time.Second
+
+	docsFetchTimeout = 5 * time.Second
+)
+
+// DocsHandler serves GET /docs/openapi.json: the gateway's own endpoints from
+// docs/openapi.yaml merged with the swagger doc of every upstream and of the
+// gRPC-mapped routes, rewritten to the paths clients call on the gateway.
+// The spec is rebuilt in the background; callers get the last good one
+// meanwhile and only wait when there is none yet.
+type DocsHandler struct {
+	router   *Router
+	basePath string
+	server   string
+
+	mu       sync.Mutex
+	spec     *openapi3.T
+	body     []byte
+	expires  time.Time
+	err      error         // of the last build, while there is no spec
+	building chan struct{} // closed when the build in flight is done
+}
+
+// NewDocsHandler reads the base document from basePath. serverURL goes into
+// servers; empty means "/", so Swagger UI calls whatever host served it.
+func NewDocsHandler(router *Router, basePath, serverURL string) *DocsHandler {
+	if serverURL == "" {
+		serverURL = "/"
+	}
+	return &DocsHandler{router: router, basePath: basePath, server: serverURL}
+}
+
+func (h *DocsHandler) Serve(c echo.Context) error {
+	_, body, err := h.load(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSONBlob(http.StatusOK, body)
+}
+
+// Spec returns the merged document, starting a rebuild when it has expired.
+func (h *DocsHandler) Spec(ctx context.Context) (*openapi3.T, error) {
+	spec, _, err := h.load(ctx)
+	return spec, err
+}
+
+// Refresh starts building the spec without waiting for it, so the first
+// request finds it ready.
+func (h *DocsHandler) Refresh() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.rebuild()
+}
+
+func (h *DocsHandler) load(ctx context.Context) (*openapi3.T, []byte, error) {
+	h.mu.Lock()
+	if h.spec != nil {
+		if !time.Now().Before(h.expires) {
+			h.rebuild()
+		}
+		spec, body := h.spec, h.body
+		h.mu.Unlock()
+		return spec, body, nil
+	}
+	done := h.rebuild()
+	h.mu.Unlock()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.spec == nil {
+		return nil, nil, h.err
+	}
+	return h.spec, h.body, nil
+}
+
+// rebuild starts a build unless one is in flight and returns the channel
+// closed when it is done. h.mu must be held.
+func (h *DocsHandler) rebuild() <-chan struct{} {
+	if h.building != nil {
+		return h.building
+	}
+	done := make(chan struct{})
+	h.building = done
+
+	go func() {
+		// Not the caller's context: the build outlives the request that
+		// started it. Every fetch has its own timeout.
+		spec, complete, err := h.build(context.Background())
+		var body []byte
+		if err == nil {
+			body, err = json.Marshal(spec)
+		}
+
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		defer close(done)
+		h.building = nil
+
+		if err != nil {
+			// Keep serving the last good document rather than nothing
+			if h.spec != nil {
+				log.Printf("[DOCS] rebuild failed, serving previous spec: %v", err)
+				h.expires = time.Now().Add(docsRetry)
+			}
+			h.err = err
+			return
+		}
+		h.spec, h.body, h.err = spec, body, nil
+		h.expires = time.Now().Add(docsTTL)
+		if !complete {
+			h.expires = time.Now().Add(docsRetry)
+		}
+	}()
+	return done
+}
+
+// build merges everything into a fresh copy of the base document. complete
+// is false when a service's doc couldn't be fetched or converted; those are
+// listed under x-unavailable.
+func (h *DocsHandler) build(ctx context.Context) (*openapi3.T, bool, error) {
+	spec, err := openapi3.NewLoader().LoadFromFile(h.basePath)
+	if err != nil {
+		return nil, false, fmt.Errorf("load %s: %w", h.basePath, err)
+	}
+	spec.Servers = openapi3.Servers{{URL: h.server}}
+
+	sources := []apidocs.Source{
+		{Name: "payment_grpc", Swagger: pb.PaymentSwagger, Path: grpcDocsPath},
+		{Name: "auth", Swagger: pb.AuthSwagger, Path: grpcDocsPath},
+	}
+
+	table := h.router.table.Load()
+	names := make([]string, 0, len(table.config.Upstreams))
+	for name := range table.config.Upstreams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var unavailable []string
+	for _, name := range names {
+		docs := table.config.Upstreams[name].Docs
+		if docs == "" {
+			continue
+		}
+		fetchCtx, cancel := context.WithTimeout(ctx, docsFetchTimeout)
+		body, err := h.router.getJSON(fetchCtx, name, docs)
+		cancel()
+		if err != nil {
+			unavailable = append(unavailable, name+": "+err.Error())
+			continue
+		}
+		sources = append(sources, apidocs.Source{Name: name, Swagger: body, Path: table.docsPath(name)})
+	}
+
+	if err := apidocs.Merge(spec, sources); err != nil {
+		var joined interface{ Unwrap() []error }
+		if errors.As(err, &joined) {
+			for _, e := range joined.Unwrap() {
+				unavailable = append(unavailable, e.Error())
+			}
+		} else {
+			unavailable = append(unavailable, err.Error())
+		}
+	}
+
+	if len(unavailable) > 0 {
+		log.Printf("[DOCS] incomplete spec: %s", strings.Join(unavailable, "; "))
+		if spec.Extensions == nil {
+			spec.Extensions = make(map[string]any)
+		}
+		spec.Extensions["x-unavailable"] = unavailable
+	}
+	return spec, len(unavailable) == 0, nil
+}
+
+// grpcDocsPath maps the transcoded RPCs. Their google.api.http paths already
+// are gateway paths; only register and login are served without a token.
+func grpcDocsPath(method, path string) (string, bool, bool) {
+	return path, path == "/register" || path == "/login", true
+}
+
+// docsPath maps the service paths of upstream to the gateway. A path is kept
+// when undoing a route's add_prefix and strip_prefix gives a gateway path
+// that this table would route back to that same route. Versioned routes are
+// listed under their version's path (/api/v2/products).
+func (t *routeTable) docsPath(upstream string) apidocs.PathFunc {
+	return func(method, path string) (string, bool, bool) {
+		for _, rt := range t.routes {
+			if rt.Upstream != upstream {
+				continue
+			}
+			rest, ok := strings.CutPrefix(path, rt.AddPrefix)
+			if !ok {
+				continue
+			}
+			gatewayPath := rt.StripPrefix + rest
+			version := rt.Version
+			if version == "" && t.versions != nil {
+				version = t.versioning.Default
+			}
+			if m, _ := t.match(method, gatewayPath, version); m != rt {
+				continue
+			}
+			if rt.Version != "" {
+				prefix := t.versioning.Prefix
+				gatewayPath = prefix + "/" + rt.Version + strings.TrimPrefix(gatewayPath, prefix)
+			}
+			return gatewayPath, !rt.RequiresAuth(t.config.Defaults), true
+		}
+		return "", false, false
+	}
+}
+
+// match returns the route Handle would pick for a request of version, nil
+// if there is none. pathMatched reports a route for the path that doesn't
+// take method.
+func (t *routeTable) match(method, path, version string) (rt *route, pathMatched bool) {
+	for _, rt := range t.routes {
+		if rt.Version != "" && rt.Version != version {
+			continue
+		}
+		if !matchPrefix(path, rt.PathPrefix) {
+			continue
+		}
+		if rt.methods != nil && !rt.methods[method] {
+			pathMatched = true
+			continue
+		}
+		return rt, false
+	}
+	return nil, pathMatched
+}
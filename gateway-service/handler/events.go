@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	eventsHeartbeat  = 15 * time.Second
+	eventsRetry      = 3 * time.Second // what browsers wait before reconnecting
+	eventsMaxBackoff = 30 * time.Second
+)
+
+// eventSources are the upstreams whose /events streams GET /api/events
+// merges, in the order their cursors appear in the event ID.
+var eventSources = []string{"transaction", "payment"}
+
+// EventsHandler serves GET /api/events: the status changes of the caller's
+// transactions and payments as one Server-Sent Events stream.
+//
+// Each service streams its own events at /events?email=; the gateway follows
+// both and reconnects when one drops. The ID sent to the client holds both
+// cursors ("<transaction>.<payment>"), so a client reconnecting with
+// Last-Event-ID resumes both streams where it left off.
+type EventsHandler struct {
+	router *Router
+}
+
+// sseMessage is one message read from an upstream stream. Data is empty for
+// a message that only moves the cursor.
+type sseMessage struct {
+	source int
+	ID     string
+	Event  string
+	Data   string
+}
+
+func NewEventsHandler(router *Router) *EventsHandler {
+	return &EventsHandler{router: router}
+}
+
+func (h *EventsHandler) Stream(c echo.Context) error {
+	claims, _ := c.Get("user").(jwt.MapClaims)
+	email, _ := claims["email"].(string)
+	if email == "" {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Token has no email claim"})
+	}
+
+	cursors, err := parseEventID(c.Request().Header.Get("Last-Event-ID"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set("X-Accel-Buffering", "no") // keep nginx from holding events back
+	res.WriteHeader(http.StatusOK)
+	fmt.Fprintf(res, "retry: %d\n\n", eventsRetry.Milliseconds())
+	res.Flush()
+
+	ctx, cancel := context.WithCancel(c.Request().Context())
+	defer cancel()
+
+	messages := make(chan sseMessage)
+	for i, source := range eventSources {
+		go h.follow(ctx, i, source, email, cursors[i], messages)
+	}
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-heartbeat.C:
+			fmt.Fprint(res, ": heartbeat\n\n")
+			res.Flush()
+		case msg := <-messages:
+			cursors[msg.source] = msg.ID
+			fmt.Fprintf(res, "id: %s\n", strings.Join(cursors, "."))
+			if msg.Data != "" {
+				if msg.Event != "" {
+					fmt.Fprintf(res, "event: %s\n", msg.Event)
+				}
+				for _, line := range strings.Split(msg.Data, "\n") {
+					fmt.Fprintf(res, "data: %s\n", line)
+				}
+			}
+			fmt.Fprint(res, "\n")
+			res.Flush()
+		}
+	}
+}
+
+// follow streams one upstream's events for email into out until ctx is
+// done, reconnecting from the last cursor seen with exponential backoff.
+func (h *EventsHandler) follow(ctx context.Context, index int, source, email, cursor string, out chan<- sseMessage) {
+	backoff := time.Second
+	for {
+		connected, err := h.stream(ctx, index, source, email, &cursor, out)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			backoff = time.Second
+		}
+		var ue *upstreamError
+		if errors.As(err, &ue) && ue.status == http.StatusBadRequest && cursor != "" {
+			// A cursor the upstream doesn't know: better to go on from now
+			cursor = ""
+		}
+		log.Printf("[EVENTS] %s stream ended: %v; reconnecting in %s", source, err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, eventsMaxBackoff)
+	}
+}
+
+// stream reads one connection to source's event stream. connected reports
+// whether the upstream accepted it.
+func (h *EventsHandler) stream(ctx context.Context, index int, source, email string, cursor *string, out chan<- sseMessage) (connected bool, err error) {
+	u := h.router.upstream(source)
+	if u == nil {
+		return false, fmt.Errorf("upstream %q not configured", source)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/events?email="+url.QueryEscape(email), nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set(echo.HeaderAccept, "text/event-stream")
+	if *cursor != "" {
+		req.Header.Set("Last-Event-ID", *cursor)
+	}
+
+	resp, err := u.RoundTrip(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, &upstreamError{status: resp.StatusCode}
+	}
+
+	err = readSSE(resp.Body, func(msg sseMessage) {
+		if msg.ID == "" || msg.ID == *cursor {
+			return
+		}
+		*cursor = msg.ID
+		msg.source = index
+		select {
+		case out <- msg:
+		case <-ctx.Done():
+		}
+	})
+	if err == nil {
+		err = errors.New("closed by upstream")
+	}
+	return true, err
+}
+
+// readSSE calls fn for every message of an event stream. Comments are
+// skipped; an ID-only message is passed on, it moves the cursor.
+func readSSE(body io.Reader, fn func(sseMessage)) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 4096), 1<<20)
+
+	var (
+		msg  sseMessage
+		data []string
+		seen bool
+	)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if seen {
+				msg.Data = strings.Join(data, "\n")
+				fn(msg)
+			}
+			msg, data, seen = sseMessage{}, nil, false
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			msg.ID, seen = value, true
+		case "event":
+			msg.Event, seen = value, true
+		case "data":
+			data, seen = append(data, value), true
+		}
+	}
+	return scanner.Err()
+}
+
+// parseEventID splits a Last-Event-ID sent by a client into one cursor per
+// event source; "" for a source means "from now".
+func parseEventID(id string) ([]string, error) {
+	cursors := make([]string, len(eventSources))
+	if id == "" {
+		return cursors, nil
+	}
+	parts := strings.Split(id, ".")
+	if len(parts) != len(eventSources) {
+		return nil, errors.New("Invalid Last-Event-ID")
+	}
+	copy(cursors, parts)
+	return cursors, nil
+}
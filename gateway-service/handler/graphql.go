@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"gateway-service/config"
+	"gateway-service/internal/gql"
+	pb "gateway-service/internal/pb"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/graphql-go/graphql"
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// graphqlCallTimeout bounds each backend call a GraphQL query makes.
+const graphqlCallTimeout = 5 * time.Second
+
+// GraphQLHandler serves /api/graphql: products and transactions from their
+// REST upstreams, payments over gRPC.
+type GraphQLHandler struct {
+	schema  graphql.Schema
+	backend gql.Backend
+	limits  gql.Limits
+}
+
+func NewGraphQLHandler(router *Router, grpcClients *config.GRPCClients, limits gql.Limits) (*GraphQLHandler, error) {
+	schema, err := gql.NewSchema()
+	if err != nil {
+		return nil, err
+	}
+	return &GraphQLHandler{
+		schema:  schema,
+		backend: &graphqlBackend{router: router, grpc: grpcClients},
+		limits:  limits,
+	}, nil
+}
+
+// Serve takes the query as a JSON body on POST, or as the query,
+// operationName and variables parameters on GET. Queries that don't parse,
+// aren't valid or go over the limits get a 400; errors while executing come
+// with a 200 next to whatever data could be resolved.
+func (h *GraphQLHandler) Serve(c echo.Context) error {
+	claims, _ := c.Get("user").(jwt.MapClaims)
+	email, _ := claims["email"].(string)
+	if email == "" {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Token has no email claim"})
+	}
+
+	var params gql.Params
+	if c.Request().Method == http.MethodGet {
+		params.Query = c.QueryParam("query")
+		params.OperationName = c.QueryParam("operationName")
+		if variables := c.QueryParam("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &params.Variables); err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid variables"})
+			}
+		}
+	} else if err := json.NewDecoder(c.Request().Body).Decode(&params); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	if params.Query == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing query"})
+	}
+
+	ctx := gql.WithRequest(c.Request().Context(), h.backend, email)
+	result, executed := gql.Execute(ctx, h.schema, h.limits, params)
+	if !executed {
+		return c.JSON(http.StatusBadRequest, result)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// graphqlBackend is gql.Backend on the gateway's upstreams.
+type graphqlBackend struct {
+	router *Router
+	grpc   *config.GRPCClients
+}
+
+func (b *graphqlBackend) Products(ctx context.Context) ([]*gql.Product, error) {
+	var products []*gql.Product
+	return products, b.get(ctx, "product", "/products", &products)
+}
+
+func (b *graphqlBackend) Product(ctx context.Context, id string) (*gql.Product, error) {
+	var product *gql.Product
+	return product, b.get(ctx, "product", "/products/"+url.PathEscape(id), &product)
+}
+
+func (b *graphqlBackend) Transactions(ctx context.Context) ([]*gql.Transaction, error) {
+	var transactions []*gql.Transaction
+	return transactions, b.get(ctx, "transaction", "/transactions", &transactions)
+}
+
+func (b *graphqlBackend) Transaction(ctx context.Context, id string) (*gql.Transaction, error) {
+	var transaction *gql.Transaction
+	return transaction, b.get(ctx, "transaction", "/transactions/"+url.PathEscape(id), &transaction)
+}
+
+func (b *graphqlBackend) Payments(ctx context.Context) ([]*gql.Payment, error) {
+	ctx, cancel := context.WithTimeout(ctx, graphqlCallTimeout)
+	defer cancel()
+
+	list, err := b.grpc.PaymentClient.GetAllPayments(ctx, &pb.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	payments := make([]*gql.Payment, len(list.Payments))
+	for i, p := range list.Payments {
+		payments[i] = paymentFromProto(p)
+	}
+	return payments, nil
+}
+
+func (b *graphqlBackend) Payment(ctx context.Context, id string) (*gql.Payment, error) {
+	ctx, cancel := context.WithTimeout(ctx, graphqlCallTimeout)
+	defer cancel()
+
+	payment, err := b.grpc.PaymentClient.GetPaymentByID(ctx, &pb.GetByIDRequest{Id: id})
+	if code := status.Code(err); code == codes.NotFound || code == codes.InvalidArgument {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return paymentFromProto(payment), nil
+}
+
+// get decodes path of a REST upstream into v, leaving v alone when the
+// upstream says the ID doesn't exist or isn't one.
+func (b *graphqlBackend) get(ctx context.Context, upstream, path string, v any) error {
+	ctx, cancel := context.WithTimeout(ctx, graphqlCallTimeout)
+	defer cancel()
+
+	body, err := b.router.getJSON(ctx, upstream, path)
+	var ue *upstreamError
+	if errors.As(err, &ue) && (ue.status == http.StatusNotFound || ue.status == http.StatusBadRequest) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+func paymentFromProto(p *pb.Payment) *gql.Payment {
+	return &gql.Payment{
+		ID:        p.Id,
+		Email:     p.Email,
+		Amount:    gql.Money{AmountMinor: p.AmountMinor, Currency: p.Currency},
+		Status:    paymentStatusName(p.Status),
+		CreatedAt: p.CreatedAt,
+	}
+}
+
+// paymentStatusName is the name payment-service's REST API uses for s,
+// e.g. partially_refunded for PAYMENT_STATUS_PARTIALLY_REFUNDED.
+func paymentStatusName(s pb.PaymentStatus) string {
+	return strings.ToLower(strings.TrimPrefix(s.String(), "PAYMENT_STATUS_"))
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"gateway-service/config"
+	pb "gateway-service/internal/pb"
+
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// orderPartTimeout bounds each backend call of an order lookup.
+const orderPartTimeout = 5 * time.Second
+
+// OrderHandler serves GET /api/orders/:id: the transaction, then its product
+// (REST) and payment (gRPC) fetched concurrently, merged into one document.
+type OrderHandler struct {
+	router *Router
+	grpc   *config.GRPCClients
+}
+
+// Order is the merged document. Product and Payment are null when they could
+// not be loaded; Errors then says why, keyed by part.
+type Order struct {
+	ID          string            `json:"id"`
+	Transaction json.RawMessage   `json:"transaction"`
+	Product     json.RawMessage   `json:"product"`
+	Payment     json.RawMessage   `json:"payment"`
+	Partial     bool              `json:"partial"`
+	Errors      map[string]string `json:"errors,omitempty"`
+}
+
+// upstreamError is a non-2xx answer from a REST upstream.
+type upstreamError struct {
+	status int
+	body   string
+}
+
+func (e *upstreamError) Error() string {
+	return fmt.Sprintf("upstream returned %d: %s", e.status, e.body)
+}
+
+func NewOrderHandler(router *Router, grpcClients *config.GRPCClients) *OrderHandler {
+	return &OrderHandler{router: router, grpc: grpcClients}
+}
+
+func (h *OrderHandler) Get(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	// The transaction is the order itself: without it there is nothing to merge
+	txn, err := h.fetch(ctx, "transaction", "/transactions/"+url.PathEscape(id))
+	if err != nil {
+		var ue *upstreamError
+		if errors.As(err, &ue) && ue.status == http.StatusNotFound {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Order not found"})
+		}
+		if errors.As(err, &ue) && ue.status < http.StatusInternalServerError {
+			return c.JSON(ue.status, map[string]string{"error": "Invalid order ID"})
+		}
+		return c.JSON(http.StatusBadGateway, map[string]string{"error": "Failed to load transaction: " + err.Error()})
+	}
+
+	var refs struct {
+		ProductID string `json:"product_id"`
+		PaymentID string `json:"payment_id"`
+	}
+	if err := json.Unmarshal(txn, &refs); err != nil {
+		return c.JSON(http.StatusBadGateway, map[string]string{"error": "Invalid transaction from upstream"})
+	}
+
+	order := Order{ID: id, Transaction: txn, Errors: make(map[string]string)}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	load := func(part string, fn func() (json.RawMessage, error)) {
+		defer wg.Done()
+		body, err := fn()
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			order.Errors[part] = err.Error()
+			return
+		}
+		switch part {
+		case "product":
+			order.Product = body
+		case "payment":
+			order.Payment = body
+		}
+	}
+
+	wg.Add(2)
+	go load("product", func() (json.RawMessage, error) {
+		return h.fetch(ctx, "product", "/products/"+url.PathEscape(refs.ProductID))
+	})
+	go load("payment", func() (json.RawMessage, error) {
+		return h.fetchPayment(ctx, refs.PaymentID)
+	})
+	wg.Wait()
+
+	if len(order.Errors) == 0 {
+		order.Errors = nil
+	}
+	order.Partial = order.Errors != nil
+	return c.JSON(http.StatusOK, order)
+}
+
+// fetch GETs path from a REST upstream of the route table.
+func (h *OrderHandler) fetch(ctx context.Context, upstream, path string) (json.RawMessage, error) {
+	ctx, cancel := context.WithTimeout(ctx, orderPartTimeout)
+	defer cancel()
+	return h.router.getJSON(ctx, upstream, path)
+}
+
+func (h *OrderHandler) fetchPayment(ctx context.Context, id string) (json.RawMessage, error) {
+	ctx, cancel := context.WithTimeout(ctx, orderPartTimeout)
+	defer cancel()
+
+	payment, err := h.grpc.PaymentClient.GetPaymentByID(ctx, &pb.GetByIDRequest{Id: id})
+	if err != nil {
+		st := status.Convert(err)
+		return nil, fmt.Errorf("%s: %s", st.Code(), st.Message())
+	}
+	return protojson.MarshalOptions{UseProtoNames: true}.Marshal(payment)
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+
+	"gateway-service/config"
+	"gateway-service/internal/balancer"
+	"gateway-service/internal/resilience"
+
+	"github.com/labstack/echo/v4"
+)
+
+// newReverseProxy builds the proxy for one route: the path loses the route's
+// strip_prefix and gains its add_prefix. httputil.ReverseProxy takes care of
+// hop-by-hop headers, trailers, context cancellation and flushing streamed
+// responses (SSE and chunked bodies are flushed as they arrive).
+func newReverseProxy(upstream *httpUpstream, rc config.RouteConfig) *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.Out.URL.Path = rc.UpstreamPath(r.In.URL.Path)
+			if r.In.URL.RawPath != "" {
+				r.Out.URL.RawPath = rc.UpstreamPath(r.In.URL.RawPath)
+			}
+			// X-Forwarded-For/Host/Proto; the For chain from earlier proxies is kept
+			r.Out.Header["X-Forwarded-For"] = r.In.Header["X-Forwarded-For"]
+			r.SetXForwarded()
+		},
+		Transport:    upstream,
+		ErrorHandler: proxyErrorHandler,
+	}
+}
+
+// proxyErrorHandler answers every proxy failure with the same JSON shape the
+// rest of the gateway uses.
+func proxyErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	status, message := http.StatusBadGateway, "Failed to connect to downstream service"
+
+	var maxBytesErr *http.MaxBytesError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &maxBytesErr):
+		status, message = http.StatusRequestEntityTooLarge, "Request body too large"
+	case errors.Is(err, resilience.ErrBreakerOpen), errors.Is(err, balancer.ErrNoHealthyTarget):
+		status, message = http.StatusServiceUnavailable, "Downstream service temporarily unavailable"
+	case errors.Is(err, context.Canceled) && r.Context().Err() != nil:
+		// Client went away, nobody is listening for the answer
+		return
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		status, message = http.StatusGatewayTimeout, "Downstream service timed out"
+	}
+
+	log.Printf("[PROXY] %s %s: %v", r.Method, r.URL.Path, err)
+	writeJSONError(w, status, message)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
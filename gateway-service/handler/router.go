@@ -0,0 +1,421 @@
+package handler
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"gateway-service/config"
+	"gateway-service/internal/balancer"
+	"gateway-service/internal/cache"
+	"gateway-service/internal/deprecation"
+	"gateway-service/internal/metrics"
+	"gateway-service/internal/ratelimit"
+	"gateway-service/internal/resilience"
+	"gateway-service/internal/split"
+	"gateway-service/middleware"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Router dispatches proxied requests using the route table from the gateway
+// config file. Reload swaps in a new table atomically; requests already
+// running keep the table (and upstream clients) they started with.
+type Router struct {
+	path     string
+	breakers *resilience.BreakerRegistry
+	limiter  ratelimit.Store
+	// idempotent replays POSTs that carry an Idempotency-Key
+	idempotent echo.MiddlewareFunc
+	cache      cache.Store
+	refreshing sync.Map // cache keys being refreshed in the background
+	// shadowHandlers are the in-gateway targets a route's shadow can name
+	shadowHandlers map[string]http.Handler
+	deprecations   *deprecation.Tracker
+	upstreamTLS    *tls.Config
+
+	mu        sync.Mutex // serialises reloads
+	table     atomic.Pointer[routeTable]
+	validator atomic.Pointer[RequestValidator]
+}
+
+type routeTable struct {
+	config    *config.GatewayConfig
+	routes    []*route // longest path_prefix first, versioned before not
+	upstreams map[string]*httpUpstream
+
+	// versions is nil when requests aren't routed by version
+	versions   map[string]bool
+	versioning config.VersioningSpec
+}
+
+type route struct {
+	config.RouteConfig
+	methods      map[string]bool
+	maxBody      int64
+	reverseProxy *httputil.ReverseProxy
+	handler      echo.HandlerFunc
+
+	// split routes pick one of versions per request instead of reverseProxy
+	split    *split.Splitter
+	versions []*routeVersion
+	shadow   *routeShadow
+}
+
+func NewRouter(path string, breakers *resilience.BreakerRegistry, limiter ratelimit.Store, idempotent echo.MiddlewareFunc, responses cache.Store) *Router {
+	return &Router{
+		path:         path,
+		breakers:     breakers,
+		limiter:      limiter,
+		idempotent:   idempotent,
+		cache:        responses,
+		deprecations: deprecation.NewTracker(1000),
+	}
+}
+
+// Reload reads the config file again (or falls back to the env-based default
+// routes when it doesn't exist) and swaps it in. On error the current table
+// stays active.
+func (r *Router) Reload() error {
+	cfg, err := config.LoadGatewayConfig(r.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Printf("[CONFIG] %s not found, using default routes", r.path)
+		cfg, err = config.DefaultGatewayConfig(), nil
+		if verr := cfg.Validate(); verr != nil {
+			err = verr
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	return r.Load(cfg)
+}
+
+// Load builds a route table from an already validated config and swaps it in.
+func (r *Router) Load(cfg *config.GatewayConfig) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old := r.table.Load()
+	table := &routeTable{config: cfg, upstreams: make(map[string]*httpUpstream)}
+
+	for name := range cfg.Upstreams {
+		upstreamCfg := cfg.Upstream(name)
+		if old != nil {
+			// Keep the connection pool and health state when nothing changed
+			if prev, ok := old.upstreams[name]; ok && reflect.DeepEqual(prev.cfg, upstreamCfg) {
+				table.upstreams[name] = prev
+				continue
+			}
+		}
+
+		upstream, err := newHTTPUpstream(upstreamCfg, r.breakers, r.upstreamTLS)
+		if err != nil {
+			table.closeNew(old)
+			return err
+		}
+		table.upstreams[name] = upstream
+	}
+
+	for _, rc := range cfg.Routes {
+		rt, err := r.buildRoute(cfg, rc, table.upstreams)
+		if err != nil {
+			table.closeNew(old)
+			return err
+		}
+		table.routes = append(table.routes, rt)
+	}
+	sort.SliceStable(table.routes, func(i, j int) bool {
+		a, b := table.routes[i], table.routes[j]
+		if len(a.PathPrefix) != len(b.PathPrefix) {
+			return len(a.PathPrefix) > len(b.PathPrefix)
+		}
+		return a.Version != "" && b.Version == ""
+	})
+	if versions := cfg.Versions(); versions != nil {
+		table.versioning = cfg.ResolvedVersioning()
+		table.versions = make(map[string]bool, len(versions))
+		for _, v := range versions {
+			table.versions[v] = true
+		}
+	}
+
+	r.table.Store(table)
+
+	if old != nil {
+		old.closeNew(table)
+	}
+	log.Printf("[CONFIG] loaded %d routes, %d upstreams", len(table.routes), len(table.upstreams))
+	return nil
+}
+
+// closeNew closes the upstreams of t that other doesn't share.
+func (t *routeTable) closeNew(other *routeTable) {
+	for name, u := range t.upstreams {
+		if other == nil || other.upstreams[name] != u {
+			u.close()
+		}
+	}
+}
+
+func (r *Router) buildRoute(cfg *config.GatewayConfig, rc config.RouteConfig, upstreams map[string]*httpUpstream) (*route, error) {
+	rt := &route{
+		RouteConfig:  rc,
+		maxBody:      rc.MaxBodyBytes(cfg.Defaults),
+		reverseProxy: newReverseProxy(upstreams[rc.Upstream], rc),
+	}
+	if rc.Split != nil {
+		rt.split, rt.versions = newSplit(rc, upstreams)
+	}
+	if rc.Shadow != nil {
+		var err error
+		if rt.shadow, err = newRouteShadow(rc, upstreams, r.shadowHandlers); err != nil {
+			return nil, err
+		}
+	}
+	if len(rc.Methods) > 0 {
+		rt.methods = make(map[string]bool, len(rc.Methods))
+		for _, m := range rc.Methods {
+			rt.methods[strings.ToUpper(m)] = true
+		}
+	}
+
+	h := rt.proxy
+	if r.cache != nil {
+		h = r.cacheResponses(rt, rc.Cache)(h)
+	}
+	if rt.split != nil {
+		h = rt.pickVersion(h)
+	}
+	if rc.ValidatesRequests(cfg.Defaults) {
+		h = r.validateRequest(h)
+	}
+	if r.idempotent != nil {
+		h = r.idempotent(h)
+	}
+	h = rt.limitBody(h)
+	if policy := rc.RateLimitPolicy(cfg.Defaults); policy.Enabled() {
+		h = middleware.RateLimit(r.limiter, "route:"+rc.Name, policy, middleware.IdentityKey)(h)
+	}
+	if len(rc.Roles) > 0 {
+		h = middleware.RequireRoles(rc.Roles...)(h)
+	}
+	if rc.RequiresAuth(cfg.Defaults) {
+		h = middleware.JWTMiddleware(h)
+	}
+	if rc.Deprecation != nil {
+		h = r.deprecated(rt)(h)
+	}
+	rt.handler = h
+	return rt, nil
+}
+
+// SetShadowHandler registers h under name for routes whose shadow names it
+// as handler. Call it before the first Reload.
+func (r *Router) SetShadowHandler(name string, h http.Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.shadowHandlers == nil {
+		r.shadowHandlers = make(map[string]http.Handler)
+	}
+	r.shadowHandlers[name] = h
+}
+
+// SetUpstreamTLS sets the TLS config for https upstreams. Call it before
+// the first Reload.
+func (r *Router) SetUpstreamTLS(cfg *tls.Config) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.upstreamTLS = cfg
+}
+
+// SetRequestValidator sets the validator used by routes with
+// validate_requests. Until it is set those routes proxy unchecked.
+func (r *Router) SetRequestValidator(v *RequestValidator) {
+	r.validator.Store(v)
+}
+
+func (r *Router) validateRequest(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if v := r.validator.Load(); v != nil {
+			return v.Middleware(next)(c)
+		}
+		return next(c)
+	}
+}
+
+// Handle is registered as the catch-all echo route.
+func (r *Router) Handle(c echo.Context) error {
+	table := r.table.Load()
+
+	var (
+		version string
+		inPath  bool
+	)
+	if table.versions != nil {
+		var err error
+		if version, inPath, err = table.negotiateVersion(c.Request()); err != nil {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+	}
+	rt, pathMatched := table.match(c.Request().Method, c.Request().URL.Path, version)
+	if rt == nil {
+		if pathMatched {
+			return echo.ErrMethodNotAllowed
+		}
+		return echo.ErrNotFound
+	}
+
+	if rt.Version != "" {
+		c.Response().Header().Set(HeaderXAPIVersion, rt.Version)
+		if !inPath {
+			c.Response().Header().Add(echo.HeaderVary, echo.HeaderAccept)
+		}
+	}
+	c.Set(metrics.RouteKey, rt.Name)
+	c.Set(middleware.UpstreamKey, rt.Upstream)
+	return rt.handler(c)
+}
+
+// Routes returns the active route config.
+func (r *Router) Routes() []config.RouteConfig {
+	return r.table.Load().config.Routes
+}
+
+// UpstreamStatus reports the health of every target per upstream.
+func (r *Router) UpstreamStatus() map[string][]balancer.TargetStatus {
+	table := r.table.Load()
+	out := make(map[string][]balancer.TargetStatus, len(table.upstreams))
+	for name, u := range table.upstreams {
+		out[name] = u.pool.Status()
+	}
+	return out
+}
+
+// upstream returns the active client of a configured upstream, nil if there
+// is none with that name.
+func (r *Router) upstream(name string) *httpUpstream {
+	return r.table.Load().upstreams[name]
+}
+
+// getJSON GETs path from a REST upstream through its balancer, breaker and
+// retries. A non-200 answer is an *upstreamError.
+func (r *Router) getJSON(ctx context.Context, upstream, path string) (json.RawMessage, error) {
+	u := r.upstream(upstream)
+	if u == nil {
+		return nil, fmt.Errorf("upstream %q not configured", upstream)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(echo.HeaderAccept, echo.MIMEApplicationJSON)
+
+	resp, err := u.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &upstreamError{status: resp.StatusCode, body: string(body)}
+	}
+	if !json.Valid(body) {
+		return nil, errors.New("upstream returned invalid JSON")
+	}
+	return body, nil
+}
+
+// CheckUpstreams is a readiness check: it fails when an upstream has no
+// healthy target left.
+func (r *Router) CheckUpstreams(ctx context.Context) error {
+	var down []string
+	for name, targets := range r.UpstreamStatus() {
+		healthy := false
+		for _, t := range targets {
+			healthy = healthy || t.Healthy
+		}
+		if !healthy {
+			down = append(down, name)
+		}
+	}
+	if len(down) > 0 {
+		sort.Strings(down)
+		return fmt.Errorf("no healthy target for %s", strings.Join(down, ", "))
+	}
+	return nil
+}
+
+// limitBody enforces max_body_size before anything reads the body.
+func (rt *route) limitBody(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		if rt.maxBody > 0 {
+			if req.ContentLength > rt.maxBody {
+				return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{"error": "Request body too large"})
+			}
+			req.Body = http.MaxBytesReader(c.Response(), req.Body, rt.maxBody)
+		}
+		return next(c)
+	}
+}
+
+func (rt *route) proxy(c echo.Context) error {
+	rt.serve(c.Response(), c.Request(), middleware.UserID(c))
+	return nil
+}
+
+// serve proxies req to the route's upstream, balanced by balanceKey.
+func (rt *route) serve(w http.ResponseWriter, req *http.Request, balanceKey string) {
+	clientCtx := req.Context()
+	ctx := withBalanceKey(clientCtx, balanceKey)
+	if rt.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rt.Timeout)
+		defer cancel()
+	}
+	req = req.WithContext(ctx)
+
+	if rt.shadow != nil {
+		// begin swaps req's body for the buffered one, so this same req is
+		// the one sent below
+		if mirrored := rt.shadow.begin(req); mirrored != nil {
+			tee := &teeWriter{statusWriter: statusWriter{ResponseWriter: w, status: http.StatusOK}}
+			w = tee
+			defer rt.shadow.mirror(clientCtx, mirrored, tee)
+		}
+	}
+
+	if v := versionOf(req); v != nil {
+		rt.serveVersion(v, w, req)
+		return
+	}
+	rt.reverseProxy.ServeHTTP(w, req)
+}
+
+// matchPrefix matches whole path segments, so /api/products matches
+// /api/products and /api/products/1 but not /api/productsx.
+func matchPrefix(path, prefix string) bool {
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	return len(path) == len(prefix) || strings.HasSuffix(prefix, "/") || path[len(prefix)] == '/'
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gateway-service/internal/cache"
+	"gateway-service/internal/ratelimit"
+	"gateway-service/internal/resilience"
+
+	"github.com/labstack/echo/v4"
+)
+
+// newTestRouter loads gatewayYAML, with ${UPSTREAM} replaced by upstream's
+// URL, into a router served by echo.
+func newTestRouter(t *testing.T, gatewayYAML, upstream string, shadowHandlers map[string]http.Handler) *echo.Echo {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "gateway.yaml")
+	if err := os.WriteFile(path, []byte(strings.ReplaceAll(gatewayYAML, "${UPSTREAM}", upstream)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	router := NewRouter(path, resilience.NewBreakerRegistry(), ratelimit.NewMemoryStore(time.Minute), nil, cache.NewMemoryStore(100, time.Minute))
+	for name, h := range shadowHandlers {
+		router.SetShadowHandler(name, h)
+	}
+	if err := router.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	e.Any("/*", router.Handle)
+	return e
+}
+
+func TestShadowKeepsPrimaryBody(t *testing.T) {
+	primary := make(chan string, 1)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		primary <- string(body)
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer upstream.Close()
+
+	mirrored := make(chan string, 1)
+	shadowHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mirrored <- string(body)
+		w.Write([]byte(`{"ok":true}`))
+	})
+
+	e := newTestRouter(t, `
+upstreams:
+  things:
+    url: ${UPSTREAM}
+routes:
+  - name: things
+    path_prefix: /api/things
+    upstream: things
+    auth: false
+    shadow:
+      handler: mirror
+      percent: 100
+      methods: [POST]
+`, upstream.URL, map[string]http.Handler{"mirror": shadowHandler})
+
+	const payload = `{"name":"widget"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/things", strings.NewReader(payload))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	for name, got := range map[string]chan string{"primary": primary, "shadow": mirrored} {
+		select {
+		case body := <-got:
+			if body != payload {
+				t.Errorf("%s got body %q, want %q", name, body, payload)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%s never got the request", name)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+
+	"gateway-service/config"
+	"gateway-service/internal/metrics"
+	"gateway-service/internal/shadow"
+)
+
+// HeaderXShadowRequest marks the copies sent to a shadow backend, so it can
+// tell them from real traffic.
+const HeaderXShadowRequest = "X-Shadow-Request"
+
+const (
+	// shadowMaxBody is the largest request or response body mirrored and
+	// compared
+	shadowMaxBody = 1 << 20
+	// shadowMaxInFlight bounds the copies of one route running at once;
+	// beyond it requests aren't mirrored rather than piling up
+	shadowMaxInFlight = 64
+	shadowTimeout     = 10 * time.Second
+)
+
+// routeShadow mirrors a sample of a route's requests to its shadow backend.
+type routeShadow struct {
+	route    string
+	rc       config.RouteConfig
+	spec     config.ShadowSpec
+	methods  map[string]bool
+	timeout  time.Duration
+	send     func(req *http.Request) (shadow.Response, error)
+	inFlight chan struct{}
+}
+
+func newRouteShadow(rc config.RouteConfig, upstreams map[string]*httpUpstream, handlers map[string]http.Handler) (*routeShadow, error) {
+	spec := *rc.Shadow
+	s := &routeShadow{
+		route:    rc.Name,
+		rc:       rc,
+		spec:     spec,
+		methods:  make(map[string]bool),
+		timeout:  spec.Timeout,
+		inFlight: make(chan struct{}, shadowMaxInFlight),
+	}
+	for _, m := range spec.ShadowMethods() {
+		s.methods[strings.ToUpper(m)] = true
+	}
+	if s.timeout == 0 {
+		s.timeout = shadowTimeout
+	}
+
+	if spec.Handler != "" {
+		h, ok := handlers[spec.Handler]
+		if !ok {
+			return nil, fmt.Errorf("route %q: shadow handler %q not registered", rc.Name, spec.Handler)
+		}
+		s.send = func(req *http.Request) (shadow.Response, error) {
+			rec := &recordedResponse{header: make(http.Header), status: http.StatusOK}
+			h.ServeHTTP(rec, req)
+			return shadow.Response{Status: rec.status, Body: rec.body.Bytes()}, nil
+		}
+		return s, nil
+	}
+
+	u := upstreams[spec.Upstream]
+	s.send = func(req *http.Request) (shadow.Response, error) {
+		resp, err := u.RoundTrip(req)
+		if err != nil {
+			return shadow.Response{}, err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(io.LimitReader(resp.Body, shadowMaxBody))
+		return shadow.Response{Status: resp.StatusCode, Body: body}, err
+	}
+	return s, nil
+}
+
+// begin decides whether req is mirrored and, if so, returns the copy for
+// the shadow. The body is buffered for both; req can still be sent as before.
+func (s *routeShadow) begin(req *http.Request) *http.Request {
+	if !s.methods[req.Method] || rand.Float64()*100 >= s.spec.Percent {
+		return nil
+	}
+
+	var body io.ReadCloser = http.NoBody
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.ContentLength > shadowMaxBody {
+			metrics.ShadowRequests.WithLabelValues(s.route, shadow.Skipped).Inc()
+			return nil
+		}
+		buf, err := io.ReadAll(io.LimitReader(req.Body, shadowMaxBody+1))
+		// The primary reads what was buffered, then whatever is left
+		req.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(buf), req.Body), req.Body}
+		if err != nil || len(buf) > shadowMaxBody {
+			metrics.ShadowRequests.WithLabelValues(s.route, shadow.Skipped).Inc()
+			return nil
+		}
+		body = io.NopCloser(bytes.NewReader(buf))
+	}
+
+	out := req.Clone(context.WithoutCancel(req.Context()))
+	out.Body = body
+	out.RequestURI = ""
+	out.URL.Path, out.URL.RawPath = s.path(req.URL.Path), ""
+	out.Header.Set(HeaderXShadowRequest, "true")
+	return out
+}
+
+// path is where the copy of a request for path goes: under path_prefix when
+// set, else where the primary sends it.
+func (s *routeShadow) path(path string) string {
+	if s.spec.PathPrefix != "" {
+		return s.spec.PathPrefix + strings.TrimPrefix(path, s.rc.PathPrefix)
+	}
+	return s.rc.UpstreamPath(path)
+}
+
+// mirror sends the copy in the background and compares the answer with the
+// primary's, which is complete by now.
+func (s *routeShadow) mirror(primaryCtx context.Context, mirrored *http.Request, primary *teeWriter) {
+	if primary.overflow || primaryCtx.Err() != nil {
+		// Too large to compare, or the client left before the answer
+		metrics.ShadowRequests.WithLabelValues(s.route, shadow.Skipped).Inc()
+		return
+	}
+	select {
+	case s.inFlight <- struct{}{}:
+	default:
+		metrics.ShadowRequests.WithLabelValues(s.route, shadow.Dropped).Inc()
+		return
+	}
+	want := shadow.Response{Status: primary.status, Body: bytes.Clone(primary.body.Bytes())}
+
+	go func() {
+		defer func() { <-s.inFlight }()
+
+		ctx, cancel := context.WithTimeout(mirrored.Context(), s.timeout)
+		defer cancel()
+		got, err := s.send(mirrored.WithContext(ctx))
+		if err != nil {
+			log.Printf("[SHADOW] %s %s %s: %v", s.route, mirrored.Method, mirrored.URL.Path, err)
+			metrics.ShadowRequests.WithLabelValues(s.route, shadow.Error).Inc()
+			return
+		}
+
+		diff := shadow.Compare(want, got, s.spec.IgnoreFields)
+		metrics.ShadowRequests.WithLabelValues(s.route, diff.Result).Inc()
+		switch diff.Result {
+		case shadow.StatusMismatch:
+			log.Printf("[SHADOW] %s %s %s: status %d, shadow %d", s.route, mirrored.Method, mirrored.URL.Path, want.Status, got.Status)
+		case shadow.BodyMismatch:
+			fields := strings.Join(diff.Paths, ", ")
+			if fields == "" {
+				fields = "(whole body)"
+			}
+			log.Printf("[SHADOW] %s %s %s: body differs at %s", s.route, mirrored.Method, mirrored.URL.Path, fields)
+		}
+	}()
+}
+
+// teeWriter passes a response through and keeps a copy of it, up to
+// shadowMaxBody.
+type teeWriter struct {
+	statusWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (w *teeWriter) Write(b []byte) (int, error) {
+	if !w.overflow {
+		if w.body.Len()+len(b) > shadowMaxBody {
+			w.overflow = true
+			w.body.Reset()
+		} else {
+			w.body.Write(b)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"time"
+
+	"gateway-service/config"
+	"gateway-service/internal/metrics"
+	"gateway-service/internal/split"
+	"gateway-service/middleware"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// HeaderXCanary lets testers pick the version of a split route: "true"
+	// for the canary, "false" for the stable one, or a version name.
+	HeaderXCanary = "X-Canary"
+	// HeaderXRouteVersion tells which version of a split route answered.
+	HeaderXRouteVersion = "X-Route-Version"
+)
+
+// stickyCookie keeps clients without a user ID on the same version.
+const (
+	stickyCookie       = "gw_sticky"
+	stickyCookieMaxAge = 30 * 24 * time.Hour
+)
+
+// routeVersion is one upstream version of a split route.
+type routeVersion struct {
+	name     string
+	upstream string
+	proxy    *httputil.ReverseProxy
+}
+
+type routeVersionKey struct{}
+
+// versionOf returns the version pickVersion chose for req, nil on routes
+// that aren't split.
+func versionOf(req *http.Request) *routeVersion {
+	v, _ := req.Context().Value(routeVersionKey{}).(*routeVersion)
+	return v
+}
+
+func newSplit(rc config.RouteConfig, upstreams map[string]*httpUpstream) (*split.Splitter, []*routeVersion) {
+	versions := make([]split.Version, len(rc.Split.Versions))
+	proxies := make([]*routeVersion, len(rc.Split.Versions))
+	for i, v := range rc.Split.Versions {
+		versions[i] = split.Version{Name: v.Name, Weight: v.Weight, Canary: v.Canary}
+		proxies[i] = &routeVersion{name: v.Name, upstream: v.Upstream, proxy: newReverseProxy(upstreams[v.Upstream], rc)}
+	}
+	return split.New(rc.Name, versions), proxies
+}
+
+// pickVersion chooses the version a request of a split route goes to: the
+// one X-Canary names, else the sticky pick by weight.
+func (rt *route) pickVersion(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		i, ok := rt.split.Override(c.Request().Header.Get(HeaderXCanary))
+		if !ok {
+			i = rt.split.Pick(rt.stickyKey(c))
+		}
+		v := rt.versions[i]
+
+		req := c.Request()
+		c.SetRequest(req.WithContext(context.WithValue(req.Context(), routeVersionKey{}, v)))
+		c.Response().Header().Set(HeaderXRouteVersion, v.name)
+		c.Set(middleware.UpstreamKey, v.upstream)
+		return next(c)
+	}
+}
+
+// stickyKey is what keeps a client on its version: the user ID or the
+// sticky cookie, set here on the first request. "" for sticky: none.
+func (rt *route) stickyKey(c echo.Context) string {
+	switch rt.Split.Sticky {
+	case split.StickyNone:
+		return ""
+	case "", split.StickyUser:
+		if userID := middleware.UserID(c); userID != "" {
+			return "user:" + userID
+		}
+	}
+
+	if cookie, err := c.Cookie(stickyCookie); err == nil && cookie.Value != "" {
+		return "cookie:" + cookie.Value
+	}
+	b := make([]byte, 16)
+	rand.Read(b)
+	value := hex.EncodeToString(b)
+	c.SetCookie(&http.Cookie{
+		Name:     stickyCookie,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   int(stickyCookieMaxAge.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return "cookie:" + value
+}
+
+// serveVersion proxies req to version v and records the answer in the
+// per-version metrics.
+func (rt *route) serveVersion(v *routeVersion, w http.ResponseWriter, req *http.Request) {
+	sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+	start := time.Now()
+	v.proxy.ServeHTTP(sw, req)
+	metrics.VersionDuration.WithLabelValues(rt.Name, v.name).Observe(time.Since(start).Seconds())
+	metrics.VersionRequests.WithLabelValues(rt.Name, v.name, strconv.Itoa(sw.status)).Inc()
+}
+
+// statusWriter remembers the status written through it.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status, w.wroteHeader = status, true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach the flusher underneath.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"gateway-service/config"
+	"gateway-service/internal/balancer"
+	"gateway-service/internal/health"
+	"gateway-service/internal/resilience"
+
+	"github.com/labstack/echo/v4"
+)
+
+// StatusHandler serves the aggregated GET /status: every REST target probed
+// live, the gRPC backends asked through grpc.health.v1, and the breaker of
+// each upstream.
+type StatusHandler struct {
+	router   *Router
+	grpc     *config.GRPCClients
+	breakers *resilience.BreakerRegistry
+	client   *http.Client
+}
+
+type statusReport struct {
+	Status    string                    `json:"status"` // ok / degraded
+	Upstreams map[string]upstreamReport `json:"upstreams"`
+}
+
+type upstreamReport struct {
+	Status  string         `json:"status"` // ok / degraded / down
+	Breaker string         `json:"breaker,omitempty"`
+	Targets []targetReport `json:"targets,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+type targetReport struct {
+	balancer.TargetStatus
+	Probe string `json:"probe"` // "ok" or why the probe failed
+}
+
+func NewStatusHandler(router *Router, grpcClients *config.GRPCClients, breakers *resilience.BreakerRegistry) *StatusHandler {
+	return &StatusHandler{
+		router:   router,
+		grpc:     grpcClients,
+		breakers: breakers,
+		client:   &http.Client{Timeout: 2 * time.Second},
+	}
+}
+
+func (s *StatusHandler) Status(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 3*time.Second)
+	defer cancel()
+
+	breakers := make(map[string]string)
+	for _, b := range s.breakers.Snapshots() {
+		breakers[b.Name] = b.State
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		report = statusReport{Status: "ok", Upstreams: make(map[string]upstreamReport)}
+	)
+	set := func(name string, r upstreamReport) {
+		mu.Lock()
+		defer mu.Unlock()
+		r.Breaker = breakers[name]
+		report.Upstreams[name] = r
+		if r.Status != "ok" {
+			report.Status = "degraded"
+		}
+	}
+
+	table := s.router.table.Load()
+	for name, u := range table.upstreams {
+		wg.Add(1)
+		go func(name string, u *httpUpstream) {
+			defer wg.Done()
+			set(name, s.probeUpstream(ctx, u))
+		}(name, u)
+	}
+
+	grpcChecks := map[string]health.Check{
+		"payment-grpc": health.GRPC(s.grpc.PaymentHealth, ""),
+		"auth-grpc":    health.GRPC(s.grpc.AuthHealth, ""),
+	}
+	for name, check := range grpcChecks {
+		wg.Add(1)
+		go func(name string, check health.Check) {
+			defer wg.Done()
+			r := upstreamReport{Status: "ok"}
+			if err := check(ctx); err != nil {
+				r = upstreamReport{Status: "down", Error: err.Error()}
+			}
+			set(name, r)
+		}(name, check)
+	}
+
+	wg.Wait()
+	return c.JSON(http.StatusOK, report)
+}
+
+// probeUpstream calls the health path of every target of u, regardless of
+// what the balancer currently thinks of it.
+func (s *StatusHandler) probeUpstream(ctx context.Context, u *httpUpstream) upstreamReport {
+	path := u.cfg.HealthCheck.Path
+	if path == "" {
+		path = "/healthz"
+	}
+
+	targets := u.pool.Status()
+	out := upstreamReport{Targets: make([]targetReport, len(targets))}
+	// The upstream's own transport, which has its TLS settings
+	client := &http.Client{Timeout: s.client.Timeout, Transport: u.transport}
+
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		wg.Add(1)
+		go func(i int, t balancer.TargetStatus) {
+			defer wg.Done()
+			probe := "ok"
+			if err := health.HTTP(client, t.URL+path)(ctx); err != nil {
+				probe = err.Error()
+			}
+			out.Targets[i] = targetReport{TargetStatus: t, Probe: probe}
+		}(i, t)
+	}
+	wg.Wait()
+
+	sort.Slice(out.Targets, func(i, j int) bool { return out.Targets[i].URL < out.Targets[j].URL })
+	up := 0
+	for _, t := range out.Targets {
+		if t.Probe == "ok" {
+			up++
+		}
+	}
+	switch {
+	case up == len(out.Targets):
+		out.Status = "ok"
+	case up == 0:
+		out.Status = "down"
+	default:
+		out.Status = "degraded"
+	}
+	return out
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"gateway-service/config"
+	pb "gateway-service/internal/pb"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// NewTranscoder exposes the payment and auth RPCs over REST as mapped by the
+// google.api.http options in internal/pb/*.proto: path params and bodies are
+// bound to the request message, replies are proto-JSON with the proto field
+// names (created_at) and gRPC codes become HTTP statuses. Calls go through
+// the shared gRPC clients, so they get the same breakers and retries.
+func NewTranscoder(ctx context.Context, grpcClients *config.GRPCClients) (http.Handler, error) {
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions:   protojson.MarshalOptions{UseProtoNames: true},
+			UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+		}),
+		runtime.WithErrorHandler(transcodeError),
+		runtime.WithRoutingErrorHandler(transcodeRoutingError),
+	)
+
+	if err := pb.RegisterPaymentServiceHandlerClient(ctx, mux, grpcClients.PaymentClient); err != nil {
+		return nil, err
+	}
+	if err := pb.RegisterAuthServiceHandlerClient(ctx, mux, grpcClients.AuthClient); err != nil {
+		return nil, err
+	}
+	return mux, nil
+}
+
+// transcodeError answers with the gateway's usual {"error": ...} body.
+func transcodeError(_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, _ *http.Request, err error) {
+	st := status.Convert(err)
+	writeJSONError(w, runtime.HTTPStatusFromCode(st.Code()), st.Message())
+}
+
+// transcodeRoutingError keeps 404/405 as they are instead of the 501 that
+// grpc-gateway makes of an unmapped method.
+func transcodeRoutingError(_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, _ *http.Request, httpStatus int) {
+	writeJSONError(w, httpStatus, http.StatusText(httpStatus))
+}
@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gateway-service/config"
+	"gateway-service/internal/balancer"
+	"gateway-service/internal/metrics"
+	"gateway-service/internal/resilience"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// errUpstreamStatus marks a 5xx answer so it is counted (and retried) like a
+// connection failure.
+var errUpstreamStatus = errors.New("upstream returned server error")
+
+type balanceKey struct{}
+
+// withBalanceKey attaches the key consistent_hash balancing uses (the user ID).
+func withBalanceKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, balanceKey{}, key)
+}
+
+// httpUpstream is a REST service behind the gateway with its own transport,
+// breaker, retry policy and pool of instances. It is the RoundTripper of the
+// reverse proxies of every route pointing at it.
+type httpUpstream struct {
+	cfg       config.UpstreamConfig
+	transport *http.Transport
+	traced    http.RoundTripper // transport with a client span per attempt
+	breaker   *resilience.Breaker
+	pool      *balancer.Pool
+}
+
+// tlsConfig is used for https targets: it verifies them and carries the
+// gateway's client certificate for mTLS.
+func newHTTPUpstream(cfg config.UpstreamConfig, breakers *resilience.BreakerRegistry, tlsConfig *tls.Config) (*httpUpstream, error) {
+	for _, target := range cfg.Targets {
+		if _, err := url.Parse(target); err != nil {
+			return nil, fmt.Errorf("upstream %q: %w", cfg.Name, err)
+		}
+	}
+
+	pool, err := balancer.NewPool(cfg.Name, cfg.Targets, cfg.Strategy, cfg.HealthCheck, tlsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("upstream %q: %w", cfg.Name, err)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: cfg.ConnectTimeout}).DialContext
+	transport.ResponseHeaderTimeout = cfg.ReadTimeout
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	return &httpUpstream{
+		cfg:       cfg,
+		transport: transport,
+		traced:    otelhttp.NewTransport(transport),
+		breaker:   breakers.Get(cfg.Name, cfg.Breaker),
+		pool:      pool,
+	}, nil
+}
+
+// close stops health checks and drops idle connections. Requests still in
+// flight finish normally.
+func (u *httpUpstream) close() {
+	u.pool.Close()
+	u.transport.CloseIdleConnections()
+}
+
+// RoundTrip sends req to a target picked by the balancer, through the
+// breaker. req.URL only carries the upstream path; scheme and host come from
+// the target. Idempotent methods are retried on connection errors and
+// 502/503/504, each attempt possibly on another target, so their body is
+// buffered to be replayed; other methods stream the body once.
+func (u *httpUpstream) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	key, _ := ctx.Value(balanceKey{}).(string)
+
+	policy := u.cfg.Retry
+	if !resilience.IsIdempotentMethod(req.Method) {
+		policy.MaxAttempts = 1
+	}
+
+	var payload []byte
+	if policy.MaxAttempts > 1 && req.Body != nil && req.Body != http.NoBody {
+		var err error
+		payload, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var resp *http.Response
+	err := resilience.Retry(ctx, policy, func(attempt int) (bool, error) {
+		target, err := u.pool.Pick(key)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", u.cfg.Name, err)
+		}
+		if err := u.breaker.Allow(); err != nil {
+			return false, fmt.Errorf("%s: %w", u.cfg.Name, err)
+		}
+
+		out := req.Clone(ctx)
+		setTarget(out, target.URL)
+		if payload != nil {
+			out.Body = io.NopCloser(bytes.NewReader(payload))
+			out.ContentLength = int64(len(payload))
+		}
+
+		release := target.Acquire()
+		start := time.Now()
+		r, err := u.traced.RoundTrip(out)
+		metrics.UpstreamDuration.WithLabelValues(u.cfg.Name, target.URL).Observe(time.Since(start).Seconds())
+		if err != nil {
+			release()
+			if errors.Is(ctx.Err(), context.Canceled) {
+				// The client hung up: no word on the upstream's health
+				u.breaker.Release()
+				metrics.UpstreamRequests.WithLabelValues(u.cfg.Name, target.URL, "canceled").Inc()
+				return false, err
+			}
+			u.breaker.Record(false)
+			metrics.UpstreamRequests.WithLabelValues(u.cfg.Name, target.URL, "error").Inc()
+			return ctx.Err() == nil, err
+		}
+		metrics.UpstreamRequests.WithLabelValues(u.cfg.Name, target.URL, strconv.Itoa(r.StatusCode)).Inc()
+
+		u.breaker.Record(r.StatusCode < http.StatusInternalServerError)
+		if isRetryableStatus(r.StatusCode) && attempt < policy.MaxAttempts {
+			io.Copy(io.Discard, r.Body)
+			r.Body.Close()
+			release()
+			return true, errUpstreamStatus
+		}
+
+		r.Body = &releaseOnClose{ReadCloser: r.Body, release: release}
+		resp = r
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// setTarget points req at target, keeping any base path target has.
+func setTarget(req *http.Request, target string) {
+	base, _ := url.Parse(target) // validated in newHTTPUpstream
+	basePath := strings.TrimSuffix(base.Path, "/")
+
+	req.URL.Scheme = base.Scheme
+	req.URL.Host = base.Host
+	req.URL.Path = basePath + req.URL.Path
+	if req.URL.RawPath != "" {
+		req.URL.RawPath = basePath + req.URL.RawPath
+	}
+	req.Host = "" // use the target's host
+}
+
+func isRetryableStatus(code int) bool {
+	return code == http.StatusBadGateway || code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout
+}
+
+// releaseOnClose keeps the target's active count up until the response body
+// has been fully streamed, which is what least_connections needs to see.
+type releaseOnClose struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+func (r *releaseOnClose) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(r.release)
+	return err
+}
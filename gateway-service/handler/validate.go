@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/labstack/echo/v4"
+)
+
+// RequestValidator checks path params, query params and JSON bodies against
+// the merged OpenAPI spec before a request is proxied. Requests the spec
+// doesn't describe pass through unchecked, and so does everything while the
+// spec can't be loaded.
+type RequestValidator struct {
+	spec func(context.Context) (*openapi3.T, error)
+
+	mu     sync.Mutex
+	doc    *openapi3.T // the spec router was built from
+	router routers.Router
+}
+
+// FieldError is one invalid input of a request.
+type FieldError struct {
+	In      string `json:"in"`              // path, query, header or body
+	Field   string `json:"field,omitempty"` // parameter name, or dotted path into the body
+	Message string `json:"message"`
+}
+
+type validationErrorResponse struct {
+	Error  string       `json:"error"`
+	Fields []FieldError `json:"fields"`
+}
+
+// NewRequestValidator validates against whatever spec returns; it is asked on
+// every request and is expected to cache (see DocsHandler.Spec).
+func NewRequestValidator(spec func(context.Context) (*openapi3.T, error)) *RequestValidator {
+	return &RequestValidator{spec: spec}
+}
+
+func (v *RequestValidator) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+
+		router, err := v.routerFor(req.Context())
+		if err != nil {
+			log.Printf("[VALIDATE] spec unavailable, not validating: %v", err)
+			return next(c)
+		}
+		route, pathParams, err := router.FindRoute(req)
+		if err != nil {
+			// Not in the spec: nothing to check against
+			return next(c)
+		}
+
+		err = openapi3filter.ValidateRequest(req.Context(), &openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: pathParams,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				MultiError: true,
+				// The JWT middleware has already dealt with authentication
+				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+			},
+		})
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{"error": "Request body too large"})
+			}
+			return c.JSON(http.StatusBadRequest, validationErrorResponse{
+				Error:  "Invalid request",
+				Fields: FieldErrors(err),
+			})
+		}
+		return next(c)
+	}
+}
+
+// routerFor returns a router for the current spec, rebuilt when the spec has
+// been rebuilt.
+func (v *RequestValidator) routerFor(ctx context.Context) (routers.Router, error) {
+	doc, err := v.spec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if doc == v.doc {
+		return v.router, nil
+	}
+
+	// Match on paths only: servers may name a public host the gateway
+	// doesn't see in requests
+	routing := *doc
+	routing.Servers = nil
+	router, err := gorillamux.NewRouter(&routing)
+	if err != nil {
+		return nil, err
+	}
+	v.doc, v.router = doc, router
+	return router, nil
+}
+
+// FieldErrors flattens a validation error into one entry per invalid input.
+func FieldErrors(err error) []FieldError {
+	var out []FieldError
+	collectFieldErrors(&out, "", "", err)
+	return out
+}
+
+func collectFieldErrors(out *[]FieldError, in, field string, err error) {
+	// A type switch rather than errors.As: a RequestError unwraps to the
+	// MultiError inside it, and its In/Field would be lost
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		for _, inner := range e {
+			collectFieldErrors(out, in, field, inner)
+		}
+
+	case *openapi3filter.RequestError:
+		switch {
+		case e.Parameter != nil:
+			in, field = e.Parameter.In, e.Parameter.Name
+		case e.RequestBody != nil:
+			in = "body"
+		}
+		if e.Err == nil {
+			*out = append(*out, FieldError{In: in, Field: field, Message: e.Reason})
+			return
+		}
+		collectFieldErrors(out, in, field, e.Err)
+
+	case *openapi3.SchemaError:
+		path := e.JSONPointer()
+		if field != "" {
+			path = append([]string{field}, path...)
+		}
+		*out = append(*out, FieldError{In: in, Field: strings.Join(path, "."), Message: e.Reason})
+
+	case *openapi3filter.ParseError:
+		*out = append(*out, FieldError{In: in, Field: field, Message: e.Error()})
+
+	default:
+		*out = append(*out, FieldError{In: in, Field: field, Message: err.Error()})
+	}
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gateway-service/config"
+	"gateway-service/internal/deprecation"
+	"gateway-service/internal/metrics"
+	"gateway-service/middleware"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HeaderXAPIVersion tells which API version answered a versioned route.
+const HeaderXAPIVersion = "X-API-Version"
+
+// negotiateVersion returns the API version req asks for: the version segment
+// of its path, which is taken out of req.URL, else the one its Accept names,
+// else the default. inPath reports where it came from.
+func (t *routeTable) negotiateVersion(req *http.Request) (version string, inPath bool, err error) {
+	prefix := t.versioning.Prefix
+	if rest, ok := strings.CutPrefix(req.URL.Path, prefix+"/"); ok {
+		segment, _, _ := strings.Cut(rest, "/")
+		if config.IsVersion(segment) {
+			if !t.versions[segment] {
+				return "", true, fmt.Errorf("Unknown API version %s", segment)
+			}
+			req.URL.Path = stripSegment(req.URL.Path, prefix, segment)
+			if req.URL.RawPath != "" {
+				req.URL.RawPath = stripSegment(req.URL.RawPath, prefix, segment)
+			}
+			return segment, true, nil
+		}
+	}
+
+	if version := acceptVersion(req.Header.Get(echo.HeaderAccept), t.versioning.MediaType); version != "" {
+		if !t.versions[version] {
+			return "", false, fmt.Errorf("Unknown API version %s", version)
+		}
+		return version, false, nil
+	}
+	return t.versioning.Default, false, nil
+}
+
+// stripSegment takes /segment out right after prefix: /api/v2/products
+// becomes /api/products.
+func stripSegment(path, prefix, segment string) string {
+	return prefix + strings.TrimPrefix(path, prefix+"/"+segment)
+}
+
+// acceptVersion finds the version an Accept header asks for, either as
+// vendor type (application/vnd.gateway.v2+json) or as version parameter
+// (application/json; version=2). "" when it names none.
+func acceptVersion(accept, vendor string) string {
+	if accept == "" {
+		return ""
+	}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(mediaType, vendor+"."); ok {
+			version, _, _ := strings.Cut(rest, "+")
+			if config.IsVersion(version) {
+				return version
+			}
+		}
+		if version := params["version"]; version != "" {
+			if !strings.HasPrefix(version, "v") {
+				version = "v" + version
+			}
+			if config.IsVersion(version) {
+				return version
+			}
+		}
+	}
+	return ""
+}
+
+// deprecated adds the Deprecation (RFC 9745), Sunset (RFC 8594) and Link
+// headers to a deprecated route's responses and counts who still calls it.
+func (r *Router) deprecated(rt *route) echo.MiddlewareFunc {
+	d := rt.Deprecation
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			h := c.Response().Header()
+			h.Set("Deprecation", "@"+strconv.FormatInt(d.Since.Unix(), 10))
+			if !d.Sunset.IsZero() {
+				h.Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+			}
+			if d.Link != "" {
+				h.Add("Link", "<"+d.Link+`>; rel="deprecation"; type="text/html"`)
+			}
+
+			err := next(c)
+
+			// After next: the JWT has been checked, so users count as users
+			r.deprecations.Record(rt.Name, middleware.IdentityKey(c))
+			metrics.DeprecatedRequests.WithLabelValues(rt.Name).Inc()
+			return err
+		}
+	}
+}
+
+// DeprecatedUsage reports the calls to deprecated routes per client.
+func (r *Router) DeprecatedUsage() []deprecation.Usage {
+	return r.deprecations.Snapshot()
+}
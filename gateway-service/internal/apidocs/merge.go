@@ -0,0 +1,158 @@
+package apidocs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// BearerScheme is the security scheme every non-public operation requires.
+const BearerScheme = "BearerAuth"
+
+// PathFunc maps an operation of a service to the gateway. ok is false when
+// the gateway doesn't expose it; public operations need no token.
+type PathFunc func(method, path string) (gatewayPath string, public, ok bool)
+
+// Source is the Swagger 2.0 document of one service.
+type Source struct {
+	Name    string // prefixes its schema names so services can't clash
+	Swagger []byte
+	Path    PathFunc
+}
+
+// Merge adds every source to base, rewritten to gateway paths. A source that
+// can't be converted is skipped and reported in the returned error; the
+// others are still merged.
+func Merge(base *openapi3.T, sources []Source) error {
+	if base.Paths == nil {
+		base.Paths = openapi3.NewPaths()
+	}
+	if base.Components == nil {
+		base.Components = &openapi3.Components{}
+	}
+	if base.Components.Schemas == nil {
+		base.Components.Schemas = openapi3.Schemas{}
+	}
+	if base.Components.SecuritySchemes == nil {
+		base.Components.SecuritySchemes = openapi3.SecuritySchemes{}
+	}
+	base.Components.SecuritySchemes[BearerScheme] = &openapi3.SecuritySchemeRef{
+		Value: openapi3.NewJWTSecurityScheme(),
+	}
+	base.Security = openapi3.SecurityRequirements{{BearerScheme: []string{}}}
+
+	var errs []error
+	for _, src := range sources {
+		if err := mergeSource(base, src); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", src.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func mergeSource(base *openapi3.T, src Source) error {
+	v2, err := prefixDefinitions(src.Swagger, src.Name+".")
+	if err != nil {
+		return err
+	}
+	var doc2 openapi2.T
+	if err := json.Unmarshal(v2, &doc2); err != nil {
+		return err
+	}
+	doc3, err := openapi2conv.ToV3(&doc2)
+	if err != nil {
+		return err
+	}
+
+	for name, schema := range doc3.Components.Schemas {
+		base.Components.Schemas[name] = schema
+	}
+
+	tags := make(map[string]bool)
+	for _, t := range base.Tags {
+		tags[t.Name] = true
+	}
+
+	for _, path := range doc3.Paths.InMatchingOrder() {
+		item := doc3.Paths.Value(path)
+		for method, op := range item.Operations() {
+			gatewayPath, public, ok := path, false, true
+			if src.Path != nil {
+				gatewayPath, public, ok = src.Path(method, path)
+			}
+			if !ok {
+				continue
+			}
+			if public {
+				op.Security = &openapi3.SecurityRequirements{}
+			}
+			if len(item.Parameters) > 0 {
+				op.Parameters = append(append(openapi3.Parameters{}, item.Parameters...), op.Parameters...)
+			}
+
+			target := base.Paths.Value(gatewayPath)
+			if target == nil {
+				target = &openapi3.PathItem{}
+				base.Paths.Set(gatewayPath, target)
+			}
+			target.SetOperation(method, op)
+
+			for _, tag := range op.Tags {
+				if !tags[tag] {
+					tags[tag] = true
+					base.Tags = append(base.Tags, &openapi3.Tag{Name: tag})
+				}
+			}
+		}
+	}
+	sort.Slice(base.Tags, func(i, j int) bool { return base.Tags[i].Name < base.Tags[j].Name })
+	return nil
+}
+
+// prefixDefinitions renames every definition of a Swagger 2.0 document, and
+// the $refs to it, so dto.ProductResponse of two services can coexist.
+func prefixDefinitions(data []byte, prefix string) ([]byte, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	if defs, ok := doc["definitions"].(map[string]any); ok {
+		renamed := make(map[string]any, len(defs))
+		for name, def := range defs {
+			renamed[prefix+name] = def
+		}
+		doc["definitions"] = renamed
+	}
+
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for key, child := range v {
+				if ref, ok := child.(string); ok && key == "$ref" && strings.HasPrefix(ref, "#/definitions/") {
+					v[key] = "#/definitions/" + prefix + strings.TrimPrefix(ref, "#/definitions/")
+					continue
+				}
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(doc)
+
+	// The gateway decides where things are served
+	delete(doc, "host")
+	delete(doc, "basePath")
+	delete(doc, "schemes")
+	return json.Marshal(doc)
+}
@@ -0,0 +1,102 @@
+package apidocs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const productSwagger = `{
+  "swagger": "2.0",
+  "host": "34.101.41.221:8084",
+  "paths": {
+    "/products": {
+      "get": {
+        "tags": ["Products"],
+        "responses": {"200": {"description": "OK", "schema": {"type": "array", "items": {"$ref": "#/definitions/dto.Response"}}}}
+      }
+    },
+    "/internal/reindex": {
+      "post": {"responses": {"204": {"description": "No Content"}}}
+    }
+  },
+  "definitions": {
+    "dto.Response": {"type": "object", "properties": {"name": {"type": "string"}}}
+  }
+}`
+
+const authSwagger = `{
+  "swagger": "2.0",
+  "paths": {
+    "/login": {
+      "post": {
+        "tags": ["Auth"],
+        "parameters": [{"in": "body", "name": "body", "required": true, "schema": {"$ref": "#/definitions/dto.Response"}}],
+        "responses": {"200": {"description": "OK"}}
+      }
+    }
+  },
+  "definitions": {
+    "dto.Response": {"type": "object", "properties": {"token": {"type": "string"}}}
+  }
+}`
+
+func TestMerge(t *testing.T) {
+	base := &openapi3.T{OpenAPI: "3.0.3", Info: &openapi3.Info{Title: "Gateway", Version: "1"}}
+	err := Merge(base, []Source{
+		{
+			Name:    "product",
+			Swagger: []byte(productSwagger),
+			Path: func(method, path string) (string, bool, bool) {
+				return "/api" + path, false, strings.HasPrefix(path, "/products")
+			},
+		},
+		{
+			Name:    "auth",
+			Swagger: []byte(authSwagger),
+			Path: func(method, path string) (string, bool, bool) {
+				return path, true, true
+			},
+		},
+		{Name: "broken", Swagger: []byte("{")},
+	})
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("expected the broken source to be reported, got %v", err)
+	}
+
+	if base.Paths.Value("/products") != nil || base.Paths.Value("/api/internal/reindex") != nil {
+		t.Error("paths should be rewritten and unexposed ones dropped")
+	}
+	list := base.Paths.Value("/api/products")
+	if list == nil || list.Get == nil {
+		t.Fatal("missing GET /api/products")
+	}
+	if list.Get.Security != nil {
+		t.Error("protected operation should inherit the global Bearer requirement")
+	}
+	ref := list.Get.Responses.Value("200").Value.Content.Get("application/json").Schema.Value.Items.Ref
+	if ref != "#/components/schemas/product.dto.Response" {
+		t.Errorf("ref not prefixed: %q", ref)
+	}
+
+	login := base.Paths.Value("/login")
+	if login == nil || login.Post == nil {
+		t.Fatal("missing POST /login")
+	}
+	if login.Post.Security == nil || len(*login.Post.Security) != 0 {
+		t.Error("public operation should have an empty security requirement")
+	}
+
+	for _, name := range []string{"product.dto.Response", "auth.dto.Response"} {
+		if base.Components.Schemas[name] == nil {
+			t.Errorf("missing schema %s", name)
+		}
+	}
+	if base.Components.SecuritySchemes[BearerScheme] == nil || len(base.Security) != 1 {
+		t.Error("Bearer scheme not set up")
+	}
+	if len(base.Tags) != 2 || base.Tags[0].Name != "Auth" {
+		t.Errorf("unexpected tags %v", base.Tags)
+	}
+}
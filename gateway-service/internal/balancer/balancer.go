@@ -0,0 +1,156 @@
+package balancer
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"sync/atomic"
+)
+
+// ErrNoHealthyTarget is returned when every target has been ejected.
+var ErrNoHealthyTarget = errors.New("no healthy upstream target")
+
+const (
+	RoundRobin       = "round_robin"
+	LeastConnections = "least_connections"
+	ConsistentHash   = "consistent_hash"
+)
+
+// Target is one instance of an upstream service.
+type Target struct {
+	URL string
+
+	healthy atomic.Bool
+	active  atomic.Int64
+}
+
+func NewTarget(url string) *Target {
+	t := &Target{URL: url}
+	t.healthy.Store(true)
+	return t
+}
+
+func (t *Target) Healthy() bool { return t.healthy.Load() }
+
+// Active is the number of requests currently in flight to the target.
+func (t *Target) Active() int64 { return t.active.Load() }
+
+// Acquire marks a request as started; call the returned func when done.
+func (t *Target) Acquire() (release func()) {
+	t.active.Add(1)
+	return func() { t.active.Add(-1) }
+}
+
+// Balancer picks a target for a request. key is only used by
+// consistent_hash (the user ID); the other strategies ignore it.
+type Balancer interface {
+	Pick(key string) (*Target, error)
+}
+
+// ValidStrategy reports whether New accepts strategy.
+func ValidStrategy(strategy string) bool {
+	switch strategy {
+	case "", RoundRobin, LeastConnections, ConsistentHash:
+		return true
+	}
+	return false
+}
+
+// New returns the balancer for strategy; "" means round_robin.
+func New(strategy string, targets []*Target) (Balancer, error) {
+	if len(targets) == 0 {
+		return nil, errors.New("balancer needs at least one target")
+	}
+
+	switch strategy {
+	case "", RoundRobin:
+		return &roundRobin{targets: targets}, nil
+	case LeastConnections:
+		return &leastConnections{targets: targets}, nil
+	case ConsistentHash:
+		return newHashRing(targets), nil
+	}
+	return nil, fmt.Errorf("unknown load balancing strategy %q", strategy)
+}
+
+type roundRobin struct {
+	targets []*Target
+	next    atomic.Uint64
+}
+
+func (b *roundRobin) Pick(string) (*Target, error) {
+	n := uint64(len(b.targets))
+	start := b.next.Add(1) - 1
+	for i := uint64(0); i < n; i++ {
+		if t := b.targets[(start+i)%n]; t.Healthy() {
+			return t, nil
+		}
+	}
+	return nil, ErrNoHealthyTarget
+}
+
+type leastConnections struct {
+	targets []*Target
+	next    atomic.Uint64 // rotates the start so ties don't all land on the first target
+}
+
+func (b *leastConnections) Pick(string) (*Target, error) {
+	n := len(b.targets)
+	start := int(b.next.Add(1) % uint64(n))
+
+	var best *Target
+	for i := 0; i < n; i++ {
+		t := b.targets[(start+i)%n]
+		if !t.Healthy() {
+			continue
+		}
+		if best == nil || t.Active() < best.Active() {
+			best = t
+		}
+	}
+	if best == nil {
+		return nil, ErrNoHealthyTarget
+	}
+	return best, nil
+}
+
+// virtualNodes per target smooths out the distribution on the ring.
+const virtualNodes = 100
+
+type hashRing struct {
+	hashes  []uint32
+	targets map[uint32]*Target
+	rr      *roundRobin // for requests without a key
+}
+
+func newHashRing(targets []*Target) *hashRing {
+	r := &hashRing{targets: make(map[uint32]*Target), rr: &roundRobin{targets: targets}}
+	for _, t := range targets {
+		for i := 0; i < virtualNodes; i++ {
+			h := crc32.ChecksumIEEE([]byte(t.URL + "#" + strconv.Itoa(i)))
+			r.hashes = append(r.hashes, h)
+			r.targets[h] = t
+		}
+	}
+	sort.Slice(r.hashes, func(i, j int) bool { return r.hashes[i] < r.hashes[j] })
+	return r
+}
+
+// Pick walks clockwise from the key's position to the first healthy target,
+// so only the keys of an ejected target move elsewhere.
+func (r *hashRing) Pick(key string) (*Target, error) {
+	if key == "" {
+		return r.rr.Pick(key)
+	}
+
+	h := crc32.ChecksumIEEE([]byte(key))
+	start := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= h })
+	for i := 0; i < len(r.hashes); i++ {
+		if t := r.targets[r.hashes[(start+i)%len(r.hashes)]]; t.Healthy() {
+			return t, nil
+		}
+	}
+	return nil, ErrNoHealthyTarget
+}
@@ -0,0 +1,77 @@
+package balancer
+
+import "testing"
+
+func targets(urls ...string) []*Target {
+	out := make([]*Target, 0, len(urls))
+	for _, u := range urls {
+		out = append(out, NewTarget(u))
+	}
+	return out
+}
+
+func TestRoundRobinSkipsUnhealthy(t *testing.T) {
+	ts := targets("a", "b", "c")
+	ts[1].healthy.Store(false)
+	b, _ := New(RoundRobin, ts)
+
+	var got []string
+	for i := 0; i < 4; i++ {
+		tg, err := b.Pick("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, tg.URL)
+	}
+	if got[0] != "a" || got[1] != "c" || got[2] != "c" || got[3] != "a" {
+		t.Errorf("unexpected order %v", got)
+	}
+
+	ts[0].healthy.Store(false)
+	ts[2].healthy.Store(false)
+	if _, err := b.Pick(""); err != ErrNoHealthyTarget {
+		t.Errorf("expected ErrNoHealthyTarget, got %v", err)
+	}
+}
+
+func TestLeastConnections(t *testing.T) {
+	ts := targets("a", "b")
+	b, _ := New(LeastConnections, ts)
+
+	release := ts[0].Acquire()
+	for i := 0; i < 3; i++ {
+		if tg, _ := b.Pick(""); tg.URL != "b" {
+			t.Fatalf("pick %d = %s, want b", i, tg.URL)
+		}
+	}
+	release()
+}
+
+func TestConsistentHashIsSticky(t *testing.T) {
+	ts := targets("http://a", "http://b", "http://c")
+	b, _ := New(ConsistentHash, ts)
+
+	first, _ := b.Pick("user-42")
+	for i := 0; i < 10; i++ {
+		if tg, _ := b.Pick("user-42"); tg != first {
+			t.Fatalf("user moved from %s to %s", first.URL, tg.URL)
+		}
+	}
+
+	// Ejecting the user's target moves them, and only while it is down
+	first.healthy.Store(false)
+	moved, _ := b.Pick("user-42")
+	if moved == first {
+		t.Fatal("picked an unhealthy target")
+	}
+	first.healthy.Store(true)
+	if back, _ := b.Pick("user-42"); back != first {
+		t.Fatal("user should return to the original target")
+	}
+}
+
+func TestUnknownStrategy(t *testing.T) {
+	if _, err := New("random", targets("a")); err == nil {
+		t.Error("expected error for unknown strategy")
+	}
+}
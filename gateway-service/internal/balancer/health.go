@@ -0,0 +1,141 @@
+package balancer
+
+import (
+	"context"
+	"crypto/tls"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HealthCheck configures active health checking. A target is ejected after
+// UnhealthyThreshold failed probes in a row and restored after
+// HealthyThreshold successful ones.
+type HealthCheck struct {
+	Path               string
+	Interval           time.Duration
+	Timeout            time.Duration
+	HealthyThreshold   int
+	UnhealthyThreshold int
+}
+
+func (hc HealthCheck) Enabled() bool {
+	return hc.Path != "" && hc.Interval > 0
+}
+
+// Pool is the set of targets behind one upstream plus the balancer and the
+// health checker running against them.
+type Pool struct {
+	Name    string
+	Targets []*Target
+	Balancer
+
+	check  HealthCheck
+	client *http.Client
+	stop   chan struct{}
+	once   sync.Once
+}
+
+// NewPool balances over urls. Health probes of https targets use tlsConfig,
+// the system defaults when it is nil.
+func NewPool(name string, urls []string, strategy string, check HealthCheck, tlsConfig *tls.Config) (*Pool, error) {
+	targets := make([]*Target, 0, len(urls))
+	for _, u := range urls {
+		targets = append(targets, NewTarget(u))
+	}
+
+	b, err := New(strategy, targets)
+	if err != nil {
+		return nil, err
+	}
+
+	if check.Timeout <= 0 {
+		check.Timeout = 2 * time.Second
+	}
+	if check.HealthyThreshold <= 0 {
+		check.HealthyThreshold = 1
+	}
+	if check.UnhealthyThreshold <= 0 {
+		check.UnhealthyThreshold = 2
+	}
+
+	p := &Pool{
+		Name:     name,
+		Targets:  targets,
+		Balancer: b,
+		check:    check,
+		client:   &http.Client{Timeout: check.Timeout, Transport: &http.Transport{TLSClientConfig: tlsConfig}},
+		stop:     make(chan struct{}),
+	}
+	if check.Enabled() {
+		for _, t := range targets {
+			go p.watch(t)
+		}
+	}
+	return p, nil
+}
+
+// Close stops the health checkers.
+func (p *Pool) Close() {
+	p.once.Do(func() { close(p.stop) })
+}
+
+func (p *Pool) watch(t *Target) {
+	ticker := time.NewTicker(p.check.Interval)
+	defer ticker.Stop()
+
+	var passes, fails int
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+
+		if p.probe(t) {
+			passes, fails = passes+1, 0
+			if !t.Healthy() && passes >= p.check.HealthyThreshold {
+				t.healthy.Store(true)
+				log.Printf("[HEALTH] %s target %s is healthy again", p.Name, t.URL)
+			}
+		} else {
+			passes, fails = 0, fails+1
+			if t.Healthy() && fails >= p.check.UnhealthyThreshold {
+				t.healthy.Store(false)
+				log.Printf("[HEALTH] %s target %s ejected", p.Name, t.URL)
+			}
+		}
+	}
+}
+
+func (p *Pool) probe(t *Target) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), p.check.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.URL+p.check.Path, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode < http.StatusInternalServerError
+}
+
+// TargetStatus is the admin view of a target.
+type TargetStatus struct {
+	URL     string `json:"url"`
+	Healthy bool   `json:"healthy"`
+	Active  int64  `json:"active_requests"`
+}
+
+func (p *Pool) Status() []TargetStatus {
+	out := make([]TargetStatus, 0, len(p.Targets))
+	for _, t := range p.Targets {
+		out = append(out, TargetStatus{URL: t.URL, Healthy: t.Healthy(), Active: t.Active()})
+	}
+	return out
+}
@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Entry is a cached upstream response.
+type Entry struct {
+	Status int
+	Header http.Header
+	Body   []byte
+	ETag   string
+
+	// Path is the gateway path the response belongs to, used by Invalidate
+	Path       string
+	StoredAt   time.Time
+	FreshUntil time.Time
+	StaleUntil time.Time // >= FreshUntil; served while a refresh runs
+}
+
+// Fresh reports whether e can be served without asking the upstream.
+func (e *Entry) Fresh(now time.Time) bool {
+	return now.Before(e.FreshUntil)
+}
+
+// Store keeps cached responses. MemoryStore is enough for a single gateway
+// instance; several gateways should share one (e.g. Redis) so a mutation
+// through one instance invalidates what the others serve.
+type Store interface {
+	// Get returns the entry for key, nil when there is none or it is past
+	// StaleUntil.
+	Get(ctx context.Context, key string) (*Entry, error)
+	Set(ctx context.Context, key string, e *Entry) error
+	// Invalidate drops every entry for path, for a path above it (the
+	// collection) and below it (its sub-resources).
+	Invalidate(ctx context.Context, path string) error
+}
+
+// Related reports whether a change to one path affects a response cached for
+// the other: /products/1 is related to /products and /products/1/reviews,
+// but not to /products/10.
+func Related(a, b string) bool {
+	a, b = strings.TrimSuffix(a, "/"), strings.TrimSuffix(b, "/")
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	return a == b || strings.HasPrefix(b, a+"/")
+}
@@ -0,0 +1,126 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore is an in-process Store holding at most maxEntries responses.
+// When full, expired entries are dropped first, then the oldest ones.
+type MemoryStore struct {
+	mu         sync.Mutex
+	entries    map[string]*Entry
+	maxEntries int
+	now        func() time.Time
+	stop       chan struct{}
+}
+
+func NewMemoryStore(maxEntries int, sweepInterval time.Duration) *MemoryStore {
+	s := &MemoryStore{
+		entries:    make(map[string]*Entry),
+		maxEntries: maxEntries,
+		now:        time.Now,
+		stop:       make(chan struct{}),
+	}
+	if sweepInterval > 0 {
+		go s.sweep(sweepInterval)
+	}
+	return s
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	if !s.now().Before(e.StaleUntil) {
+		delete(s.entries, key)
+		return nil, nil
+	}
+	return e, nil
+}
+
+func (s *MemoryStore) Set(_ context.Context, key string, e *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[key]; !ok && s.maxEntries > 0 && len(s.entries) >= s.maxEntries {
+		s.evict()
+	}
+	s.entries[key] = e
+	return nil
+}
+
+func (s *MemoryStore) Invalidate(_ context.Context, path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, e := range s.entries {
+		if Related(e.Path, path) {
+			delete(s.entries, key)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of cached entries.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// Close stops the background sweeper.
+func (s *MemoryStore) Close() {
+	select {
+	case <-s.stop:
+	default:
+		close(s.stop)
+	}
+}
+
+// evict makes room for one entry. Called with mu held.
+func (s *MemoryStore) evict() {
+	now := s.now()
+	var (
+		oldestKey string
+		oldest    time.Time
+	)
+	for key, e := range s.entries {
+		if !now.Before(e.StaleUntil) {
+			delete(s.entries, key)
+			continue
+		}
+		if oldestKey == "" || e.StoredAt.Before(oldest) {
+			oldestKey, oldest = key, e.StoredAt
+		}
+	}
+	if len(s.entries) >= s.maxEntries && oldestKey != "" {
+		delete(s.entries, oldestKey)
+	}
+}
+
+func (s *MemoryStore) sweep(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			now := s.now()
+			s.mu.Lock()
+			for key, e := range s.entries {
+				if !now.Before(e.StaleUntil) {
+					delete(s.entries, key)
+				}
+			}
+			s.mu.Unlock()
+		case <-s.stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRelated(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want bool
+	}{
+		{"/api/products", "/api/products", true},
+		{"/api/products", "/api/products/1", true},
+		{"/api/products/1/", "/api/products", true},
+		{"/api/products/1", "/api/products/10", false},
+		{"/api/products", "/api/productsx", false},
+	}
+	for _, tc := range cases {
+		if got := Related(tc.a, tc.b); got != tc.want {
+			t.Errorf("Related(%q, %q) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Unix(0, 0)
+	store := NewMemoryStore(0, 0)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	entry := func(path string) *Entry {
+		return &Entry{Path: path, StoredAt: now, FreshUntil: now.Add(time.Minute), StaleUntil: now.Add(2 * time.Minute)}
+	}
+	store.Set(ctx, "list", entry("/api/products"))
+	store.Set(ctx, "list?page=2", entry("/api/products"))
+	store.Set(ctx, "one", entry("/api/products/1"))
+	store.Set(ctx, "ten", entry("/api/products/10"))
+
+	// Updating product 1 changes the list too, but not product 10
+	store.Invalidate(ctx, "/api/products/1")
+	for key, want := range map[string]bool{"list": false, "list?page=2": false, "one": false, "ten": true} {
+		if e, _ := store.Get(ctx, key); (e != nil) != want {
+			t.Errorf("%s present = %v, want %v", key, e != nil, want)
+		}
+	}
+
+	// Stale entries are still returned until StaleUntil
+	now = now.Add(90 * time.Second)
+	if e, _ := store.Get(ctx, "ten"); e == nil || e.Fresh(now) {
+		t.Error("expected a stale entry")
+	}
+	now = now.Add(time.Minute)
+	if e, _ := store.Get(ctx, "ten"); e != nil {
+		t.Error("entry past StaleUntil should be gone")
+	}
+}
+
+func TestMemoryStoreEvictsOldest(t *testing.T) {
+	now := time.Unix(0, 0)
+	store := NewMemoryStore(2, 0)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i, key := range []string{"a", "b", "c"} {
+		at := now.Add(time.Duration(i) * time.Second)
+		store.Set(ctx, key, &Entry{StoredAt: at, FreshUntil: at.Add(time.Hour), StaleUntil: at.Add(time.Hour)})
+	}
+	if store.Len() != 2 {
+		t.Fatalf("Len = %d, want 2", store.Len())
+	}
+	if e, _ := store.Get(ctx, "a"); e != nil {
+		t.Error("oldest entry should have been evicted")
+	}
+}
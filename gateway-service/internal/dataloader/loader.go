@@ -0,0 +1,102 @@
+package dataloader
+
+import (
+	"context"
+	"sync"
+)
+
+// BatchFunc loads many keys in one go. Keys missing from the map load as
+// the zero value; an error fails every key of the batch.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// Loader collects the keys asked for while a GraphQL level resolves and
+// loads them with one batch call when the first result is needed. Each key
+// is loaded at most once. A Loader belongs to one request: it caches
+// everything it loaded for as long as it lives.
+type Loader[K comparable, V any] struct {
+	batch BatchFunc[K, V]
+
+	mu      sync.Mutex
+	results map[K]*result[V]
+	pending []K
+}
+
+type result[V any] struct {
+	value V
+	err   error
+	done  chan struct{}
+}
+
+func New[K comparable, V any](batch BatchFunc[K, V]) *Loader[K, V] {
+	return &Loader[K, V]{batch: batch, results: make(map[K]*result[V])}
+}
+
+// Load queues key for the next batch and returns a thunk that runs the
+// batch, if no other thunk did yet, and returns the key's value.
+func (l *Loader[K, V]) Load(ctx context.Context, key K) func() (V, error) {
+	l.mu.Lock()
+	r, ok := l.results[key]
+	if !ok {
+		r = &result[V]{done: make(chan struct{})}
+		l.results[key] = r
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (V, error) {
+		l.dispatch(ctx)
+		<-r.done
+		return r.value, r.err
+	}
+}
+
+// LoadMany loads keys in a single batch.
+func (l *Loader[K, V]) LoadMany(ctx context.Context, keys []K) ([]V, error) {
+	thunks := make([]func() (V, error), len(keys))
+	for i, key := range keys {
+		thunks[i] = l.Load(ctx, key)
+	}
+	values := make([]V, len(keys))
+	for i, thunk := range thunks {
+		v, err := thunk()
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// Prime stores value for key unless it is loaded already, for values that
+// came along with another call.
+func (l *Loader[K, V]) Prime(key K, value V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.results[key]; ok {
+		return
+	}
+	r := &result[V]{value: value, done: make(chan struct{})}
+	close(r.done)
+	l.results[key] = r
+}
+
+// dispatch loads every pending key.
+func (l *Loader[K, V]) dispatch(ctx context.Context) {
+	l.mu.Lock()
+	keys := l.pending
+	l.pending = nil
+	batch := make([]*result[V], len(keys))
+	for i, key := range keys {
+		batch[i] = l.results[key]
+	}
+	l.mu.Unlock()
+	if len(keys) == 0 {
+		return
+	}
+
+	values, err := l.batch(ctx, keys)
+	for i, key := range keys {
+		batch[i].value, batch[i].err = values[key], err
+		close(batch[i].done)
+	}
+}
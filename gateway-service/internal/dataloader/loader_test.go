@@ -0,0 +1,71 @@
+package dataloader
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestLoaderBatchesAndDedupes(t *testing.T) {
+	var calls [][]string
+	l := New(func(_ context.Context, keys []string) (map[string]int, error) {
+		calls = append(calls, append([]string(nil), keys...))
+		out := make(map[string]int)
+		for _, k := range keys {
+			if k != "missing" {
+				out[k] = len(k)
+			}
+		}
+		return out, nil
+	})
+	ctx := context.Background()
+
+	a, b, a2, missing := l.Load(ctx, "a"), l.Load(ctx, "bb"), l.Load(ctx, "a"), l.Load(ctx, "missing")
+	if v, _ := b(); v != 2 {
+		t.Errorf("bb = %d", v)
+	}
+	if v, _ := a(); v != 1 {
+		t.Errorf("a = %d", v)
+	}
+	if v, _ := a2(); v != 1 {
+		t.Errorf("a (again) = %d", v)
+	}
+	if v, err := missing(); v != 0 || err != nil {
+		t.Errorf("missing = %d, %v", v, err)
+	}
+
+	if len(calls) != 1 {
+		t.Fatalf("expected one batch, got %v", calls)
+	}
+	sort.Strings(calls[0])
+	if !reflect.DeepEqual(calls[0], []string{"a", "bb", "missing"}) {
+		t.Errorf("unexpected batch %v", calls[0])
+	}
+
+	// Loaded keys come from the cache, new ones make a new batch
+	if v, _ := l.Load(ctx, "a")(); v != 1 || len(calls) != 1 {
+		t.Error("a should be cached")
+	}
+	if v, _ := l.Load(ctx, "ccc")(); v != 3 || len(calls) != 2 {
+		t.Error("ccc should be loaded in a second batch")
+	}
+}
+
+func TestLoaderPrimeAndErrors(t *testing.T) {
+	boom := errors.New("boom")
+	l := New(func(_ context.Context, keys []string) (map[string]int, error) {
+		return nil, boom
+	})
+	ctx := context.Background()
+
+	l.Prime("a", 7)
+	values, err := l.LoadMany(ctx, []string{"a"})
+	if err != nil || values[0] != 7 {
+		t.Errorf("primed key should not be loaded: %v, %v", values, err)
+	}
+	if _, err := l.Load(ctx, "b")(); !errors.Is(err, boom) {
+		t.Errorf("expected the batch error, got %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package deprecation
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// OtherClients collects the use by clients beyond a route's maxClients.
+const OtherClients = "other"
+
+// Usage is how often one client called a deprecated route.
+type Usage struct {
+	Route     string    `json:"route"`
+	Client    string    `json:"client"`
+	Count     int64     `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// Tracker counts the calls to deprecated routes per client, to know whom to
+// talk to before a sunset. It keeps at most maxClients per route in memory.
+type Tracker struct {
+	mu         sync.Mutex
+	usage      map[string]map[string]*Usage // route → client
+	maxClients int
+	now        func() time.Time
+}
+
+func NewTracker(maxClients int) *Tracker {
+	return &Tracker{usage: make(map[string]map[string]*Usage), maxClients: maxClients, now: time.Now}
+}
+
+// Record counts one call of route by client.
+func (t *Tracker) Record(route, client string) {
+	now := t.now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	clients, ok := t.usage[route]
+	if !ok {
+		clients = make(map[string]*Usage)
+		t.usage[route] = clients
+	}
+	u, ok := clients[client]
+	if !ok {
+		if t.maxClients > 0 && len(clients) >= t.maxClients {
+			client = OtherClients
+			u = clients[client]
+		}
+		if u == nil {
+			u = &Usage{Route: route, Client: client, FirstSeen: now}
+			clients[client] = u
+		}
+	}
+	u.Count++
+	u.LastSeen = now
+}
+
+// Snapshot returns the usage of every deprecated route, busiest clients
+// first within each route.
+func (t *Tracker) Snapshot() []Usage {
+	t.mu.Lock()
+	out := make([]Usage, 0)
+	for _, clients := range t.usage {
+		for _, u := range clients {
+			out = append(out, *u)
+		}
+	}
+	t.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Route != out[j].Route {
+			return out[i].Route < out[j].Route
+		}
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Client < out[j].Client
+	})
+	return out
+}
@@ -0,0 +1,44 @@
+package deprecation
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrackerCountsPerClient(t *testing.T) {
+	now := time.Unix(100, 0)
+	tracker := NewTracker(0)
+	tracker.now = func() time.Time { return now }
+
+	tracker.Record("products-v1", "user:a")
+	now = now.Add(time.Minute)
+	tracker.Record("products-v1", "user:a")
+	tracker.Record("products-v1", "user:b")
+	tracker.Record("orders-v1", "ip:1.2.3.4")
+
+	got := tracker.Snapshot()
+	if len(got) != 3 {
+		t.Fatalf("expected 3 entries, got %+v", got)
+	}
+	if got[0].Route != "orders-v1" || got[1].Client != "user:a" || got[1].Count != 2 || got[2].Client != "user:b" {
+		t.Errorf("unexpected order or counts: %+v", got)
+	}
+	if !got[1].FirstSeen.Equal(time.Unix(100, 0)) || !got[1].LastSeen.Equal(now) {
+		t.Errorf("unexpected first/last seen: %+v", got[1])
+	}
+}
+
+func TestTrackerCapsClients(t *testing.T) {
+	tracker := NewTracker(2)
+	for _, client := range []string{"a", "b", "c", "d", "a"} {
+		tracker.Record("r", client)
+	}
+
+	counts := map[string]int64{}
+	for _, u := range tracker.Snapshot() {
+		counts[u.Client] = u.Count
+	}
+	if len(counts) != 3 || counts["a"] != 2 || counts["b"] != 1 || counts[OtherClients] != 2 {
+		t.Errorf("clients beyond the cap should be counted as %q: %v", OtherClients, counts)
+	}
+}
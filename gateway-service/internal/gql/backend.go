@@ -0,0 +1,136 @@
+package gql
+
+import (
+	"context"
+	"sync"
+
+	"gateway-service/internal/dataloader"
+)
+
+// Product, Transaction and Payment are what the upstreams return, with their
+// JSON field names.
+type Product struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Price Money  `json:"price"`
+	Stock int    `json:"stock"`
+}
+
+type Transaction struct {
+	ID        string `json:"id"`
+	ProductID string `json:"product_id"`
+	PaymentID string `json:"payment_id"`
+	Quantity  int    `json:"quantity"`
+	Total     Money  `json:"total"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"created_at"`
+}
+
+type Payment struct {
+	ID        string `json:"id"`
+	Email     string `json:"email"`
+	Amount    Money  `json:"amount"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"created_at"`
+}
+
+// Money is an amount in whole minor units (cents) of an ISO 4217 currency.
+type Money struct {
+	AmountMinor int64  `json:"amount_minor"`
+	Currency    string `json:"currency"`
+}
+
+// Backend is where the schema's data comes from. The single-item methods
+// return nil, nil for an ID that doesn't exist.
+type Backend interface {
+	Products(ctx context.Context) ([]*Product, error)
+	Product(ctx context.Context, id string) (*Product, error)
+	Transactions(ctx context.Context) ([]*Transaction, error)
+	Transaction(ctx context.Context, id string) (*Transaction, error)
+	Payments(ctx context.Context) ([]*Payment, error)
+	Payment(ctx context.Context, id string) (*Payment, error)
+}
+
+// fetchAllAbove is the batch size from which a loader asks for the whole
+// list once instead of making one call per ID.
+const fetchAllAbove = 10
+
+// request is the state of one GraphQL request: who asks, and loaders that
+// batch and cache the lookups of all its resolvers.
+type request struct {
+	backend  Backend
+	email    string
+	products *dataloader.Loader[string, *Product]
+	payments *dataloader.Loader[string, *Payment]
+}
+
+type requestKey struct{}
+
+// WithRequest prepares ctx for executing one request of the user with email.
+func WithRequest(ctx context.Context, backend Backend, email string) context.Context {
+	r := &request{
+		backend:  backend,
+		email:    email,
+		products: dataloader.New(batchByID(backend.Products, backend.Product, func(p *Product) string { return p.ID })),
+		payments: dataloader.New(batchByID(backend.Payments, backend.Payment, func(p *Payment) string { return p.ID })),
+	}
+	return context.WithValue(ctx, requestKey{}, r)
+}
+
+func requestFrom(ctx context.Context) *request {
+	return ctx.Value(requestKey{}).(*request)
+}
+
+// batchByID loads a batch of IDs with concurrent single calls, or with one
+// list call when there are more than fetchAllAbove of them.
+func batchByID[V any](
+	list func(context.Context) ([]V, error),
+	one func(context.Context, string) (V, error),
+	id func(V) string,
+) dataloader.BatchFunc[string, V] {
+	return func(ctx context.Context, keys []string) (map[string]V, error) {
+		out := make(map[string]V, len(keys))
+
+		if len(keys) > fetchAllAbove {
+			all, err := list(ctx)
+			if err != nil {
+				return nil, err
+			}
+			want := make(map[string]bool, len(keys))
+			for _, key := range keys {
+				want[key] = true
+			}
+			for _, v := range all {
+				if want[id(v)] {
+					out[id(v)] = v
+				}
+			}
+			return out, nil
+		}
+
+		var (
+			mu       sync.Mutex
+			wg       sync.WaitGroup
+			firstErr error
+		)
+		for _, key := range keys {
+			wg.Add(1)
+			go func(key string) {
+				defer wg.Done()
+				v, err := one(ctx, key)
+
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+					}
+					return
+				}
+				out[key] = v
+			}(key)
+		}
+		wg.Wait()
+		return out, firstErr
+	}
+}
@@ -0,0 +1,170 @@
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type fakeBackend struct {
+	mu    sync.Mutex
+	calls map[string]int
+
+	products     []*Product
+	transactions []*Transaction
+	payments     []*Payment
+}
+
+func (b *fakeBackend) count(name string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.calls[name]++
+}
+
+func (b *fakeBackend) Products(context.Context) ([]*Product, error) {
+	b.count("Products")
+	return b.products, nil
+}
+
+func (b *fakeBackend) Product(_ context.Context, id string) (*Product, error) {
+	b.count("Product")
+	for _, p := range b.products {
+		if p.ID == id {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+func (b *fakeBackend) Transactions(context.Context) ([]*Transaction, error) {
+	b.count("Transactions")
+	return b.transactions, nil
+}
+
+func (b *fakeBackend) Transaction(_ context.Context, id string) (*Transaction, error) {
+	b.count("Transaction")
+	for _, tx := range b.transactions {
+		if tx.ID == id {
+			return tx, nil
+		}
+	}
+	return nil, nil
+}
+
+func (b *fakeBackend) Payments(context.Context) ([]*Payment, error) {
+	b.count("Payments")
+	return b.payments, nil
+}
+
+func (b *fakeBackend) Payment(_ context.Context, id string) (*Payment, error) {
+	b.count("Payment")
+	for _, p := range b.payments {
+		if p.ID == id {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{
+		calls: make(map[string]int),
+		products: []*Product{
+			{ID: "p1", Name: "Pen", Price: Money{AmountMinor: 200, Currency: "IDR"}, Stock: 5},
+			{ID: "p2", Name: "Ink", Price: Money{AmountMinor: 300, Currency: "IDR"}, Stock: 1},
+		},
+		transactions: []*Transaction{
+			{ID: "t1", ProductID: "p1", PaymentID: "pay1", Quantity: 1, Total: Money{AmountMinor: 200, Currency: "IDR"}, Status: "paid"},
+			{ID: "t2", ProductID: "p2", PaymentID: "pay2", Quantity: 1, Total: Money{AmountMinor: 300, Currency: "IDR"}, Status: "pending"},
+			{ID: "t3", ProductID: "p1", PaymentID: "pay3", Quantity: 2, Total: Money{AmountMinor: 400, Currency: "IDR"}, Status: "paid"},
+		},
+		payments: []*Payment{
+			{ID: "pay1", Email: "a@b.c", Amount: Money{AmountMinor: 200, Currency: "IDR"}, Status: "paid"},
+			{ID: "pay2", Email: "x@y.z", Amount: Money{AmountMinor: 300, Currency: "IDR"}, Status: "pending"},
+			{ID: "pay3", Email: "a@b.c", Amount: Money{AmountMinor: 400, Currency: "IDR"}, Status: "paid"},
+		},
+	}
+}
+
+func run(t *testing.T, backend Backend, limits Limits, query string) (string, bool) {
+	t.Helper()
+	schema, err := NewSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithRequest(context.Background(), backend, "a@b.c")
+	result, executed := Execute(ctx, schema, limits, Params{Query: query})
+	body, _ := json.Marshal(result)
+	return string(body), executed
+}
+
+func TestTransactionsBatchTheirProductsAndPayments(t *testing.T) {
+	b := newFakeBackend()
+	body, executed := run(t, b, Limits{}, `{ transactions { id product { name } payment { status } } }`)
+	if !executed {
+		t.Fatalf("query rejected: %s", body)
+	}
+
+	want := `{"data":{"transactions":[` +
+		`{"id":"t1","payment":{"status":"paid"},"product":{"name":"Pen"}},` +
+		`{"id":"t3","payment":{"status":"paid"},"product":{"name":"Pen"}}]}}`
+	if body != want {
+		t.Errorf("got %s\nwant %s", body, want)
+	}
+	// Payments are loaded once to filter by owner and then come from the
+	// cache; the one product both transactions share is loaded once.
+	if b.calls["Payment"] != 3 || b.calls["Product"] != 1 {
+		t.Errorf("unexpected backend calls %v", b.calls)
+	}
+}
+
+func TestOthersTransactionsAreHidden(t *testing.T) {
+	body, _ := run(t, newFakeBackend(), Limits{}, `{ transaction(id: "t2") { id } payment(id: "pay2") { id } }`)
+	if body != `{"data":{"payment":null,"transaction":null}}` {
+		t.Errorf("got %s", body)
+	}
+}
+
+func TestMoneyPastInt32(t *testing.T) {
+	b := newFakeBackend()
+	b.payments[0].Amount = Money{AmountMinor: 5_000_000_000, Currency: "IDR"}
+	body, _ := run(t, b, Limits{}, `{ payment(id: "pay1") { amount { amountMinor currency } } }`)
+	if want := `{"data":{"payment":{"amount":{"amountMinor":5000000000,"currency":"IDR"}}}}`; body != want {
+		t.Errorf("got %s\nwant %s", body, want)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	query := `
+		query { transactions { ...tx } products { id } }
+		fragment tx on Transaction { id product { id name } }`
+	// depth: transactions > product > name = 3
+	// complexity: transactions 1 + 10*(id 1 + product 1 + 2) = 41, products 1 + 10*1 = 11
+	cases := []struct {
+		limits  Limits
+		wantErr string
+	}{
+		{Limits{MaxDepth: 3, MaxComplexity: 52}, ""},
+		{Limits{MaxDepth: 2}, "query depth 3 exceeds the limit of 2"},
+		{Limits{MaxComplexity: 51}, "query complexity 52 exceeds the limit of 51"},
+	}
+	for _, tc := range cases {
+		body, executed := run(t, newFakeBackend(), tc.limits, query)
+		if tc.wantErr == "" {
+			if !executed {
+				t.Errorf("%+v: query rejected: %s", tc.limits, body)
+			}
+			continue
+		}
+		if executed || !strings.Contains(body, tc.wantErr) {
+			t.Errorf("%+v: expected %q, got %s", tc.limits, tc.wantErr, body)
+		}
+	}
+
+	// Introspection isn't counted
+	if body, executed := run(t, newFakeBackend(), Limits{MaxDepth: 1}, `{ __schema { types { name } } }`); !executed {
+		t.Errorf("introspection rejected: %s", body)
+	}
+}
@@ -0,0 +1,128 @@
+package gql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// listCost is how many items a list field is assumed to hold when pricing
+// what is selected on them.
+const listCost = 10
+
+// Limits bound what one query may ask for. Depth counts nested fields, the
+// top-level ones being 1. Complexity counts every field once, and the fields
+// under a list listCost times. Introspection fields aren't counted. Zero
+// means no limit.
+type Limits struct {
+	MaxDepth      int
+	MaxComplexity int
+}
+
+// Check measures the operation of doc that will run and fails if it goes
+// over a limit. doc must have passed validation.
+func (l Limits) Check(schema graphql.Schema, doc *ast.Document, operationName string) error {
+	w := walker{schema: schema, fragments: make(map[string]*ast.FragmentDefinition)}
+	var op *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.OperationDefinition:
+			if operationName == "" || def.Name != nil && def.Name.Value == operationName {
+				op = def
+			}
+		case *ast.FragmentDefinition:
+			w.fragments[def.Name.Value] = def
+		}
+	}
+	if op == nil {
+		return nil // the executor reports the unknown operation
+	}
+
+	root := graphql.Type(schema.QueryType())
+	if op.Operation == ast.OperationTypeMutation {
+		root = schema.MutationType()
+	}
+	depth, complexity := w.selectionSet(op.SelectionSet, root, 1)
+	if l.MaxDepth > 0 && depth > l.MaxDepth {
+		return fmt.Errorf("query depth %d exceeds the limit of %d", depth, l.MaxDepth)
+	}
+	if l.MaxComplexity > 0 && complexity > l.MaxComplexity {
+		return fmt.Errorf("query complexity %d exceeds the limit of %d", complexity, l.MaxComplexity)
+	}
+	return nil
+}
+
+type walker struct {
+	schema    graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+}
+
+// selectionSet returns the deepest field level under set, which sits at
+// depth, and what its fields cost.
+func (w walker) selectionSet(set *ast.SelectionSet, parent graphql.Type, depth int) (maxDepth, cost int) {
+	if set == nil {
+		return 0, 0
+	}
+	for _, sel := range set.Selections {
+		d, c := 0, 0
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(sel.Name.Value, "__") {
+				continue
+			}
+			d, c = depth, 1
+			if sel.SelectionSet != nil {
+				named, isList := unwrap(fieldType(parent, sel.Name.Value))
+				childDepth, childCost := w.selectionSet(sel.SelectionSet, named, depth+1)
+				if isList {
+					childCost *= listCost
+				}
+				d, c = max(d, childDepth), c+childCost
+			}
+		case *ast.InlineFragment:
+			t := parent
+			if sel.TypeCondition != nil {
+				t = w.schema.Type(sel.TypeCondition.Name.Value)
+			}
+			d, c = w.selectionSet(sel.SelectionSet, t, depth)
+		case *ast.FragmentSpread:
+			// Validation has ruled out unknown and cyclic fragments
+			frag := w.fragments[sel.Name.Value]
+			if frag == nil {
+				continue
+			}
+			d, c = w.selectionSet(frag.SelectionSet, w.schema.Type(frag.TypeCondition.Name.Value), depth)
+		}
+		maxDepth, cost = max(maxDepth, d), cost+c
+	}
+	return maxDepth, cost
+}
+
+func fieldType(parent graphql.Type, name string) graphql.Type {
+	obj, ok := parent.(*graphql.Object)
+	if !ok {
+		return nil
+	}
+	field, ok := obj.Fields()[name]
+	if !ok {
+		return nil
+	}
+	return field.Type
+}
+
+// unwrap strips the non-null and list wrappers off t and tells whether there
+// was a list among them.
+func unwrap(t graphql.Type) (named graphql.Type, isList bool) {
+	for {
+		switch w := t.(type) {
+		case *graphql.NonNull:
+			t = w.OfType
+		case *graphql.List:
+			t, isList = w.OfType, true
+		default:
+			return t, isList
+		}
+	}
+}
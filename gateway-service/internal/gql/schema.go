@@ -0,0 +1,237 @@
+package gql
+
+import (
+	"context"
+	"math"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Fields without a resolver are read from the struct field of the same name,
+// compared case-insensitively (createdAt is CreatedAt, productId ProductID).
+
+// longType carries 64-bit integers, which GraphQL's Int (32 bits) can't:
+// minor units of IDR pass 2^31 at about 21 million rupiah.
+var longType = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "Long",
+	Description: "A 64-bit integer.",
+	Serialize: func(value interface{}) interface{} {
+		if v, ok := value.(int64); ok {
+			return v
+		}
+		return nil
+	},
+	ParseValue: func(value interface{}) interface{} {
+		if v, ok := value.(float64); ok && v == math.Trunc(v) && math.Abs(v) < 1<<63 {
+			return int64(v)
+		}
+		return nil
+	},
+	ParseLiteral: func(valueAST ast.Value) interface{} {
+		if v, ok := valueAST.(*ast.IntValue); ok {
+			if n, err := strconv.ParseInt(v.Value, 10, 64); err == nil {
+				return n
+			}
+		}
+		return nil
+	},
+})
+
+var moneyType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "Money",
+	Description: "An amount in whole minor units (cents) of an ISO 4217 currency.",
+	Fields: graphql.Fields{
+		"amountMinor": &graphql.Field{Type: graphql.NewNonNull(longType)},
+		"currency":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+	},
+})
+
+var productType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Product",
+	Fields: graphql.Fields{
+		"id":    &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+		"name":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"price": &graphql.Field{Type: graphql.NewNonNull(moneyType)},
+		"stock": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+	},
+})
+
+var paymentType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Payment",
+	Fields: graphql.Fields{
+		"id":        &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+		"email":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"amount":    &graphql.Field{Type: graphql.NewNonNull(moneyType)},
+		"status":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"createdAt": &graphql.Field{Type: graphql.String},
+	},
+})
+
+var transactionType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Transaction",
+	Fields: graphql.Fields{
+		"id":        &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+		"productId": &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+		"paymentId": &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+		"quantity":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"total":     &graphql.Field{Type: graphql.NewNonNull(moneyType)},
+		"status":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"createdAt": &graphql.Field{Type: graphql.String},
+		"product": &graphql.Field{
+			Type: productType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				tx := p.Source.(*Transaction)
+				return thunk(requestFrom(p.Context).products.Load(p.Context, tx.ProductID)), nil
+			},
+		},
+		"payment": &graphql.Field{
+			Type: paymentType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				tx := p.Source.(*Transaction)
+				return thunk(requestFrom(p.Context).payments.Load(p.Context, tx.PaymentID)), nil
+			},
+		},
+	},
+})
+
+var idArgs = graphql.FieldConfigArgument{
+	"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+}
+
+var queryType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Query",
+	Fields: graphql.Fields{
+		"products": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(productType))),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				r := requestFrom(p.Context)
+				products, err := r.backend.Products(p.Context)
+				if err != nil {
+					return nil, err
+				}
+				for _, product := range products {
+					r.products.Prime(product.ID, product)
+				}
+				return products, nil
+			},
+		},
+		"product": &graphql.Field{
+			Type: productType,
+			Args: idArgs,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id, _ := p.Args["id"].(string)
+				return thunk(requestFrom(p.Context).products.Load(p.Context, id)), nil
+			},
+		},
+		"payments": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(paymentType))),
+			Description: "The caller's payments.",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return ownPayments(p.Context)
+			},
+		},
+		"payment": &graphql.Field{
+			Type: paymentType,
+			Args: idArgs,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				r := requestFrom(p.Context)
+				id, _ := p.Args["id"].(string)
+				load := r.payments.Load(p.Context, id)
+				return func() (interface{}, error) {
+					payment, err := load()
+					if err != nil || payment == nil || payment.Email != r.email {
+						return nil, err
+					}
+					return payment, nil
+				}, nil
+			},
+		},
+		"transactions": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(transactionType))),
+			Description: "The caller's transactions.",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return ownTransactions(p.Context)
+			},
+		},
+		"transaction": &graphql.Field{
+			Type: transactionType,
+			Args: idArgs,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				r := requestFrom(p.Context)
+				id, _ := p.Args["id"].(string)
+				tx, err := r.backend.Transaction(p.Context, id)
+				if err != nil || tx == nil {
+					return nil, err
+				}
+				// Whoever owns the payment owns the transaction
+				payment, err := r.payments.Load(p.Context, tx.PaymentID)()
+				if err != nil || payment == nil || payment.Email != r.email {
+					return nil, err
+				}
+				return tx, nil
+			},
+		},
+	},
+})
+
+// NewSchema builds the gateway's GraphQL schema. Resolvers expect the
+// context to come from WithRequest.
+func NewSchema() (graphql.Schema, error) {
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
+
+// thunk adapts a loader thunk to what graphql-go resolves lazily. The
+// executor resolves a whole level before calling thunks, so every key asked
+// for on that level goes into one batch.
+func thunk[V any](load func() (V, error)) func() (interface{}, error) {
+	return func() (interface{}, error) {
+		v, err := load()
+		if err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+}
+
+func ownPayments(ctx context.Context) ([]*Payment, error) {
+	r := requestFrom(ctx)
+	payments, err := r.backend.Payments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	own := make([]*Payment, 0, len(payments))
+	for _, payment := range payments {
+		r.payments.Prime(payment.ID, payment)
+		if payment.Email == r.email {
+			own = append(own, payment)
+		}
+	}
+	return own, nil
+}
+
+// ownTransactions filters the transactions by the email on their payments,
+// which are loaded in one batch and stay cached for the payment field.
+func ownTransactions(ctx context.Context) ([]*Transaction, error) {
+	r := requestFrom(ctx)
+	transactions, err := r.backend.Transactions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(transactions))
+	for i, tx := range transactions {
+		ids[i] = tx.PaymentID
+	}
+	payments, err := r.payments.LoadMany(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	own := make([]*Transaction, 0, len(transactions))
+	for i, tx := range transactions {
+		if payments[i] != nil && payments[i].Email == r.email {
+			own = append(own, tx)
+		}
+	}
+	return own, nil
+}
@@ -0,0 +1,23 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// GRPC checks a gRPC backend through grpc.health.v1; service "" asks about
+// the server as a whole.
+func GRPC(client healthpb.HealthClient, service string) Check {
+	return func(ctx context.Context) error {
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			return err
+		}
+		if resp.Status != healthpb.HealthCheckResponse_SERVING {
+			return fmt.Errorf("status %s", resp.Status)
+		}
+		return nil
+	}
+}
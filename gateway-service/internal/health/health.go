@@ -0,0 +1,85 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Check reports whether one dependency is usable.
+type Check func(ctx context.Context) error
+
+// Checker runs the readiness checks of a service. Liveness never looks at
+// dependencies: a restart doesn't fix a database that is down.
+type Checker struct {
+	timeout time.Duration
+	names   []string
+	checks  map[string]Check
+}
+
+// Status is the body of /readyz.
+type Status struct {
+	Status string            `json:"status"` // ok / unavailable
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout, checks: make(map[string]Check)}
+}
+
+// Add registers a dependency check under name.
+func (h *Checker) Add(name string, check Check) *Checker {
+	h.names = append(h.names, name)
+	h.checks[name] = check
+	sort.Strings(h.names)
+	return h
+}
+
+// Run executes all checks concurrently, each bounded by the checker timeout.
+func (h *Checker) Run(ctx context.Context) Status {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		out = Status{Status: "ok", Checks: make(map[string]string, len(h.names))}
+	)
+	for _, name := range h.names {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			result := "ok"
+			if err := check(ctx); err != nil {
+				result = err.Error()
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			out.Checks[name] = result
+			if result != "ok" {
+				out.Status = "unavailable"
+			}
+		}(name, h.checks[name])
+	}
+	wg.Wait()
+	return out
+}
+
+// Liveness handles GET /healthz.
+func (h *Checker) Liveness(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Readiness handles GET /readyz: 200 when every check passes, 503 otherwise.
+func (h *Checker) Readiness(c echo.Context) error {
+	status := h.Run(c.Request().Context())
+	code := http.StatusOK
+	if status.Status != "ok" {
+		code = http.StatusServiceUnavailable
+	}
+	return c.JSON(code, status)
+}
@@ -0,0 +1,38 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Response is the stored answer to a request, replayed for its retries.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Record is what the store holds for a key. Response is nil while the first
+// request with that key is still running.
+type Record struct {
+	Fingerprint string
+	Response    *Response
+}
+
+// Store keeps idempotency records. MemoryStore is enough for a single
+// gateway instance; several gateways behind a load balancer need a shared
+// implementation (e.g. Redis, SET NX for Begin) so a retry landing on
+// another instance still finds the original.
+type Store interface {
+	// Begin claims key for a request with fingerprint, holding it for at
+	// most lockTTL. It returns nil when the claim succeeded, otherwise the
+	// record that already holds the key.
+	Begin(ctx context.Context, key, fingerprint string, lockTTL time.Duration) (*Record, error)
+	// Complete stores the response of a claimed key for ttl, along with the
+	// fingerprint of the request it answers, in case the claim expired while
+	// the request ran.
+	Complete(ctx context.Context, key, fingerprint string, resp Response, ttl time.Duration) error
+	// Release drops a claim without a response, so the request can be retried.
+	Release(ctx context.Context, key string) error
+}
@@ -0,0 +1,111 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	record  Record
+	expires time.Time
+}
+
+// MemoryStore is an in-process Store. Expired records are dropped by a
+// background sweeper.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+	now     func() time.Time
+	stop    chan struct{}
+}
+
+func NewMemoryStore(sweepInterval time.Duration) *MemoryStore {
+	s := &MemoryStore{
+		entries: make(map[string]*entry),
+		now:     time.Now,
+		stop:    make(chan struct{}),
+	}
+	if sweepInterval > 0 {
+		go s.sweep(sweepInterval)
+	}
+	return s
+}
+
+func (s *MemoryStore) Begin(_ context.Context, key, fingerprint string, lockTTL time.Duration) (*Record, error) {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok && now.Before(e.expires) {
+		record := e.record
+		return &record, nil
+	}
+	s.entries[key] = &entry{record: Record{Fingerprint: fingerprint}, expires: now.Add(lockTTL)}
+	return nil, nil
+}
+
+func (s *MemoryStore) Complete(_ context.Context, key, fingerprint string, resp Response, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		// The claim expired while the request ran; store it anyway
+		e = &entry{}
+		s.entries[key] = e
+	}
+	e.record.Fingerprint = fingerprint
+	e.record.Response = &resp
+	e.expires = s.now().Add(ttl)
+	return nil
+}
+
+func (s *MemoryStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok && e.record.Response == nil {
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+// Len returns the number of stored records, expired ones included until the
+// next sweep.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// Close stops the background sweeper.
+func (s *MemoryStore) Close() {
+	select {
+	case <-s.stop:
+	default:
+		close(s.stop)
+	}
+}
+
+func (s *MemoryStore) sweep(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			now := s.now()
+			s.mu.Lock()
+			for key, e := range s.entries {
+				if !now.Before(e.expires) {
+					delete(s.entries, key)
+				}
+			}
+			s.mu.Unlock()
+		case <-s.stop:
+			return
+		}
+	}
+}
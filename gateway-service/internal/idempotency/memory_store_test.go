@@ -0,0 +1,87 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreLifecycle(t *testing.T) {
+	now := time.Unix(0, 0)
+	store := NewMemoryStore(0)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if rec, _ := store.Begin(ctx, "k", "fp", time.Minute); rec != nil {
+		t.Fatal("first Begin should claim the key")
+	}
+
+	// A retry while the first request runs sees it in flight
+	rec, _ := store.Begin(ctx, "k", "fp", time.Minute)
+	if rec == nil || rec.Response != nil || rec.Fingerprint != "fp" {
+		t.Fatalf("expected in-flight record, got %+v", rec)
+	}
+
+	store.Complete(ctx, "k", "fp", Response{Status: 201, Body: []byte("ok")}, time.Hour)
+	rec, _ = store.Begin(ctx, "k", "other", time.Minute)
+	if rec == nil || rec.Response == nil || rec.Response.Status != 201 || rec.Fingerprint != "fp" {
+		t.Fatalf("expected stored response, got %+v", rec)
+	}
+
+	// Release doesn't drop a completed record
+	store.Release(ctx, "k")
+	if rec, _ := store.Begin(ctx, "k", "fp", time.Minute); rec == nil {
+		t.Error("completed record should survive Release")
+	}
+
+	now = now.Add(time.Hour)
+	if rec, _ := store.Begin(ctx, "k", "fp", time.Minute); rec != nil {
+		t.Error("expired record should be claimable again")
+	}
+}
+
+func TestMemoryStoreRelease(t *testing.T) {
+	store := NewMemoryStore(0)
+	ctx := context.Background()
+
+	store.Begin(ctx, "k", "fp", time.Minute)
+	store.Release(ctx, "k")
+	if rec, _ := store.Begin(ctx, "k", "fp", time.Minute); rec != nil {
+		t.Error("released key should be claimable again")
+	}
+}
+
+func TestMemoryStoreLockExpires(t *testing.T) {
+	now := time.Unix(0, 0)
+	store := NewMemoryStore(0)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	store.Begin(ctx, "k", "fp", time.Minute)
+	now = now.Add(time.Minute)
+	if rec, _ := store.Begin(ctx, "k", "fp", time.Minute); rec != nil {
+		t.Error("an abandoned claim should expire after its lock TTL")
+	}
+}
+
+func TestMemoryStoreCompleteAfterLockExpired(t *testing.T) {
+	now := time.Unix(0, 0)
+	store := NewMemoryStore(0)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	store.Begin(ctx, "k", "fp", time.Minute)
+	// The request outlives its claim, which the sweeper drops
+	now = now.Add(2 * time.Minute)
+	store.mu.Lock()
+	delete(store.entries, "k")
+	store.mu.Unlock()
+
+	store.Complete(ctx, "k", "fp", Response{Status: 201, Body: []byte("ok")}, time.Hour)
+
+	// The client's retry of the same request gets the response replayed
+	rec, _ := store.Begin(ctx, "k", "fp", time.Minute)
+	if rec == nil || rec.Response == nil || rec.Fingerprint != "fp" {
+		t.Fatalf("expected the stored response with its fingerprint, got %+v", rec)
+	}
+}
@@ -0,0 +1,14 @@
+package metrics
+
+import (
+	grpcprom "github.com/grpc-ecosystem/go-grpc-middleware/providers/prometheus"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// GRPCClient records grpc_client_* metrics for calls to the gRPC upstreams.
+// Its interceptor runs inside the retry one, so each attempt is counted.
+var GRPCClient = grpcprom.NewClientMetrics(grpcprom.WithClientHandlingTimeHistogram())
+
+func init() {
+	prometheus.MustRegister(GRPCClient)
+}
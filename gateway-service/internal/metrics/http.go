@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_request_errors_total",
+		Help: "HTTP requests answered with a 5xx status.",
+	}, []string{"method", "route"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by method and route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+// RouteKey is the context key a handler sets to label its request with a
+// config route name instead of the echo pattern (proxied routes all share /*).
+const RouteKey = "metrics.route"
+
+// Middleware records request count, errors and latency. Routes are labelled
+// with their pattern (/products/:id) or RouteKey, never the raw path.
+func Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if c.Path() == "/metrics" {
+				return next(c)
+			}
+
+			start := time.Now()
+			err := next(c)
+
+			status := ResponseStatus(c, err)
+			route := c.Path()
+			if name, ok := c.Get(RouteKey).(string); ok {
+				route = name
+			} else if route == "" {
+				route = "unmatched"
+			}
+			method := c.Request().Method
+
+			httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+			httpDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+			if status >= http.StatusInternalServerError {
+				httpErrors.WithLabelValues(method, route).Inc()
+			}
+			return err
+		}
+	}
+}
+
+// ResponseStatus is the status c is answered with once next returned err,
+// including the one echo's error handler has yet to write.
+func ResponseStatus(c echo.Context, err error) int {
+	if err == nil || c.Response().Committed {
+		return c.Response().Status
+	}
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		return he.Code
+	}
+	return http.StatusInternalServerError
+}
+
+// Handler serves the default registry in the Prometheus text format.
+func Handler() echo.HandlerFunc {
+	return echo.WrapHandler(promhttp.Handler())
+}
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// UpstreamRequests counts attempts sent to a REST upstream target, by
+	// status code or "error" when no response came back.
+	UpstreamRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_upstream_requests_total",
+		Help: "Requests sent to upstream targets by upstream, target and status.",
+	}, []string{"upstream", "target", "status"})
+
+	// UpstreamDuration is the time until response headers from a target.
+	UpstreamDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gateway_upstream_request_duration_seconds",
+		Help:    "Upstream latency until response headers.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"upstream", "target"})
+
+	// CacheRequests counts GETs on cached routes by how they were answered:
+	// hit, stale, miss or bypass.
+	CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_cache_requests_total",
+		Help: "Response cache lookups by route and result.",
+	}, []string{"route", "result"})
+
+	// VersionRequests counts the requests of a split route by the version
+	// they were sent to and the status answered, to compare a canary's error
+	// rate with the stable version's.
+	VersionRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_route_version_requests_total",
+		Help: "Requests of split routes by route, version and status.",
+	}, []string{"route", "version", "status"})
+
+	// VersionDuration is the latency of a split route per version.
+	VersionDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gateway_route_version_request_duration_seconds",
+		Help:    "Latency of split routes by route and version.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "version"})
+
+	// ShadowRequests counts mirrored requests by how the shadow's answer
+	// compared with the primary's: match, status_mismatch, body_mismatch,
+	// error, dropped or skipped.
+	ShadowRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_shadow_requests_total",
+		Help: "Requests mirrored to shadow backends by route and result.",
+	}, []string{"route", "result"})
+
+	// DeprecatedRequests counts calls to deprecated routes; the gateway's
+	// /admin/deprecations lists them per client.
+	DeprecatedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_deprecated_requests_total",
+		Help: "Requests to deprecated routes by route.",
+	}, []string{"route"})
+)
@@ -0,0 +1,13 @@
+package pb
+
+import _ "embed"
+
+// Swagger 2.0 descriptions of the REST mappings of the RPCs, generated by
+// protoc-gen-openapiv2 from the google.api.http options.
+var (
+	//go:embed payment.swagger.json
+	PaymentSwagger []byte
+
+	//go:embed auth.swagger.json
+	AuthSwagger []byte
+)
@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"sort"
+	"sync"
+)
+
+// Counters counts the requests each rate limit group let through and turned
+// away since the gateway started.
+type Counters struct {
+	mu     sync.Mutex
+	groups map[string]*GroupCount
+}
+
+// GroupCount is the tally of one group.
+type GroupCount struct {
+	Group   string `json:"group"`
+	Policy  string `json:"policy"`
+	Allowed uint64 `json:"allowed"`
+	Limited uint64 `json:"limited"`
+}
+
+func NewCounters() *Counters {
+	return &Counters{groups: make(map[string]*GroupCount)}
+}
+
+// Record counts one request of group under policy.
+func (c *Counters) Record(group string, policy Policy, allowed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	g, ok := c.groups[group]
+	if !ok {
+		g = &GroupCount{Group: group}
+		c.groups[group] = g
+	}
+	// A reload can change a route's policy; show the current one
+	g.Policy = policy.String()
+	if allowed {
+		g.Allowed++
+	} else {
+		g.Limited++
+	}
+}
+
+// Snapshot returns every group, sorted by name.
+func (c *Counters) Snapshot() []GroupCount {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out := make([]GroupCount, 0, len(c.groups))
+	for _, g := range c.groups {
+		out = append(out, *g)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Group < out[j].Group })
+	return out
+}
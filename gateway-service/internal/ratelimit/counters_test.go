@@ -0,0 +1,25 @@
+package ratelimit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCounters(t *testing.T) {
+	c := NewCounters()
+	policy := Policy{Limit: 2, Period: time.Minute}
+
+	c.Record("route:products", policy, true)
+	c.Record("route:products", policy, false)
+	c.Record("auth", policy, true)
+	c.Record("route:products", Policy{Limit: 5, Period: time.Minute}, true)
+
+	want := []GroupCount{
+		{Group: "auth", Policy: "2/1m0s", Allowed: 1},
+		{Group: "route:products", Policy: "5/1m0s", Allowed: 2, Limited: 1},
+	}
+	if got := c.Snapshot(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Snapshot() = %+v, want %+v", got, want)
+	}
+}
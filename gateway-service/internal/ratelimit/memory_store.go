@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// MemoryStore is an in-process Store. Buckets idle for longer than idleTTL
+// are dropped by a background sweeper so the map doesn't grow forever.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	idleTTL time.Duration
+	now     func() time.Time
+	stop    chan struct{}
+}
+
+func NewMemoryStore(idleTTL time.Duration) *MemoryStore {
+	s := &MemoryStore{
+		buckets: make(map[string]*bucket),
+		idleTTL: idleTTL,
+		now:     time.Now,
+		stop:    make(chan struct{}),
+	}
+	if idleTTL > 0 {
+		go s.sweep()
+	}
+	return s
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, policy Policy) (Result, error) {
+	capacity := float64(policy.capacity())
+	rate := policy.refillRate()
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, lastSeen: now}
+		s.buckets[key] = b
+	} else {
+		elapsed := now.Sub(b.lastSeen).Seconds()
+		b.tokens = math.Min(capacity, b.tokens+elapsed*rate)
+		b.lastSeen = now
+	}
+
+	res := Result{Limit: policy.capacity()}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = secondsToDuration((1 - b.tokens) / rate)
+	}
+	res.Remaining = int(math.Floor(b.tokens))
+	res.ResetAfter = secondsToDuration((capacity - b.tokens) / rate)
+
+	return res, nil
+}
+
+// Len returns the number of tracked buckets.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.buckets)
+}
+
+// Close stops the background sweeper.
+func (s *MemoryStore) Close() {
+	select {
+	case <-s.stop:
+	default:
+		close(s.stop)
+	}
+}
+
+func (s *MemoryStore) sweep() {
+	ticker := time.NewTicker(s.idleTTL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			cutoff := s.now().Add(-s.idleTTL)
+			s.mu.Lock()
+			for key, b := range s.buckets {
+				if b.lastSeen.Before(cutoff) {
+					delete(s.buckets, key)
+				}
+			}
+			s.mu.Unlock()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+func secondsToDuration(sec float64) time.Duration {
+	return time.Duration(math.Ceil(sec * float64(time.Second)))
+}
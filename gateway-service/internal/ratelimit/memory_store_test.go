@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	now := time.Unix(0, 0)
+	store := NewMemoryStore(0)
+	store.now = func() time.Time { return now }
+
+	policy := Policy{Limit: 2, Period: time.Second}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		res, _ := store.Take(ctx, "k", policy)
+		if !res.Allowed {
+			t.Fatalf("request %d should be allowed", i)
+		}
+	}
+
+	res, _ := store.Take(ctx, "k", policy)
+	if res.Allowed {
+		t.Fatal("third request should be limited")
+	}
+	if res.RetryAfter != 500*time.Millisecond {
+		t.Errorf("RetryAfter = %v, want 500ms", res.RetryAfter)
+	}
+
+	// Other keys have their own bucket
+	if res, _ := store.Take(ctx, "other", policy); !res.Allowed {
+		t.Error("other key should be allowed")
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	res, _ = store.Take(ctx, "k", policy)
+	if !res.Allowed {
+		t.Fatal("request after refill should be allowed")
+	}
+	if res.Remaining != 0 {
+		t.Errorf("Remaining = %d, want 0", res.Remaining)
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    Policy
+		wantErr bool
+	}{
+		{"120/1m", Policy{Limit: 120, Period: time.Minute}, false},
+		{"10/1s,20", Policy{Limit: 10, Period: time.Second, Burst: 20}, false},
+		{"off", Policy{}, false},
+		{"", Policy{}, false},
+		{"10", Policy{}, true},
+		{"x/1m", Policy{}, true},
+		{"10/abc", Policy{}, true},
+	}
+
+	for _, tc := range cases {
+		got, err := ParsePolicy(tc.in)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("ParsePolicy(%q) err = %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ParsePolicy(%q) = %+v, want %+v", tc.in, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy is a token-bucket limit: Limit requests per Period, with room for
+// Burst requests at once. Burst defaults to Limit when left at zero.
+type Policy struct {
+	Limit  int
+	Period time.Duration
+	Burst  int
+}
+
+// Enabled reports whether the policy actually limits anything.
+func (p Policy) Enabled() bool {
+	return p.Limit > 0 && p.Period > 0
+}
+
+func (p Policy) capacity() int {
+	if p.Burst > 0 {
+		return p.Burst
+	}
+	return p.Limit
+}
+
+// refillRate returns how many tokens are added back per second.
+func (p Policy) refillRate() float64 {
+	return float64(p.Limit) / p.Period.Seconds()
+}
+
+func (p Policy) String() string {
+	if !p.Enabled() {
+		return "off"
+	}
+	s := fmt.Sprintf("%d/%s", p.Limit, p.Period)
+	if p.Burst > 0 && p.Burst != p.Limit {
+		s += fmt.Sprintf(",%d", p.Burst)
+	}
+	return s
+}
+
+// ParsePolicy parses "<limit>/<period>[,<burst>]", e.g. "120/1m" or
+// "10/1s,20". An empty string or "off" disables limiting.
+func ParsePolicy(s string) (Policy, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "off" {
+		return Policy{}, nil
+	}
+
+	spec, burstStr, hasBurst := strings.Cut(s, ",")
+	limitStr, periodStr, ok := strings.Cut(spec, "/")
+	if !ok {
+		return Policy{}, fmt.Errorf("rate limit %q: expected <limit>/<period>", s)
+	}
+
+	limit, err := strconv.Atoi(strings.TrimSpace(limitStr))
+	if err != nil || limit <= 0 {
+		return Policy{}, fmt.Errorf("rate limit %q: invalid limit", s)
+	}
+	period, err := time.ParseDuration(strings.TrimSpace(periodStr))
+	if err != nil || period <= 0 {
+		return Policy{}, fmt.Errorf("rate limit %q: invalid period", s)
+	}
+
+	p := Policy{Limit: limit, Period: period}
+	if hasBurst {
+		burst, err := strconv.Atoi(strings.TrimSpace(burstStr))
+		if err != nil || burst <= 0 {
+			return Policy{}, fmt.Errorf("rate limit %q: invalid burst", s)
+		}
+		p.Burst = burst
+	}
+	return p, nil
+}
+
+// Result describes the outcome of taking a token from a bucket.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// ResetAfter is how long until the bucket is full again.
+	ResetAfter time.Duration
+	// RetryAfter is how long until the next token is available. Zero when
+	// the request was allowed.
+	RetryAfter time.Duration
+}
+
+// Store keeps bucket state. MemoryStore is enough for a single gateway
+// instance; deployments running several gateways behind a load balancer
+// should plug in a shared implementation (e.g. Redis with an atomic script)
+// so every instance draws from the same buckets.
+type Store interface {
+	Take(ctx context.Context, key string, policy Policy) (Result, error)
+}
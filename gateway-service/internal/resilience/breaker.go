@@ -0,0 +1,222 @@
+package resilience
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrBreakerOpen is returned by Allow while the breaker rejects calls.
+var ErrBreakerOpen = errors.New("circuit breaker is open")
+
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// BreakerSettings controls when a breaker trips and recovers.
+type BreakerSettings struct {
+	// FailureThreshold consecutive failures open the breaker.
+	FailureThreshold int
+	// OpenTimeout is how long the breaker stays open before letting probe
+	// requests through.
+	OpenTimeout time.Duration
+	// HalfOpenRequests probes must succeed to close the breaker again.
+	HalfOpenRequests int
+}
+
+// Breaker is a consecutive-failure circuit breaker. Every Allow that returns
+// nil must be followed by exactly one Record or Release.
+type Breaker struct {
+	name     string
+	settings BreakerSettings
+	now      func() time.Time
+
+	mu        sync.Mutex
+	state     State
+	failures  int
+	successes int
+	inFlight  int
+	openedAt  time.Time
+}
+
+func NewBreaker(name string, settings BreakerSettings) *Breaker {
+	return &Breaker{name: name, settings: settings.withDefaults(), now: time.Now}
+}
+
+func (s BreakerSettings) withDefaults() BreakerSettings {
+	if s.FailureThreshold <= 0 {
+		s.FailureThreshold = 5
+	}
+	if s.OpenTimeout <= 0 {
+		s.OpenTimeout = 30 * time.Second
+	}
+	if s.HalfOpenRequests <= 0 {
+		s.HalfOpenRequests = 1
+	}
+	return s
+}
+
+func (b *Breaker) Name() string { return b.name }
+
+// Allow reports whether a call may proceed.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateOpen {
+		if b.now().Sub(b.openedAt) < b.settings.OpenTimeout {
+			return ErrBreakerOpen
+		}
+		b.setState(StateHalfOpen)
+	}
+
+	if b.state == StateHalfOpen {
+		if b.inFlight >= b.settings.HalfOpenRequests {
+			return ErrBreakerOpen
+		}
+		b.inFlight++
+	}
+	return nil
+}
+
+// Record reports the outcome of a call admitted by Allow.
+func (b *Breaker) Record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateHalfOpen:
+		b.inFlight--
+		if !success {
+			b.setState(StateOpen)
+			return
+		}
+		b.successes++
+		if b.successes >= b.settings.HalfOpenRequests {
+			b.setState(StateClosed)
+		}
+	case StateClosed:
+		if success {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.settings.FailureThreshold {
+			b.setState(StateOpen)
+		}
+	}
+}
+
+// Release gives back a call admitted by Allow that says nothing about the
+// upstream, such as one the client hung up on: it neither fails nor succeeds.
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateHalfOpen && b.inFlight > 0 {
+		b.inFlight--
+	}
+}
+
+// setSettings applies new settings from the next call on. A closed breaker
+// that already has as many failures as the new threshold opens on the next.
+func (b *Breaker) setSettings(settings BreakerSettings) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.settings = settings
+}
+
+func (b *Breaker) setState(s State) {
+	b.state = s
+	b.failures = 0
+	b.successes = 0
+	b.inFlight = 0
+	if s == StateOpen {
+		b.openedAt = b.now()
+	}
+}
+
+// BreakerSnapshot is the admin view of a breaker.
+type BreakerSnapshot struct {
+	Name     string     `json:"name"`
+	State    string     `json:"state"`
+	Failures int        `json:"consecutive_failures"`
+	OpenedAt *time.Time `json:"opened_at,omitempty"`
+}
+
+func (b *Breaker) Snapshot() BreakerSnapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := b.state
+	if state == StateOpen && b.now().Sub(b.openedAt) >= b.settings.OpenTimeout {
+		state = StateHalfOpen
+	}
+
+	snap := BreakerSnapshot{Name: b.name, State: state.String(), Failures: b.failures}
+	if state == StateOpen {
+		openedAt := b.openedAt
+		snap.OpenedAt = &openedAt
+	}
+	return snap
+}
+
+// BreakerRegistry keeps one breaker per upstream so they can be listed on
+// the admin endpoint.
+type BreakerRegistry struct {
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+}
+
+func NewBreakerRegistry() *BreakerRegistry {
+	return &BreakerRegistry{breakers: make(map[string]*Breaker)}
+}
+
+// Get returns the breaker for name, creating it with settings on first use.
+// Later calls with other settings (a config reload) apply them to the
+// existing breaker, which keeps its state.
+func (r *BreakerRegistry) Get(name string, settings BreakerSettings) *Breaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if b, ok := r.breakers[name]; ok {
+		b.setSettings(settings.withDefaults())
+		return b
+	}
+	b := NewBreaker(name, settings)
+	r.breakers[name] = b
+	return b
+}
+
+func (r *BreakerRegistry) Snapshots() []BreakerSnapshot {
+	r.mu.Lock()
+	breakers := make([]*Breaker, 0, len(r.breakers))
+	for _, b := range r.breakers {
+		breakers = append(breakers, b)
+	}
+	r.mu.Unlock()
+
+	snaps := make([]BreakerSnapshot, 0, len(breakers))
+	for _, b := range breakers {
+		snaps = append(snaps, b.Snapshot())
+	}
+	sort.Slice(snaps, func(i, j int) bool { return snaps[i].Name < snaps[j].Name })
+	return snaps
+}
@@ -0,0 +1,124 @@
+package resilience
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreakerTransitions(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := NewBreaker("payment", BreakerSettings{FailureThreshold: 2, OpenTimeout: time.Second, HalfOpenRequests: 1})
+	b.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if err := b.Allow(); err != nil {
+			t.Fatalf("closed breaker rejected call %d: %v", i, err)
+		}
+		b.Record(false)
+	}
+
+	if err := b.Allow(); err != ErrBreakerOpen {
+		t.Fatalf("expected ErrBreakerOpen, got %v", err)
+	}
+	if got := b.Snapshot().State; got != "open" {
+		t.Fatalf("state = %s, want open", got)
+	}
+
+	// After the open timeout a single probe is let through
+	now = now.Add(time.Second)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("half-open breaker rejected probe: %v", err)
+	}
+	if err := b.Allow(); err != ErrBreakerOpen {
+		t.Fatalf("second concurrent probe should be rejected, got %v", err)
+	}
+
+	// Failed probe re-opens
+	b.Record(false)
+	if err := b.Allow(); err != ErrBreakerOpen {
+		t.Fatalf("expected re-opened breaker, got %v", err)
+	}
+
+	now = now.Add(time.Second)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe rejected: %v", err)
+	}
+	b.Record(true)
+	if got := b.Snapshot().State; got != "closed" {
+		t.Fatalf("state = %s, want closed", got)
+	}
+}
+
+func TestBreakerSuccessResetsFailures(t *testing.T) {
+	b := NewBreaker("product", BreakerSettings{FailureThreshold: 2})
+
+	b.Allow()
+	b.Record(false)
+	b.Allow()
+	b.Record(true)
+	b.Allow()
+	b.Record(false)
+
+	if got := b.Snapshot().State; got != "closed" {
+		t.Fatalf("state = %s, want closed", got)
+	}
+}
+
+func TestBreakerRelease(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := NewBreaker("payment", BreakerSettings{FailureThreshold: 1, OpenTimeout: time.Second})
+	b.now = func() time.Time { return now }
+
+	// Released calls are not failures
+	for i := 0; i < 3; i++ {
+		b.Allow()
+		b.Release()
+	}
+	if got := b.Snapshot().State; got != "closed" {
+		t.Fatalf("state = %s, want closed", got)
+	}
+
+	b.Allow()
+	b.Record(false)
+	now = now.Add(time.Second)
+
+	// A released probe frees its slot for the next one
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe rejected: %v", err)
+	}
+	b.Release()
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe after a released one rejected: %v", err)
+	}
+	b.Record(true)
+	if got := b.Snapshot().State; got != "closed" {
+		t.Fatalf("state = %s, want closed", got)
+	}
+}
+
+func TestBreakerRegistryReload(t *testing.T) {
+	r := NewBreakerRegistry()
+	b := r.Get("payment", BreakerSettings{FailureThreshold: 5, OpenTimeout: time.Minute})
+	now := time.Unix(0, 0)
+	b.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		b.Allow()
+		b.Record(false)
+	}
+
+	// Reloaded: same breaker, same failures, the new settings
+	if got := r.Get("payment", BreakerSettings{FailureThreshold: 3, OpenTimeout: time.Second}); got != b {
+		t.Fatal("reload replaced the breaker")
+	}
+	b.Allow()
+	b.Record(false)
+	if got := b.Snapshot().State; got != "open" {
+		t.Fatalf("state = %s, want open after the lowered threshold", got)
+	}
+
+	now = now.Add(time.Second)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe rejected after the new open timeout: %v", err)
+	}
+}
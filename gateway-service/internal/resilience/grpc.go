@@ -0,0 +1,60 @@
+package resilience
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryClientInterceptor applies a per-call timeout, the breaker and, for
+// methods idempotent reports true for, retries on codes.Unavailable.
+func UnaryClientInterceptor(b *Breaker, p RetryPolicy, timeout time.Duration, idempotent func(fullMethod string) bool) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		policy := p
+		if idempotent == nil || !idempotent(method) {
+			policy.MaxAttempts = 1
+		}
+
+		return Retry(ctx, policy, func(int) (bool, error) {
+			if err := b.Allow(); err != nil {
+				return false, status.Error(codes.Unavailable, b.Name()+": "+err.Error())
+			}
+
+			callCtx, cancel := ctx, context.CancelFunc(func() {})
+			if timeout > 0 {
+				callCtx, cancel = context.WithTimeout(ctx, timeout)
+			}
+			err := invoker(callCtx, method, req, reply, cc, opts...)
+			cancel()
+
+			code := status.Code(err)
+			if code == codes.Canceled && ctx.Err() != nil {
+				// The caller gave up: no word on the upstream's health
+				b.Release()
+				return false, err
+			}
+			b.Record(!isUpstreamFailure(code))
+			return code == codes.Unavailable, err
+		})
+	}
+}
+
+// isUpstreamFailure separates "the server is unhealthy" from ordinary
+// application errors, which must not trip the breaker.
+func isUpstreamFailure(code codes.Code) bool {
+	switch code {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+		return true
+	}
+	return false
+}
+
+// ReadOnlyMethods treats RPCs named Get*/List* as idempotent.
+func ReadOnlyMethods(fullMethod string) bool {
+	name := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+	return strings.HasPrefix(name, "Get") || strings.HasPrefix(name, "List")
+}
@@ -0,0 +1,47 @@
+package resilience
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryClientInterceptorCanceled(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := NewBreaker("payment-grpc", BreakerSettings{FailureThreshold: 1, OpenTimeout: time.Second})
+	b.now = func() time.Time { return now }
+	interceptor := UnaryClientInterceptor(b, RetryPolicy{MaxAttempts: 1}, 0, nil)
+
+	fail := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return status.Error(codes.Unavailable, "down")
+	}
+	canceled := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		<-ctx.Done()
+		return status.FromContextError(ctx.Err()).Err()
+	}
+
+	interceptor(context.Background(), "/payment.PaymentService/GetPayment", nil, nil, nil, fail)
+	now = now.Add(time.Second)
+
+	// The probe's caller hangs up: the breaker neither closes nor re-opens
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := interceptor(ctx, "/payment.PaymentService/GetPayment", nil, nil, nil, canceled); status.Code(err) != codes.Canceled {
+		t.Fatalf("expected Canceled, got %v", err)
+	}
+	if got := b.Snapshot().State; got != "half-open" {
+		t.Fatalf("state = %s, want half-open", got)
+	}
+
+	// The next probe still goes through, and its failure re-opens
+	if err := interceptor(context.Background(), "/payment.PaymentService/GetPayment", nil, nil, nil, fail); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected the probe to reach the upstream, got %v", err)
+	}
+	if got := b.Snapshot().State; got != "open" {
+		t.Fatalf("state = %s, want open", got)
+	}
+}
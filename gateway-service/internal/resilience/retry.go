@@ -0,0 +1,62 @@
+package resilience
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy bounds how often and how fast a failed call is retried.
+type RetryPolicy struct {
+	// MaxAttempts includes the first try; 1 disables retries.
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Backoff returns the delay before the given retry (1-based), using
+// exponential backoff with full jitter.
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	ceiling := p.BaseDelay << (retry - 1)
+	if ceiling <= 0 || ceiling > p.MaxDelay {
+		ceiling = p.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling) + 1
+}
+
+// Retry calls fn until it succeeds, reports the error as not retryable, the
+// attempts run out or ctx is done. The last error is returned.
+func Retry(ctx context.Context, p RetryPolicy, fn func(attempt int) (retryable bool, err error)) error {
+	attempts := max(p.MaxAttempts, 1)
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		var retryable bool
+		retryable, err = fn(attempt)
+		if err == nil || !retryable || attempt == attempts {
+			return err
+		}
+
+		timer := time.NewTimer(p.Backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+	return err
+}
+
+// IsIdempotentMethod reports whether an HTTP method may be retried safely.
+func IsIdempotentMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
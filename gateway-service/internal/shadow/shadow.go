@@ -0,0 +1,135 @@
+package shadow
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// Results of mirroring one request, the result label of
+// gateway_shadow_requests_total.
+const (
+	Match          = "match"
+	StatusMismatch = "status_mismatch"
+	BodyMismatch   = "body_mismatch"
+	Error          = "error"   // the shadow couldn't be reached
+	Dropped        = "dropped" // too many copies in flight already
+	Skipped        = "skipped" // a body too large to compare
+)
+
+// maxDiffs caps the paths a Diff lists.
+const maxDiffs = 10
+
+// Response is what one side answered.
+type Response struct {
+	Status int
+	Body   []byte
+}
+
+// Diff is the outcome of comparing a shadow response with the primary one.
+type Diff struct {
+	Result string
+	// Paths are the JSON fields that differ ("items[0].price", "" for the
+	// whole body), at most maxDiffs
+	Paths []string
+}
+
+// Compare compares the shadow's answer with the primary's. JSON bodies are
+// compared by value, so key order and spacing don't count, leaving out
+// fields named in ignore at any depth (IDs, timestamps). Other bodies must
+// match byte for byte.
+func Compare(primary, shadow Response, ignore []string) Diff {
+	if primary.Status != shadow.Status {
+		return Diff{Result: StatusMismatch}
+	}
+
+	var a, b interface{}
+	if decode(primary.Body, &a) != nil || decode(shadow.Body, &b) != nil {
+		if bytes.Equal(primary.Body, shadow.Body) {
+			return Diff{Result: Match}
+		}
+		return Diff{Result: BodyMismatch, Paths: []string{""}}
+	}
+
+	skip := make(map[string]bool, len(ignore))
+	for _, name := range ignore {
+		skip[name] = true
+	}
+	var paths []string
+	diff("", a, b, skip, &paths)
+	if len(paths) == 0 {
+		return Diff{Result: Match}
+	}
+	return Diff{Result: BodyMismatch, Paths: paths}
+}
+
+func decode(body []byte, v *interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// diff appends to paths where a and b differ.
+func diff(path string, a, b interface{}, skip map[string]bool, paths *[]string) {
+	if len(*paths) >= maxDiffs {
+		return
+	}
+	switch a := a.(type) {
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok {
+			*paths = append(*paths, path)
+			return
+		}
+		keys := make([]string, 0, len(a)+len(b))
+		for k := range a {
+			keys = append(keys, k)
+		}
+		for k := range b {
+			if _, ok := a[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if skip[k] {
+				continue
+			}
+			diff(join(path, k), a[k], b[k], skip, paths)
+		}
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			*paths = append(*paths, path)
+			return
+		}
+		for i := range a {
+			diff(path+"["+strconv.Itoa(i)+"]", a[i], b[i], skip, paths)
+		}
+	case json.Number:
+		// 10 and 10.0 are the same amount
+		b, ok := b.(json.Number)
+		if !ok {
+			*paths = append(*paths, path)
+			return
+		}
+		x, errA := a.Float64()
+		y, errB := b.Float64()
+		if errA != nil || errB != nil || x != y {
+			*paths = append(*paths, path)
+		}
+	default:
+		if !reflect.DeepEqual(a, b) {
+			*paths = append(*paths, path)
+		}
+	}
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
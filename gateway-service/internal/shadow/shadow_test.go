@@ -0,0 +1,60 @@
+package shadow
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name      string
+		primary   Response
+		shadow    Response
+		ignore    []string
+		wantRes   string
+		wantPaths []string
+	}{
+		{
+			name:    "same JSON in another order",
+			primary: Response{200, []byte(`{"a":1,"b":[1,2]}`)},
+			shadow:  Response{200, []byte(`{ "b": [1, 2], "a": 1.0 }`)},
+			wantRes: Match,
+		},
+		{
+			name:    "status differs",
+			primary: Response{201, []byte(`{}`)},
+			shadow:  Response{200, []byte(`{}`)},
+			wantRes: StatusMismatch,
+		},
+		{
+			name:      "fields differ",
+			primary:   Response{200, []byte(`{"amount":10,"items":[{"qty":1}],"status":"paid"}`)},
+			shadow:    Response{200, []byte(`{"amount":"10","items":[{"qty":2}],"extra":true,"status":"paid"}`)},
+			wantRes:   BodyMismatch,
+			wantPaths: []string{"amount", "extra", "items[0].qty"},
+		},
+		{
+			name:    "ignored fields at any depth",
+			primary: Response{200, []byte(`{"id":"a","items":[{"id":"x","qty":1}]}`)},
+			shadow:  Response{200, []byte(`{"id":"b","items":[{"id":"y","qty":1}]}`)},
+			ignore:  []string{"id"},
+			wantRes: Match,
+		},
+		{
+			name:      "not JSON",
+			primary:   Response{200, []byte(`ok`)},
+			shadow:    Response{200, []byte(`OK`)},
+			wantRes:   BodyMismatch,
+			wantPaths: []string{""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Compare(tt.primary, tt.shadow, tt.ignore)
+			if got.Result != tt.wantRes || !reflect.DeepEqual(got.Paths, tt.wantPaths) {
+				t.Errorf("got %+v, want %s %v", got, tt.wantRes, tt.wantPaths)
+			}
+		})
+	}
+}
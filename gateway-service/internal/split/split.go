@@ -0,0 +1,91 @@
+package split
+
+import (
+	"hash/crc32"
+	"math/rand/v2"
+	"strings"
+)
+
+// Sticky modes: how a client keeps getting the same version.
+const (
+	StickyUser   = "user"   // JWT user ID, the cookie for anonymous clients
+	StickyCookie = "cookie" // the gateway's sticky cookie only
+	StickyNone   = "none"   // a new pick for every request
+)
+
+// ValidSticky reports whether mode is a sticky mode; "" means user.
+func ValidSticky(mode string) bool {
+	switch mode {
+	case "", StickyUser, StickyCookie, StickyNone:
+		return true
+	}
+	return false
+}
+
+// Version is one upstream version of a route and its share of the traffic.
+type Version struct {
+	Name   string
+	Weight int
+	// Canary marks the version X-Canary: true asks for
+	Canary bool
+}
+
+// Splitter spreads a route's traffic over its versions by weight.
+//
+// Every key falls into a fixed bucket of [0, total weight) and the versions
+// own consecutive ranges of buckets in the order they are listed. With the
+// new version listed last, raising its weight only moves clients onto it:
+// nobody who already got it is sent back.
+type Splitter struct {
+	route    string
+	versions []Version
+	total    int
+}
+
+// New returns a splitter for route. The caller has checked that the weights
+// are not negative and add up to more than zero.
+func New(route string, versions []Version) *Splitter {
+	s := &Splitter{route: route, versions: versions}
+	for _, v := range versions {
+		s.total += v.Weight
+	}
+	return s
+}
+
+func (s *Splitter) Versions() []Version { return s.versions }
+
+// Pick returns the index of the version for key. The same key gets the same
+// version for as long as the weights stay the same; "" picks at random.
+func (s *Splitter) Pick(key string) int {
+	var bucket int
+	if key == "" {
+		bucket = rand.IntN(s.total)
+	} else {
+		// Hashed with the route so a user isn't on the canary of every route
+		bucket = int(crc32.ChecksumIEEE([]byte(s.route+"|"+key)) % uint32(s.total))
+	}
+
+	for i, v := range s.versions {
+		if bucket < v.Weight {
+			return i
+		}
+		bucket -= v.Weight
+	}
+	return len(s.versions) - 1 // not reached
+}
+
+// Override resolves an X-Canary header: "true" is the first canary version,
+// "false" the first one that isn't, anything else a version name. ok is
+// false when the value names no version.
+func (s *Splitter) Override(value string) (index int, ok bool) {
+	value = strings.TrimSpace(value)
+	for i, v := range s.versions {
+		switch {
+		case strings.EqualFold(value, "true") && v.Canary,
+			strings.EqualFold(value, "false") && !v.Canary,
+			value == v.Name:
+			return i, true
+		}
+	}
+	return 0, false
+}
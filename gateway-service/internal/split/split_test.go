@@ -0,0 +1,62 @@
+package split
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestPickFollowsWeights(t *testing.T) {
+	s := New("products", []Version{{Name: "stable", Weight: 90}, {Name: "canary", Weight: 10, Canary: true}})
+
+	counts := make([]int, 2)
+	for i := 0; i < 10000; i++ {
+		counts[s.Pick("user-"+strconv.Itoa(i))]++
+	}
+	if counts[1] < 800 || counts[1] > 1200 {
+		t.Errorf("canary should get about 10%% of users, got %d/10000", counts[1])
+	}
+}
+
+func TestPickIsSticky(t *testing.T) {
+	s := New("products", []Version{{Name: "stable", Weight: 50}, {Name: "canary", Weight: 50}})
+	first := s.Pick("alice")
+	for i := 0; i < 100; i++ {
+		if s.Pick("alice") != first {
+			t.Fatal("the same key should always get the same version")
+		}
+	}
+}
+
+func TestRaisingCanaryWeightKeepsItsUsers(t *testing.T) {
+	small := New("products", []Version{{Name: "stable", Weight: 90}, {Name: "canary", Weight: 10}})
+	large := New("products", []Version{{Name: "stable", Weight: 50}, {Name: "canary", Weight: 50}})
+
+	for i := 0; i < 1000; i++ {
+		key := "user-" + strconv.Itoa(i)
+		if small.Pick(key) == 1 && large.Pick(key) != 1 {
+			t.Fatalf("%s was moved off the canary when its weight went up", key)
+		}
+	}
+}
+
+func TestZeroWeightIsNeverPicked(t *testing.T) {
+	s := New("products", []Version{{Name: "stable", Weight: 1}, {Name: "next", Weight: 0}})
+	for i := 0; i < 100; i++ {
+		if s.Pick("") != 0 || s.Pick(strconv.Itoa(i)) != 0 {
+			t.Fatal("a version with weight 0 is only reachable by override")
+		}
+	}
+}
+
+func TestOverride(t *testing.T) {
+	s := New("products", []Version{{Name: "v1", Weight: 1}, {Name: "v2", Weight: 0, Canary: true}})
+
+	for value, want := range map[string]int{"true": 1, "TRUE": 1, "false": 0, "v1": 0, "v2": 1} {
+		if got, ok := s.Override(value); !ok || got != want {
+			t.Errorf("Override(%q) = %d, %v; want %d", value, got, ok, want)
+		}
+	}
+	if _, ok := s.Override("v3"); ok {
+		t.Error("unknown version should not override")
+	}
+}
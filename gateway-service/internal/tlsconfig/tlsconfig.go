@@ -0,0 +1,178 @@
+// Package tlsconfig builds the TLS settings of the service's listeners and
+// of its connections to other services from PEM files. Certificates are
+// read again when their files change, so they can be renewed in place.
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// reloadInterval is how often certificate files are checked for changes.
+const reloadInterval = 10 * time.Second
+
+// Files are the PEM files of one TLS identity, read from <prefix>_CERT_FILE,
+// <prefix>_KEY_FILE and <prefix>_CA_FILE.
+type Files struct {
+	prefix string
+	Cert   string
+	Key    string
+	CA     string // the CA peers' certificates must be signed by
+}
+
+func FromEnv(prefix string) Files {
+	return Files{
+		prefix: prefix,
+		Cert:   os.Getenv(prefix + "_CERT_FILE"),
+		Key:    os.Getenv(prefix + "_KEY_FILE"),
+		CA:     os.Getenv(prefix + "_CA_FILE"),
+	}
+}
+
+// Server returns the config of a listener serving Cert, nil when Cert isn't
+// set. With CA set, clients must present a certificate it signed (mTLS).
+func (f Files) Server() (*tls.Config, error) {
+	if f.Cert == "" && f.Key == "" {
+		return nil, nil
+	}
+	cert, err := f.certificate()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: cert.getCertificate}
+	if f.CA != "" {
+		if cfg.ClientCAs, err = loadPool(f.CA); err != nil {
+			return nil, err
+		}
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
+
+// Client returns the config for dialing other services: their certificates
+// are verified against CA (the system roots when it isn't set), and Cert is
+// presented to those that ask for a client certificate. nil when none of the
+// files is set.
+func (f Files) Client() (*tls.Config, error) {
+	if f.Cert == "" && f.Key == "" && f.CA == "" {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	var err error
+	if f.CA != "" {
+		if cfg.RootCAs, err = loadPool(f.CA); err != nil {
+			return nil, err
+		}
+	}
+	if f.Cert != "" || f.Key != "" {
+		cert, err := f.certificate()
+		if err != nil {
+			return nil, err
+		}
+		cfg.GetClientCertificate = cert.getClientCertificate
+	}
+	return cfg, nil
+}
+
+func (f Files) certificate() (*reloader, error) {
+	if f.Cert == "" || f.Key == "" {
+		return nil, fmt.Errorf("%s_CERT_FILE and %s_KEY_FILE must be set together", f.prefix, f.prefix)
+	}
+	r := &reloader{certFile: f.Cert, keyFile: f.Key}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	r.last = r.modTimes()
+	go r.watch()
+	return r, nil
+}
+
+func loadPool(file string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("%s: no certificates found", file)
+	}
+	return pool, nil
+}
+
+// reloader holds a certificate and swaps in the one on disk when its files
+// change. A pair that doesn't load (say the key isn't written yet) is tried
+// again on the next check while the current one stays in use.
+type reloader struct {
+	certFile, keyFile string
+	cert              atomic.Pointer[tls.Certificate]
+	last              [2]time.Time
+}
+
+func (r *reloader) load() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load %s: %w", r.certFile, err)
+	}
+	r.cert.Store(&cert)
+	return nil
+}
+
+func (r *reloader) watch() {
+	ticker := time.NewTicker(reloadInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		r.check()
+	}
+}
+
+// check reloads the certificate if its files changed since the last load.
+func (r *reloader) check() {
+	current := r.modTimes()
+	if current == r.last {
+		return
+	}
+	if err := r.load(); err != nil {
+		log.Printf("[TLS] reload failed, keeping current certificate: %v", err)
+		return
+	}
+	r.last = current
+	log.Printf("[TLS] reloaded %s", r.certFile)
+}
+
+func (r *reloader) modTimes() [2]time.Time {
+	var times [2]time.Time
+	for i, file := range []string{r.certFile, r.keyFile} {
+		if info, err := os.Stat(file); err == nil {
+			times[i] = info.ModTime()
+		}
+	}
+	return times
+}
+
+func (r *reloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+func (r *reloader) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// StartEcho serves e on address, over TLS when cfg isn't nil. e.Shutdown
+// stops it either way.
+func StartEcho(e *echo.Echo, address string, cfg *tls.Config) error {
+	if cfg == nil {
+		return e.Start(address)
+	}
+	e.TLSServer.Addr = address
+	e.TLSServer.TLSConfig = cfg
+	return e.StartServer(e.TLSServer)
+}
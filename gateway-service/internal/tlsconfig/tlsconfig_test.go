@@ -0,0 +1,128 @@
+package tlsconfig
+
+import (
+	"bytes"
+	"crypto/tls"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFiles(t *testing.T, ca *CA, name string) Files {
+	t.Helper()
+	certPEM, keyPEM, err := ca.Issue([]string{name, "127.0.0.1"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	f := Files{
+		prefix: "TLS",
+		Cert:   filepath.Join(dir, "cert.pem"),
+		Key:    filepath.Join(dir, "key.pem"),
+		CA:     filepath.Join(dir, "ca.pem"),
+	}
+	for file, data := range map[string][]byte{f.Cert: certPEM, f.Key: keyPEM, f.CA: ca.CertPEM} {
+		if err := os.WriteFile(file, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return f
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca, err := NewCA("test CA", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverCfg, err := writeFiles(t, ca, "server").Server()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// httptest's StartTLS would put its own certificate in front of ours
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", serverCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+		}),
+		ErrorLog: log.New(io.Discard, "", 0),
+	}
+	go srv.Serve(ln)
+	defer srv.Close()
+	url := "https://" + ln.Addr().String()
+
+	get := func(f Files) (string, error) {
+		cfg, err := f.Client()
+		if err != nil {
+			t.Fatal(err)
+		}
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}}
+		resp, err := client.Get(url)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		var body bytes.Buffer
+		body.ReadFrom(resp.Body)
+		return body.String(), nil
+	}
+
+	clientFiles := writeFiles(t, ca, "client")
+	if who, err := get(clientFiles); err != nil || who != "client" {
+		t.Errorf("with a client certificate: %q, %v", who, err)
+	}
+	if _, err := get(Files{CA: clientFiles.CA}); err == nil {
+		t.Error("the server should require a client certificate")
+	}
+
+	other, _ := NewCA("other CA", time.Hour)
+	if _, err := get(writeFiles(t, other, "client")); err == nil {
+		t.Error("a certificate from another CA should be refused")
+	}
+}
+
+func TestReloaderPicksUpChangedFiles(t *testing.T) {
+	ca, _ := NewCA("test CA", time.Hour)
+	f := writeFiles(t, ca, "first")
+	r, err := f.certificate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := r.cert.Load()
+
+	// A half-written pair keeps the current certificate
+	later := time.Now().Add(time.Minute)
+	os.WriteFile(f.Cert, []byte("not a certificate"), 0o600)
+	os.Chtimes(f.Cert, later, later)
+	r.check()
+	if r.cert.Load() != first {
+		t.Fatal("a broken certificate file replaced the current certificate")
+	}
+
+	next := writeFiles(t, ca, "second")
+	for _, file := range [][2]string{{next.Cert, f.Cert}, {next.Key, f.Key}} {
+		data, _ := os.ReadFile(file[0])
+		os.WriteFile(file[1], data, 0o600)
+		os.Chtimes(file[1], later.Add(time.Minute), later.Add(time.Minute))
+	}
+	r.check()
+	if r.cert.Load() == first {
+		t.Fatal("the new certificate wasn't loaded")
+	}
+}
+
+func TestCertAndKeyGoTogether(t *testing.T) {
+	if _, err := (Files{prefix: "TLS", Cert: "cert.pem"}).Server(); err == nil || err.Error() != "TLS_CERT_FILE and TLS_KEY_FILE must be set together" {
+		t.Errorf("unexpected error %v", err)
+	}
+	if cfg, err := (Files{}).Client(); cfg != nil || err != nil {
+		t.Errorf("no files should mean no TLS, got %v, %v", cfg, err)
+	}
+}
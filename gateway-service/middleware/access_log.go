@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"gateway-service/internal/metrics"
+
+	"github.com/labstack/echo/v4"
+)
+
+// UpstreamKey is the context key a handler sets to the upstream that served
+// the request, for the access log.
+const UpstreamKey = "gateway.upstream"
+
+// AccessLogEntry is one line of the access log.
+type AccessLogEntry struct {
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"request_id,omitempty"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Route     string    `json:"route"`
+	Upstream  string    `json:"upstream,omitempty"`
+	UserID    string    `json:"user_id,omitempty"`
+	RemoteIP  string    `json:"remote_ip"`
+	Status    int       `json:"status"`
+	LatencyMS float64   `json:"latency_ms"`
+	BytesIn   int64     `json:"bytes_in"`
+	BytesOut  int64     `json:"bytes_out"`
+}
+
+// AccessLog writes an AccessLogEntry as one JSON line to w for every
+// request. The route is the config route name or the echo pattern, like
+// the metrics label.
+func AccessLog(w io.Writer) echo.MiddlewareFunc {
+	var mu sync.Mutex
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+
+			req, res := c.Request(), c.Response()
+			entry := AccessLogEntry{
+				Time:      start.UTC(),
+				RequestID: res.Header().Get(echo.HeaderXRequestID),
+				Method:    req.Method,
+				Path:      req.URL.Path,
+				Route:     c.Path(),
+				UserID:    UserID(c),
+				RemoteIP:  c.RealIP(),
+				Status:    metrics.ResponseStatus(c, err),
+				LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+				BytesIn:   max(req.ContentLength, 0),
+				BytesOut:  res.Size,
+			}
+			if name, ok := c.Get(metrics.RouteKey).(string); ok {
+				entry.Route = name
+			}
+			entry.Upstream, _ = c.Get(UpstreamKey).(string)
+
+			line, _ := json.Marshal(entry)
+			mu.Lock()
+			w.Write(append(line, '\n'))
+			mu.Unlock()
+			return err
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"gateway-service/internal/idempotency"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// HeaderIdempotencyKey is sent by clients that may retry a POST.
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed marks a response replayed from the store.
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLen = 255
+	// idempotencyLockTTL bounds how long an unfinished request (e.g. the
+	// gateway died mid-way) keeps its key locked.
+	idempotencyLockTTL = 2 * time.Minute
+	// maxReplayBody is the largest response worth storing; bigger ones are
+	// passed through and the key released.
+	maxReplayBody = 1 << 20
+)
+
+// Idempotency makes POSTs that carry an Idempotency-Key safe to retry. The
+// first response (status, headers, body) is stored for ttl under user + key
+// and replayed to retries; a retry while the original still runs gets 409,
+// and reusing a key for a different request gets 422. 5xx responses aren't
+// stored, so the client can retry those for real. Runs after JWTMiddleware.
+func Idempotency(store idempotency.Store, ttl time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			idemKey := req.Header.Get(HeaderIdempotencyKey)
+			if req.Method != http.MethodPost || idemKey == "" {
+				return next(c)
+			}
+			if len(idemKey) > maxIdempotencyKeyLen {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "Idempotency-Key too long"})
+			}
+
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{"error": "Request body too large"})
+				}
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "Failed to read request body"})
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := req.Context()
+			key := "idem|" + IdentityKey(c) + "|" + idemKey
+			fingerprint := requestFingerprint(req, body)
+
+			record, err := store.Begin(ctx, key, fingerprint, idempotencyLockTTL)
+			if err != nil {
+				log.Printf("[IDEMPOTENCY] store error: %v", err)
+				return next(c)
+			}
+			if record != nil {
+				switch {
+				case record.Fingerprint != fingerprint:
+					return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Idempotency-Key was already used for a different request"})
+				case record.Response == nil:
+					c.Response().Header().Set(echo.HeaderRetryAfter, "1")
+					return c.JSON(http.StatusConflict, map[string]string{"error": "A request with this Idempotency-Key is still in progress"})
+				default:
+					return replay(c, record.Response)
+				}
+			}
+
+			// Headers set so far belong to this attempt (request ID, rate
+			// limit, CORS) and aren't stored
+			before := make(map[string]bool)
+			for name := range c.Response().Header() {
+				before[name] = true
+			}
+			rec := &responseRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = rec
+			err = next(c)
+			c.Response().Writer = rec.ResponseWriter
+
+			status := c.Response().Status
+			if err != nil || status >= http.StatusInternalServerError || rec.overflow || !c.Response().Committed {
+				if rerr := store.Release(ctx, key); rerr != nil {
+					log.Printf("[IDEMPOTENCY] store error: %v", rerr)
+				}
+				return err
+			}
+
+			header := make(http.Header)
+			for name, values := range c.Response().Header() {
+				if !before[name] {
+					header[name] = append([]string(nil), values...)
+				}
+			}
+			resp := idempotency.Response{Status: status, Header: header, Body: rec.body.Bytes()}
+			if err := store.Complete(ctx, key, fingerprint, resp, ttl); err != nil {
+				log.Printf("[IDEMPOTENCY] store error: %v", err)
+			}
+			return nil
+		}
+	}
+}
+
+// requestFingerprint identifies what a key was first used for.
+func requestFingerprint(req *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, req.Method+" "+req.URL.Path+"?"+req.URL.RawQuery+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func replay(c echo.Context, resp *idempotency.Response) error {
+	h := c.Response().Header()
+	for name, values := range resp.Header {
+		h[name] = append([]string(nil), values...)
+	}
+	h.Set(HeaderIdempotentReplayed, "true")
+	c.Response().WriteHeader(resp.Status)
+	_, err := c.Response().Write(resp.Body)
+	return err
+}
+
+// responseRecorder copies the body written through it, up to maxReplayBody.
+type responseRecorder struct {
+	http.ResponseWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if !r.overflow {
+		if r.body.Len()+len(b) > maxReplayBody {
+			r.overflow = true
+			r.body.Reset()
+		} else {
+			r.body.Write(b)
+		}
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the flusher underneath.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
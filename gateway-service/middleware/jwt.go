@@ -46,3 +46,36 @@ func JWTMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 		return next(c)
 	}
 }
+
+// RequireRoles only lets through users whose "role" or "roles" claim contains
+// at least one of roles. Must run after JWTMiddleware.
+func RequireRoles(roles ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims, _ := c.Get("user").(jwt.MapClaims)
+			for _, have := range claimRoles(claims) {
+				for _, want := range roles {
+					if have == want {
+						return next(c)
+					}
+				}
+			}
+			return echo.NewHTTPError(http.StatusForbidden, "Insufficient role")
+		}
+	}
+}
+
+func claimRoles(claims jwt.MapClaims) []string {
+	var roles []string
+	if role, ok := claims["role"].(string); ok {
+		roles = append(roles, role)
+	}
+	if list, ok := claims["roles"].([]interface{}); ok {
+		for _, r := range list {
+			if role, ok := r.(string); ok {
+				roles = append(roles, role)
+			}
+		}
+	}
+	return roles
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gateway-service/internal/ratelimit"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+func TestIdentityKey(t *testing.T) {
+	e := echo.New()
+	e.IPExtractor = echo.ExtractIPDirect()
+
+	newContext := func(apiKey string, claims jwt.MapClaims) echo.Context {
+		req := httptest.NewRequest(http.MethodGet, "/api/products", nil)
+		req.RemoteAddr = "203.0.113.7:51234"
+		req.Header.Set("X-Forwarded-For", "198.51.100.1")
+		if apiKey != "" {
+			req.Header.Set(HeaderAPIKey, apiKey)
+		}
+		c := e.NewContext(req, httptest.NewRecorder())
+		if claims != nil {
+			c.Set("user", claims)
+		}
+		return c
+	}
+
+	if got := IdentityKey(newContext("secret-key", jwt.MapClaims{"user_id": "u1"})); got != "user:u1" {
+		t.Errorf("with claims: %q", got)
+	}
+	key := IdentityKey(newContext("secret-key", nil))
+	if !strings.HasPrefix(key, "key:") || strings.Contains(key, "secret-key") {
+		t.Errorf("with an API key: %q", key)
+	}
+	if other := IdentityKey(newContext("other-key", nil)); other == key {
+		t.Errorf("two API keys share %q", key)
+	}
+	// The forwarding header is the client's to set; the peer isn't
+	if got := IdentityKey(newContext("", nil)); got != "ip:203.0.113.7" {
+		t.Errorf("without either: %q", got)
+	}
+}
+
+func TestRateLimitByAPIKey(t *testing.T) {
+	e := echo.New()
+	limit := RateLimit(ratelimit.NewMemoryStore(time.Minute), "route:products", ratelimit.Policy{Limit: 1, Period: time.Minute}, IdentityKey)
+	e.GET("/api/products", func(c echo.Context) error { return c.NoContent(http.StatusOK) }, limit)
+
+	get := func(apiKey string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/products", nil)
+		req.Header.Set(HeaderAPIKey, apiKey)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := get("key-a"); code != http.StatusOK {
+		t.Fatalf("first call: %d", code)
+	}
+	if code := get("key-a"); code != http.StatusTooManyRequests {
+		t.Errorf("second call with the same key: %d", code)
+	}
+	// Same IP, another key: a bucket of its own
+	if code := get("key-b"); code != http.StatusOK {
+		t.Errorf("call with another key: %d", code)
+	}
+}
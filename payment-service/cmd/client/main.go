@@ -7,12 +7,25 @@ import (
 	"time"
 
 	pb "payment-service/internal/pb"
+	"payment-service/internal/tlsconfig"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 func main() {
-	conn, err := grpc.Dial("localhost:50051", grpc.WithInsecure())
+	// TLS_CA_FILE, TLS_CERT_FILE and TLS_KEY_FILE for a server with mTLS
+	tlsConfig, err := tlsconfig.FromEnv("TLS").Client()
+	if err != nil {
+		log.Fatalf("Invalid TLS config: %v", err)
+	}
+	creds := insecure.NewCredentials()
+	if tlsConfig != nil {
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	conn, err := grpc.Dial("localhost:50051", grpc.WithTransportCredentials(creds))
 	if err != nil {
 		log.Fatalf("did not connect: %v", err)
 	}
@@ -21,21 +34,25 @@ func main() {
 	client := pb.NewPaymentServiceClient(conn)
 
 	// ✅ Input manual
-	var email string
-	var amount float64
+	var email, card string
+	var amountMinor int64
 
 	fmt.Print("Enter email: ")
 	fmt.Scanln(&email)
 
-	fmt.Print("Enter amount: ")
-	fmt.Scanln(&amount)
+	fmt.Print("Enter amount in cents (1050 for 10.50): ")
+	fmt.Scanln(&amountMinor)
+
+	fmt.Print("Enter card number (4000000000000002 is declined): ")
+	fmt.Scanln(&card)
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
 
 	createReq := &pb.CreatePaymentRequest{
-		Email:  email,
-		Amount: amount,
+		Email:       email,
+		AmountMinor: amountMinor, // in the server's DEFAULT_CURRENCY
+		Card:        card,
 	}
 
 	createRes, err := client.CreatePayment(ctx, createReq)
@@ -1,14 +1,25 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
 	docs "payment-service/docs"
 	"payment-service/internal/delivery/http/handler"
+	"payment-service/internal/health"
 	"payment-service/internal/infra"
+	"payment-service/internal/metrics"
+	"payment-service/internal/money"
+	"payment-service/internal/provider"
 	"payment-service/internal/service"
+	"payment-service/internal/tlsconfig"
+	"payment-service/internal/tracing"
+	"syscall"
 	"time"
 
 	echoMiddleware "github.com/labstack/echo/v4/middleware"
@@ -19,17 +30,20 @@ import (
 	"github.com/labstack/echo/v4"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
 )
 
 func main() {
-	docs.SwaggerInfo.Host = "34.101.41.221:8084"
-	docs.SwaggerInfo.Schemes = []string{"http"}
-
 	// Load ENV
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, using environment variables")
 	}
 
+	// Empty host: Swagger UI calls whatever host served it (e.g. through the gateway)
+	docs.SwaggerInfo.Host = os.Getenv("SWAGGER_HOST")
+
 	port := os.Getenv("PORT")
 	mongoURI := os.Getenv("MONGO_URI")
 	mongoDBName := os.Getenv("MONGO_DB")
@@ -38,8 +52,16 @@ func main() {
 		log.Fatal("Missing required environment variables")
 	}
 
+	shutdownTracing, err := tracing.Init(context.Background(), "payment-service")
+	if err != nil {
+		log.Fatalf("Failed to init tracing: %v", err)
+	}
+
 	// Connect MongoDB
-	client, err := mongo.NewClient(options.Client().ApplyURI(mongoURI))
+	client, err := mongo.NewClient(options.Client().
+		ApplyURI(mongoURI).
+		SetMonitor(otelmongo.NewMonitor()).
+		SetPoolMonitor(metrics.PoolMonitor()))
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -55,18 +77,65 @@ func main() {
 
 	db := client.Database(mongoDBName)
 
+	// New payments that name no currency are in DEFAULT_CURRENCY, and so
+	// were all amounts stored before they had one
+	currency, err := money.ParseCurrency(cmp.Or(os.Getenv("DEFAULT_CURRENCY"), "IDR"))
+	if err != nil {
+		log.Fatalf("Invalid DEFAULT_CURRENCY: %v", err)
+	}
+	if err := infra.MigrateMoney(ctx, db, currency); err != nil {
+		log.Fatalf("Failed to migrate amounts: %v", err)
+	}
+
 	// Init Repository
-	paymentRepo := infra.NewMongoPaymentRepository(db)
+	paymentRepo, err := infra.NewMongoPaymentRepository(ctx, db)
+	if err != nil {
+		log.Fatalf("Failed to set up payments: %v", err)
+	}
 
 	// Init Service
-	paymentService := service.NewPaymentService(paymentRepo, 5*time.Second)
+	eventRepo, err := infra.NewMongoEventRepository(ctx, db)
+	if err != nil {
+		log.Fatalf("Failed to set up event log: %v", err)
+	}
+	eventService := service.NewEventService(eventRepo)
+	// PAYMENT_PROVIDER picks the provider of payments that name none;
+	// FAKE_PSP_CONFIG the rules of the fake one, whose payments are in Mongo
+	// for both servers to see
+	providers, err := provider.FromEnv(infra.NewMongoFakeStore(db))
+	if err != nil {
+		log.Fatalf("Invalid payment provider config: %v", err)
+	}
+	paymentService := service.NewPaymentService(paymentRepo, eventService, providers, currency, 5*time.Second)
+	refundRepo, err := infra.NewMongoRefundRepository(ctx, db)
+	if err != nil {
+		log.Fatalf("Failed to set up refunds: %v", err)
+	}
+	refundService := service.NewRefundService(paymentRepo, refundRepo, eventService, providers, 5*time.Second)
+
+	// Follow the payments providers left pending or settling
+	reconcileInterval, err := time.ParseDuration(cmp.Or(os.Getenv("RECONCILE_INTERVAL"), "30s"))
+	if err != nil {
+		log.Fatalf("Invalid RECONCILE_INTERVAL: %v", err)
+	}
+	reconcileCtx, stopReconciler := context.WithCancel(context.Background())
+	defer stopReconciler()
+	go service.RunReconciler(reconcileCtx, paymentService, reconcileInterval)
 
 	// Init Handler
 	paymentHandler := handler.NewPaymentHandler(paymentService)
+	refundHandler := handler.NewRefundHandler(refundService)
+	eventHandler := handler.NewEventHandler(eventService)
+
+	checker := health.NewChecker(2*time.Second).
+		Add("mongo", func(ctx context.Context) error { return client.Ping(ctx, readpref.Primary()) })
 
 	// Init Echo
 	e := echo.New()
 	e.Use(echoMiddleware.CORS()) // ini WAJIB untuk Swagger!
+	e.Use(otelecho.Middleware("payment-service"))
+	e.Use(tracing.RequestID())
+	e.Use(metrics.Middleware())
 
 	// Routes
 	e.GET("/payments", paymentHandler.GetAll)
@@ -74,12 +143,45 @@ func main() {
 	e.POST("/payments", paymentHandler.Create)
 	e.PUT("/payments/:id", paymentHandler.Update)
 	e.DELETE("/payments/:id", paymentHandler.Delete)
+	e.POST("/payments/:id/refunds", refundHandler.Create)
+	e.GET("/payments/:id/refunds", refundHandler.List)
 	e.GET("/payments/swagger/*", echoSwagger.WrapHandler)
+	e.GET("/events", eventHandler.Stream) // internal, followed by the gateway
+	e.GET("/metrics", metrics.Handler())
+	e.GET("/healthz", checker.Liveness)
+	e.GET("/readyz", checker.Readiness)
+
+	// TLS_CERT_FILE and TLS_KEY_FILE turn on TLS; with TLS_CA_FILE clients
+	// must present a certificate signed by that CA
+	serverTLS, err := tlsconfig.FromEnv("TLS").Server()
+	if err != nil {
+		log.Fatalf("Invalid TLS config: %v", err)
+	}
+	// Swagger UI calls back with the scheme the service is served over
+	docs.SwaggerInfo.Schemes = []string{"http"}
+	if serverTLS != nil {
+		docs.SwaggerInfo.Schemes = []string{"https"}
+	}
 
 	// Start server
 	address := fmt.Sprintf(":%s", port)
-	log.Printf("Starting Shopping Service at %s...", address)
-	if err := e.Start(address); err != nil {
-		log.Fatal(err)
+	go func() {
+		log.Printf("Starting Shopping Service at %s...", address)
+		if err := tlsconfig.StartEcho(e, address, serverTLS); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	<-quit
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer shutdownCancel()
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Shutdown: %v", err)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Printf("Tracing shutdown: %v", err)
 	}
 }
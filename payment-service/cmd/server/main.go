@@ -1,19 +1,34 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"payment-service/internal/delivery/grpcserver"
+	"payment-service/internal/health"
 	"payment-service/internal/infra"
+	"payment-service/internal/metrics"
+	"payment-service/internal/money"
+	pb "payment-service/internal/pb"
+	"payment-service/internal/provider"
 	"payment-service/internal/service"
+	"payment-service/internal/tlsconfig"
+	"payment-service/internal/tracing"
 
 	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	grpchealth "google.golang.org/grpc/health"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
 )
 
 func main() {
@@ -31,7 +46,15 @@ func main() {
 		log.Fatal("MONGO_URI & MONGO_DB must be set")
 	}
 
-	client, err := mongo.NewClient(options.Client().ApplyURI(mongoURI))
+	shutdownTracing, err := tracing.Init(context.Background(), "payment-service-grpc")
+	if err != nil {
+		log.Fatalf("Failed to init tracing: %v", err)
+	}
+
+	client, err := mongo.NewClient(options.Client().
+		ApplyURI(mongoURI).
+		SetMonitor(otelmongo.NewMonitor()).
+		SetPoolMonitor(metrics.PoolMonitor()))
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -46,8 +69,85 @@ func main() {
 
 	db := client.Database(mongoDBName)
 
-	paymentRepo := infra.NewMongoPaymentRepository(db)
-	paymentService := service.NewPaymentService(paymentRepo, 5*time.Second)
+	// New payments that name no currency are in DEFAULT_CURRENCY, and so
+	// were all amounts stored before they had one
+	currency, err := money.ParseCurrency(cmp.Or(os.Getenv("DEFAULT_CURRENCY"), "IDR"))
+	if err != nil {
+		log.Fatalf("Invalid DEFAULT_CURRENCY: %v", err)
+	}
+	if err := infra.MigrateMoney(ctx, db, currency); err != nil {
+		log.Fatalf("Failed to migrate amounts: %v", err)
+	}
+
+	paymentRepo, err := infra.NewMongoPaymentRepository(ctx, db)
+	if err != nil {
+		log.Fatalf("Failed to set up payments: %v", err)
+	}
+	eventRepo, err := infra.NewMongoEventRepository(ctx, db)
+	if err != nil {
+		log.Fatalf("Failed to set up event log: %v", err)
+	}
+	eventService := service.NewEventService(eventRepo)
+	// PAYMENT_PROVIDER picks the provider of payments that name none;
+	// FAKE_PSP_CONFIG the rules of the fake one, whose payments are in Mongo
+	// for both servers to see
+	providers, err := provider.FromEnv(infra.NewMongoFakeStore(db))
+	if err != nil {
+		log.Fatalf("Invalid payment provider config: %v", err)
+	}
+	paymentService := service.NewPaymentService(paymentRepo, eventService, providers, currency, 5*time.Second)
+	refundRepo, err := infra.NewMongoRefundRepository(ctx, db)
+	if err != nil {
+		log.Fatalf("Failed to set up refunds: %v", err)
+	}
+	refundService := service.NewRefundService(paymentRepo, refundRepo, eventService, providers, 5*time.Second)
+
+	// Follow the payments providers left pending or settling
+	reconcileInterval, err := time.ParseDuration(cmp.Or(os.Getenv("RECONCILE_INTERVAL"), "30s"))
+	if err != nil {
+		log.Fatalf("Invalid RECONCILE_INTERVAL: %v", err)
+	}
+	reconcileCtx, stopReconciler := context.WithCancel(context.Background())
+	defer stopReconciler()
+	go service.RunReconciler(reconcileCtx, paymentService, reconcileInterval)
+
+	// grpc.health.v1 reports NOT_SERVING while Mongo is unreachable
+	checker := health.NewChecker(2*time.Second).
+		Add("mongo", func(ctx context.Context) error { return client.Ping(ctx, readpref.Primary()) })
+	healthServer := grpchealth.NewServer()
+	healthCtx, stopHealth := context.WithCancel(context.Background())
+	defer stopHealth()
+	checker.ServeGRPC(healthCtx, healthServer, 10*time.Second, pb.PaymentService_ServiceDesc.ServiceName)
 
-	grpcserver.RunGRPCServer(paymentService, grpcPort)
+	// TLS_CERT_FILE and TLS_KEY_FILE turn on TLS; with TLS_CA_FILE clients
+	// must present a certificate signed by that CA
+	serverTLS, err := tlsconfig.FromEnv("TLS").Server()
+	if err != nil {
+		log.Fatalf("Invalid TLS config: %v", err)
+	}
+	go grpcserver.RunGRPCServer(paymentService, refundService, healthServer, grpcPort, serverTLS)
+
+	// gRPC has no HTTP listener of its own, so /metrics gets one
+	metricsPort := os.Getenv("METRICS_PORT")
+	if metricsPort == "" {
+		metricsPort = "9091"
+	}
+	go func() {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+		log.Printf("Metrics listening at :%s", metricsPort)
+		if err := http.ListenAndServe(":"+metricsPort, mux); err != nil {
+			log.Fatalf("metrics server: %v", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	<-quit
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Printf("Tracing shutdown: %v", err)
+	}
 }
@@ -47,7 +47,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Create a new payment",
+                "description": "Create a payment and charge the card with the payment's\nprovider. A declined payment is created failed; one the\nprovider hasn't settled yet is pending or authorized.",
                 "consumes": [
                     "application/json"
                 ],
@@ -135,7 +135,7 @@ const docTemplate = `{
                 }
             },
             "put": {
-                "description": "Update payment status by ID",
+                "description": "Move a payment to another status. Only the transitions of the\npayment state machine are allowed, e.g. pending to captured.\nPayments with a provider can only be captured or cancelled.",
                 "consumes": [
                     "application/json"
                 ],
@@ -179,6 +179,33 @@ const docTemplate = `{
                                 "type": "string"
                             }
                         }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     }
                 }
             },
@@ -224,6 +251,140 @@ const docTemplate = `{
                     }
                 }
             }
+        },
+        "/payments/{id}/refunds": {
+            "get": {
+                "description": "List the refunds of a payment, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Refunds"
+                ],
+                "summary": "List the refunds of a payment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Payment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.RefundResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Refund all or part of a captured payment. Several partial refunds\ncan be made until the whole amount is refunded; the payment moves\nto partially_refunded, then refunded.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Refunds"
+                ],
+                "summary": "Refund a payment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Payment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Create Refund Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateRefundRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateRefundResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
         }
     },
     "definitions": {
@@ -235,10 +396,69 @@ const docTemplate = `{
             ],
             "properties": {
                 "amount": {
-                    "type": "number"
+                    "description": "The currency defaults to the service's (DEFAULT_CURRENCY)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dto.Money"
+                        }
+                    ]
+                },
+                "card": {
+                    "description": "Card number, passed to the provider and never stored. With the fake\nprovider 4000000000000002 is declined and 4000000000003220 settles\nlater.",
+                    "type": "string",
+                    "example": "4242424242424242"
                 },
                 "email": {
                     "type": "string"
+                },
+                "provider": {
+                    "description": "The service's default provider (PAYMENT_PROVIDER) when empty",
+                    "type": "string",
+                    "example": "fake"
+                }
+            }
+        },
+        "dto.CreateRefundRequest": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "description": "Who is making the refund, recorded in the status history",
+                    "type": "string"
+                },
+                "amount": {
+                    "description": "Amount to refund, in the payment's currency; all that is left to\nrefund when omitted",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dto.Money"
+                        }
+                    ]
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreateRefundResponse": {
+            "type": "object",
+            "properties": {
+                "payment": {
+                    "$ref": "#/definitions/dto.PaymentResponse"
+                },
+                "refund": {
+                    "$ref": "#/definitions/dto.RefundResponse"
+                }
+            }
+        },
+        "dto.Money": {
+            "type": "object",
+            "properties": {
+                "amount_minor": {
+                    "type": "integer",
+                    "example": 1050
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "IDR"
                 }
             }
         },
@@ -246,7 +466,7 @@ const docTemplate = `{
             "type": "object",
             "properties": {
                 "amount": {
-                    "type": "number"
+                    "$ref": "#/definitions/dto.Money"
                 },
                 "created_at": {
                     "type": "string"
@@ -257,8 +477,63 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
+                "provider": {
+                    "type": "string"
+                },
+                "refunded_amount": {
+                    "$ref": "#/definitions/dto.Money"
+                },
                 "status": {
                     "type": "string"
+                },
+                "status_history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.StatusChangeResponse"
+                    }
+                }
+            }
+        },
+        "dto.RefundResponse": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "type": "string"
+                },
+                "amount": {
+                    "$ref": "#/definitions/dto.Money"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "payment_id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.StatusChangeResponse": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "type": "string"
+                },
+                "at": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
                 }
             }
         },
@@ -268,7 +543,12 @@ const docTemplate = `{
                 "status"
             ],
             "properties": {
+                "actor": {
+                    "description": "Who is making the change, recorded in the status history",
+                    "type": "string"
+                },
                 "status": {
+                    "description": "pending, authorized, captured, failed or cancelled (refunds set\nrefunded and partially_refunded)",
                     "type": "string"
                 }
             }
@@ -2,42 +2,50 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"payment-service/internal/domain"
+	"payment-service/internal/money"
 	pb "payment-service/internal/pb" // Ini path ke hasil generate!
+	"payment-service/internal/provider"
 	"payment-service/internal/service"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type PaymentGRPCServer struct {
 	pb.UnimplementedPaymentServiceServer
 	paymentService service.PaymentService
+	refundService  service.RefundService
 }
 
-func NewPaymentGRPCServer(paymentService service.PaymentService) *PaymentGRPCServer {
+func NewPaymentGRPCServer(paymentService service.PaymentService, refundService service.RefundService) *PaymentGRPCServer {
 	return &PaymentGRPCServer{
 		paymentService: paymentService,
+		refundService:  refundService,
 	}
 }
 
 func (s *PaymentGRPCServer) CreatePayment(ctx context.Context, req *pb.CreatePaymentRequest) (*pb.Payment, error) {
+	amount, err := money.Parse(req.AmountMinor, req.Currency)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
 	payment, err := s.paymentService.Create(ctx, &domain.Payment{
-		Email:  req.Email,
-		Amount: req.Amount,
-	})
+		Email:    req.Email,
+		Amount:   amount,
+		Provider: req.Provider,
+	}, req.Card)
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
 
-	return &pb.Payment{
-		Id:        payment.ID.Hex(),
-		Email:     payment.Email,
-		Amount:    payment.Amount,
-		Status:    payment.Status,
-		CreatedAt: payment.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}, nil
+	return toPB(payment), nil
 }
 
 // Implement GetAllPayments, GetPaymentByID, UpdatePayment, DeletePayment juga!
@@ -48,14 +56,8 @@ func (s *PaymentGRPCServer) GetAllPayments(ctx context.Context, req *pb.Empty) (
 	}
 
 	var pbPayments []*pb.Payment
-	for _, p := range payments {
-		pbPayments = append(pbPayments, &pb.Payment{
-			Id:        p.ID.Hex(),
-			Email:     p.Email,
-			Amount:    p.Amount,
-			Status:    p.Status,
-			CreatedAt: p.CreatedAt.Format(time.RFC3339),
-		})
+	for i := range payments {
+		pbPayments = append(pbPayments, toPB(&payments[i]))
 	}
 
 	return &pb.PaymentList{Payments: pbPayments}, nil
@@ -73,13 +75,7 @@ func (s *PaymentGRPCServer) GetPaymentByID(ctx context.Context, req *pb.GetByIDR
 		return nil, err
 	}
 
-	return &pb.Payment{
-		Id:        payment.ID.Hex(),
-		Email:     payment.Email,
-		Amount:    payment.Amount,
-		Status:    payment.Status,
-		CreatedAt: payment.CreatedAt.Format(time.RFC3339),
-	}, nil
+	return toPB(payment), nil
 }
 
 func (s *PaymentGRPCServer) UpdatePayment(ctx context.Context, req *pb.UpdatePaymentRequest) (*pb.Payment, error) {
@@ -88,22 +84,21 @@ func (s *PaymentGRPCServer) UpdatePayment(ctx context.Context, req *pb.UpdatePay
 		return nil, err
 	}
 
-	updateData := &domain.Payment{
-		Status: req.Status,
+	newStatus, ok := fromPBStatus[req.Status]
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, domain.ErrInvalidStatus.Error())
+	}
+	actor := req.Actor
+	if actor == "" {
+		actor = "grpc"
 	}
 
-	payment, err := s.paymentService.Update(ctx, objectID, updateData)
+	payment, err := s.paymentService.UpdateStatus(ctx, objectID, newStatus, actor)
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
 
-	return &pb.Payment{
-		Id:        payment.ID.Hex(),
-		Email:     payment.Email,
-		Amount:    payment.Amount,
-		Status:    payment.Status,
-		CreatedAt: payment.CreatedAt.Format(time.RFC3339),
-	}, nil
+	return toPB(payment), nil
 }
 
 func (s *PaymentGRPCServer) DeletePayment(ctx context.Context, req *pb.GetByIDRequest) (*pb.Empty, error) {
@@ -120,3 +115,117 @@ func (s *PaymentGRPCServer) DeletePayment(ctx context.Context, req *pb.GetByIDRe
 
 	return &pb.Empty{}, nil
 }
+
+func (s *PaymentGRPCServer) Refund(ctx context.Context, req *pb.RefundRequest) (*pb.RefundResult, error) {
+	objectID, err := primitive.ObjectIDFromHex(req.Id)
+	if err != nil {
+		return nil, err
+	}
+	actor := req.Actor
+	if actor == "" {
+		actor = "grpc"
+	}
+
+	amount, err := money.Parse(req.AmountMinor, req.Currency)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	refund, payment, err := s.refundService.Refund(ctx, objectID, amount, req.Reason, actor)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &pb.RefundResult{Refund: toPBRefund(refund), Payment: toPB(payment)}, nil
+}
+
+func (s *PaymentGRPCServer) ListRefunds(ctx context.Context, req *pb.GetByIDRequest) (*pb.RefundList, error) {
+	objectID, err := primitive.ObjectIDFromHex(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	refunds, err := s.refundService.List(ctx, objectID)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	var pbRefunds []*pb.Refund
+	for i := range refunds {
+		pbRefunds = append(pbRefunds, toPBRefund(&refunds[i]))
+	}
+	return &pb.RefundList{Refunds: pbRefunds}, nil
+}
+
+// toStatusError gives the errors of the payment state machine and of the
+// payment providers their gRPC codes.
+func toStatusError(err error) error {
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return status.Error(codes.NotFound, "payment not found")
+	case errors.Is(err, domain.ErrInvalidStatus), errors.Is(err, domain.ErrInvalidRefundAmount),
+		errors.Is(err, money.ErrUnknownCurrency), errors.Is(err, money.ErrCurrencyMismatch),
+		errors.Is(err, provider.ErrUnknownProvider):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrInvalidTransition), errors.Is(err, domain.ErrStatusConflict),
+		errors.Is(err, domain.ErrNotRefundable), errors.Is(err, domain.ErrRefundExceedsAmount),
+		errors.Is(err, provider.ErrRejected), errors.Is(err, provider.ErrUnknownPayment):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, provider.ErrTimeout):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	return err
+}
+
+var toPBStatus = map[domain.PaymentStatus]pb.PaymentStatus{
+	domain.StatusPending:           pb.PaymentStatus_PAYMENT_STATUS_PENDING,
+	domain.StatusAuthorized:        pb.PaymentStatus_PAYMENT_STATUS_AUTHORIZED,
+	domain.StatusCaptured:          pb.PaymentStatus_PAYMENT_STATUS_CAPTURED,
+	domain.StatusFailed:            pb.PaymentStatus_PAYMENT_STATUS_FAILED,
+	domain.StatusCancelled:         pb.PaymentStatus_PAYMENT_STATUS_CANCELLED,
+	domain.StatusRefunded:          pb.PaymentStatus_PAYMENT_STATUS_REFUNDED,
+	domain.StatusPartiallyRefunded: pb.PaymentStatus_PAYMENT_STATUS_PARTIALLY_REFUNDED,
+}
+
+var fromPBStatus = func() map[pb.PaymentStatus]domain.PaymentStatus {
+	m := make(map[pb.PaymentStatus]domain.PaymentStatus, len(toPBStatus))
+	for d, p := range toPBStatus {
+		m[p] = d
+	}
+	return m
+}()
+
+func toPB(p *domain.Payment) *pb.Payment {
+	out := &pb.Payment{
+		Id:            p.ID.Hex(),
+		Email:         p.Email,
+		AmountMinor:   p.Amount.AmountMinor,
+		Currency:      string(p.Amount.Currency),
+		Status:        toPBStatus[p.Status],
+		RefundedMinor: p.Refunded().AmountMinor,
+		Provider:      p.Provider,
+		CreatedAt:     p.CreatedAt.Format(time.RFC3339),
+	}
+	for _, change := range p.StatusHistory {
+		out.StatusHistory = append(out.StatusHistory, &pb.StatusChange{
+			From:   toPBStatus[change.From],
+			To:     toPBStatus[change.To],
+			At:     change.At.Format(time.RFC3339),
+			Actor:  change.Actor,
+			Reason: change.Reason,
+		})
+	}
+	return out
+}
+
+func toPBRefund(r *domain.Refund) *pb.Refund {
+	return &pb.Refund{
+		Id:          r.ID.Hex(),
+		PaymentId:   r.PaymentID.Hex(),
+		AmountMinor: r.Amount.AmountMinor,
+		Currency:    string(r.Amount.Currency),
+		Reason:      r.Reason,
+		Actor:       r.Actor,
+		CreatedAt:   r.CreatedAt.Format(time.RFC3339),
+	}
+}
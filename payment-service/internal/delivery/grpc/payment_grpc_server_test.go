@@ -2,16 +2,21 @@ package grpc_test
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
 	"payment-service/internal/delivery/grpc"
 	"payment-service/internal/domain"
+	"payment-service/internal/money"
 	pb "payment-service/internal/pb"
+	"payment-service/internal/provider"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // ===== Mock PaymentService =====
@@ -29,14 +34,15 @@ func (m *MockPaymentService) GetByID(ctx context.Context, id primitive.ObjectID)
 	return args.Get(0).(*domain.Payment), args.Error(1)
 }
 
-func (m *MockPaymentService) Create(ctx context.Context, payment *domain.Payment) (*domain.Payment, error) {
-	args := m.Called(ctx, payment)
+func (m *MockPaymentService) Create(ctx context.Context, payment *domain.Payment, card string) (*domain.Payment, error) {
+	args := m.Called(ctx, payment, card)
 	return args.Get(0).(*domain.Payment), args.Error(1)
 }
 
-func (m *MockPaymentService) Update(ctx context.Context, id primitive.ObjectID, payment *domain.Payment) (*domain.Payment, error) {
-	args := m.Called(ctx, id, payment)
-	return args.Get(0).(*domain.Payment), args.Error(1)
+func (m *MockPaymentService) UpdateStatus(ctx context.Context, id primitive.ObjectID, status domain.PaymentStatus, actor string) (*domain.Payment, error) {
+	args := m.Called(ctx, id, status, actor)
+	payment, _ := args.Get(0).(*domain.Payment)
+	return payment, args.Error(1)
 }
 
 func (m *MockPaymentService) Delete(ctx context.Context, id primitive.ObjectID) error {
@@ -44,41 +50,86 @@ func (m *MockPaymentService) Delete(ctx context.Context, id primitive.ObjectID)
 	return args.Error(0)
 }
 
+func (m *MockPaymentService) Reconcile(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
 // ===== Test CreatePayment =====
 func TestCreatePayment(t *testing.T) {
 	mockService := new(MockPaymentService)
-	server := grpc.NewPaymentGRPCServer(mockService)
+	server := grpc.NewPaymentGRPCServer(mockService, nil)
 
 	ctx := context.Background()
 
 	req := &pb.CreatePaymentRequest{
-		Email:  "test@example.com",
-		Amount: 500,
+		Email:       "test@example.com",
+		AmountMinor: 50000,
+		Currency:    "idr",
+		Card:        "4242424242424242",
+		Provider:    "fake",
 	}
 
 	fakePayment := &domain.Payment{
 		ID:        primitive.NewObjectID(),
 		Email:     "test@example.com",
-		Amount:    500,
-		Status:    "success",
+		Amount:    money.New(50000, "IDR"),
+		Status:    domain.StatusCaptured,
+		Provider:  "fake",
 		CreatedAt: time.Now(),
 	}
 
-	mockService.On("Create", ctx, mock.AnythingOfType("*domain.Payment")).Return(fakePayment, nil)
+	mockService.On("Create", ctx, mock.MatchedBy(func(p *domain.Payment) bool {
+		return p.Amount == money.New(50000, "IDR") && p.Provider == "fake"
+	}), "4242424242424242").Return(fakePayment, nil)
 
 	res, err := server.CreatePayment(ctx, req)
 
 	assert.NoError(t, err)
 	assert.Equal(t, req.Email, res.Email)
-	assert.Equal(t, req.Amount, res.Amount)
-	assert.Equal(t, fakePayment.Status, res.Status)
+	assert.Equal(t, req.AmountMinor, res.AmountMinor)
+	assert.Equal(t, "IDR", res.Currency)
+	assert.Equal(t, pb.PaymentStatus_PAYMENT_STATUS_CAPTURED, res.Status)
+	assert.Equal(t, "fake", res.Provider)
 	mockService.AssertExpectations(t)
 }
 
+func TestCreatePaymentDeclined(t *testing.T) {
+	mockService := new(MockPaymentService)
+	server := grpc.NewPaymentGRPCServer(mockService, nil)
+	ctx := context.Background()
+
+	now := time.Now()
+	declined := &domain.Payment{
+		ID:       primitive.NewObjectID(),
+		Email:    "test@example.com",
+		Amount:   money.New(50000, "IDR"),
+		Status:   domain.StatusFailed,
+		Provider: "fake",
+		StatusHistory: []domain.StatusChange{
+			{To: domain.StatusPending, At: now, Actor: domain.ActorSystem},
+			{From: domain.StatusPending, To: domain.StatusFailed, At: now, Actor: "fake", Reason: "card_declined"},
+		},
+		CreatedAt: now,
+	}
+	mockService.On("Create", ctx, mock.AnythingOfType("*domain.Payment"), "4000000000000002").Return(declined, nil)
+
+	res, err := server.CreatePayment(ctx, &pb.CreatePaymentRequest{Email: "test@example.com", AmountMinor: 50000, Card: "4000000000000002"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, pb.PaymentStatus_PAYMENT_STATUS_FAILED, res.Status)
+	assert.Equal(t, "card_declined", res.StatusHistory[1].Reason)
+
+	mockService.On("Create", ctx, mock.AnythingOfType("*domain.Payment"), "").
+		Return((*domain.Payment)(nil), fmt.Errorf("%w \"acme\"", provider.ErrUnknownProvider))
+	_, err = server.CreatePayment(ctx, &pb.CreatePaymentRequest{Email: "test@example.com", AmountMinor: 50000, Provider: "acme"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
 // ===== Test GetAllPayments =====
 func TestGetAllPayments(t *testing.T) {
 	mockService := new(MockPaymentService)
-	server := grpc.NewPaymentGRPCServer(mockService)
+	server := grpc.NewPaymentGRPCServer(mockService, nil)
 
 	ctx := context.Background()
 
@@ -86,15 +137,15 @@ func TestGetAllPayments(t *testing.T) {
 		{
 			ID:        primitive.NewObjectID(),
 			Email:     "one@example.com",
-			Amount:    100,
-			Status:    "success",
+			Amount:    money.New(10000, "IDR"),
+			Status:    domain.StatusCaptured,
 			CreatedAt: time.Now(),
 		},
 		{
 			ID:        primitive.NewObjectID(),
 			Email:     "two@example.com",
-			Amount:    200,
-			Status:    "pending",
+			Amount:    money.New(20000, "IDR"),
+			Status:    domain.StatusPending,
 			CreatedAt: time.Now(),
 		},
 	}
@@ -113,7 +164,7 @@ func TestGetAllPayments(t *testing.T) {
 // ===== Test GetPaymentByID =====
 func TestGetPaymentByID(t *testing.T) {
 	mockService := new(MockPaymentService)
-	server := grpc.NewPaymentGRPCServer(mockService)
+	server := grpc.NewPaymentGRPCServer(mockService, nil)
 
 	ctx := context.Background()
 	objectID := primitive.NewObjectID()
@@ -121,8 +172,8 @@ func TestGetPaymentByID(t *testing.T) {
 	fakePayment := &domain.Payment{
 		ID:        objectID,
 		Email:     "getbyid@example.com",
-		Amount:    300,
-		Status:    "success",
+		Amount:    money.New(30000, "IDR"),
+		Status:    domain.StatusCaptured,
 		CreatedAt: time.Now(),
 	}
 
@@ -133,45 +184,72 @@ func TestGetPaymentByID(t *testing.T) {
 
 	assert.NoError(t, err)
 	assert.Equal(t, fakePayment.Email, res.Email)
-	assert.Equal(t, fakePayment.Amount, res.Amount)
+	assert.Equal(t, fakePayment.Amount.AmountMinor, res.AmountMinor)
 	mockService.AssertExpectations(t)
 }
 
 // ===== Test UpdatePayment =====
 func TestUpdatePayment(t *testing.T) {
 	mockService := new(MockPaymentService)
-	server := grpc.NewPaymentGRPCServer(mockService)
+	server := grpc.NewPaymentGRPCServer(mockService, nil)
 
 	ctx := context.Background()
 	objectID := primitive.NewObjectID()
 
-	updateData := &domain.Payment{Status: "updated"}
 	fakePayment := &domain.Payment{
 		ID:        objectID,
 		Email:     "update@example.com",
-		Amount:    400,
-		Status:    "updated",
+		Amount:    money.New(40000, "IDR"),
+		Status:    domain.StatusCaptured,
 		CreatedAt: time.Now(),
+		StatusHistory: []domain.StatusChange{
+			{To: domain.StatusPending, At: time.Now(), Actor: domain.ActorSystem},
+			{From: domain.StatusPending, To: domain.StatusCaptured, At: time.Now(), Actor: "grpc"},
+		},
 	}
 
-	mockService.On("Update", ctx, objectID, updateData).Return(fakePayment, nil)
+	mockService.On("UpdateStatus", ctx, objectID, domain.StatusCaptured, "grpc").Return(fakePayment, nil)
 
 	req := &pb.UpdatePaymentRequest{
 		Id:     objectID.Hex(),
-		Status: "updated",
+		Status: pb.PaymentStatus_PAYMENT_STATUS_CAPTURED,
 	}
 
 	res, err := server.UpdatePayment(ctx, req)
 
 	assert.NoError(t, err)
-	assert.Equal(t, "updated", res.Status)
+	assert.Equal(t, pb.PaymentStatus_PAYMENT_STATUS_CAPTURED, res.Status)
+	assert.Len(t, res.StatusHistory, 2)
+	assert.Equal(t, pb.PaymentStatus_PAYMENT_STATUS_PENDING, res.StatusHistory[1].From)
+	mockService.AssertExpectations(t)
+}
+
+func TestUpdatePaymentRejectedTransition(t *testing.T) {
+	mockService := new(MockPaymentService)
+	server := grpc.NewPaymentGRPCServer(mockService, nil)
+
+	ctx := context.Background()
+	objectID := primitive.NewObjectID()
+
+	mockService.On("UpdateStatus", ctx, objectID, domain.StatusPending, "admin").
+		Return(nil, fmt.Errorf("%w: captured to pending", domain.ErrInvalidTransition))
+
+	_, err := server.UpdatePayment(ctx, &pb.UpdatePaymentRequest{
+		Id:     objectID.Hex(),
+		Status: pb.PaymentStatus_PAYMENT_STATUS_PENDING,
+		Actor:  "admin",
+	})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = server.UpdatePayment(ctx, &pb.UpdatePaymentRequest{Id: objectID.Hex()})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
 	mockService.AssertExpectations(t)
 }
 
 // ===== Test DeletePayment =====
 func TestDeletePayment(t *testing.T) {
 	mockService := new(MockPaymentService)
-	server := grpc.NewPaymentGRPCServer(mockService)
+	server := grpc.NewPaymentGRPCServer(mockService, nil)
 
 	ctx := context.Background()
 	objectID := primitive.NewObjectID()
@@ -184,3 +262,60 @@ func TestDeletePayment(t *testing.T) {
 	assert.NoError(t, err)
 	mockService.AssertExpectations(t)
 }
+
+// ===== Mock RefundService =====
+type MockRefundService struct {
+	mock.Mock
+}
+
+func (m *MockRefundService) Refund(ctx context.Context, paymentID primitive.ObjectID, amount money.Money, reason, actor string) (*domain.Refund, *domain.Payment, error) {
+	args := m.Called(ctx, paymentID, amount, reason, actor)
+	refund, _ := args.Get(0).(*domain.Refund)
+	payment, _ := args.Get(1).(*domain.Payment)
+	return refund, payment, args.Error(2)
+}
+
+func (m *MockRefundService) List(ctx context.Context, paymentID primitive.ObjectID) ([]domain.Refund, error) {
+	args := m.Called(ctx, paymentID)
+	return args.Get(0).([]domain.Refund), args.Error(1)
+}
+
+// ===== Test Refund =====
+func TestRefund(t *testing.T) {
+	refundService := new(MockRefundService)
+	server := grpc.NewPaymentGRPCServer(new(MockPaymentService), refundService)
+
+	ctx := context.Background()
+	paymentID := primitive.NewObjectID()
+
+	refund := &domain.Refund{ID: primitive.NewObjectID(), PaymentID: paymentID, Amount: money.New(3000, "IDR"), Reason: "damaged", Actor: "grpc", CreatedAt: time.Now()}
+	payment := &domain.Payment{ID: paymentID, Amount: money.New(10000, "IDR"), RefundedAmount: money.New(3000, "IDR"), Status: domain.StatusPartiallyRefunded}
+	refundService.On("Refund", ctx, paymentID, money.New(3000, ""), "damaged", "grpc").Return(refund, payment, nil)
+
+	res, err := server.Refund(ctx, &pb.RefundRequest{Id: paymentID.Hex(), AmountMinor: 3000, Reason: "damaged"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, paymentID.Hex(), res.Refund.PaymentId)
+	assert.Equal(t, int64(3000), res.Payment.RefundedMinor)
+	assert.Equal(t, "IDR", res.Refund.Currency)
+	assert.Equal(t, pb.PaymentStatus_PAYMENT_STATUS_PARTIALLY_REFUNDED, res.Payment.Status)
+	refundService.AssertExpectations(t)
+}
+
+func TestRefundTooMuch(t *testing.T) {
+	refundService := new(MockRefundService)
+	server := grpc.NewPaymentGRPCServer(new(MockPaymentService), refundService)
+
+	ctx := context.Background()
+	paymentID := primitive.NewObjectID()
+
+	refundService.On("Refund", ctx, paymentID, money.New(50000, "IDR"), "", "grpc").
+		Return(nil, nil, fmt.Errorf("%w: 70.00 IDR left", domain.ErrRefundExceedsAmount))
+
+	_, err := server.Refund(ctx, &pb.RefundRequest{Id: paymentID.Hex(), AmountMinor: 50000, Currency: "IDR"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = server.Refund(ctx, &pb.RefundRequest{Id: paymentID.Hex(), AmountMinor: 50000, Currency: "Rupiah"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	refundService.AssertExpectations(t)
+}
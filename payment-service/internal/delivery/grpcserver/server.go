@@ -1,25 +1,43 @@
 package grpcserver
 
 import (
+	"crypto/tls"
 	"fmt"
 	"log"
 	"net"
 
 	grpcDelivery "payment-service/internal/delivery/grpc"
+	"payment-service/internal/metrics"
 	pb "payment-service/internal/pb"
 	"payment-service/internal/service"
 
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
-func RunGRPCServer(paymentService service.PaymentService, port string) {
+// RunGRPCServer serves PaymentService, refunds included, and grpc.health.v1
+// (status kept up to date by the caller through healthServer) on port, over
+// TLS when tlsConfig isn't nil.
+func RunGRPCServer(paymentService service.PaymentService, refundService service.RefundService, healthServer *grpchealth.Server, port string, tlsConfig *tls.Config) {
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", port))
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
 
-	grpcServer := grpc.NewServer()
-	pb.RegisterPaymentServiceServer(grpcServer, grpcDelivery.NewPaymentGRPCServer(paymentService))
+	opts := []grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(metrics.GRPCServer.UnaryServerInterceptor()),
+	}
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	grpcServer := grpc.NewServer(opts...)
+	pb.RegisterPaymentServiceServer(grpcServer, grpcDelivery.NewPaymentGRPCServer(paymentService, refundService))
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	metrics.GRPCServer.InitializeMetrics(grpcServer)
 
 	log.Printf("gRPC server listening at %s", lis.Addr())
 	if err := grpcServer.Serve(lis); err != nil {
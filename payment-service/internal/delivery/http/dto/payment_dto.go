@@ -1,18 +1,47 @@
 package dto
 
+// Money is an amount in whole minor units (cents) of currency, an ISO 4217
+// code: {"amount_minor": 1050, "currency": "USD"} is $10.50.
+type Money struct {
+	AmountMinor int64  `json:"amount_minor" example:"1050"`
+	Currency    string `json:"currency" example:"IDR"`
+}
+
 type CreatePaymentRequest struct {
-	Email  string  `json:"email" binding:"required,email"`
-	Amount float64 `json:"amount" binding:"required"`
+	Email string `json:"email" binding:"required,email"`
+	// The currency defaults to the service's (DEFAULT_CURRENCY)
+	Amount Money `json:"amount" binding:"required"`
+	// Card number, passed to the provider and never stored. With the fake
+	// provider 4000000000000002 is declined and 4000000000003220 settles
+	// later.
+	Card string `json:"card" example:"4242424242424242"`
+	// The service's default provider (PAYMENT_PROVIDER) when empty
+	Provider string `json:"provider" example:"fake"`
 }
 
 type UpdatePaymentRequest struct {
+	// pending, authorized, captured, failed or cancelled (refunds set
+	// refunded and partially_refunded)
 	Status string `json:"status" binding:"required"`
+	// Who is making the change, recorded in the status history
+	Actor string `json:"actor"`
 }
 
 type PaymentResponse struct {
-	ID        string  `json:"id"`
-	Email     string  `json:"email"`
-	Amount    float64 `json:"amount"`
-	Status    string  `json:"status"`
-	CreatedAt string  `json:"created_at"`
+	ID             string                 `json:"id"`
+	Email          string                 `json:"email"`
+	Amount         Money                  `json:"amount"`
+	RefundedAmount Money                  `json:"refunded_amount"`
+	Status         string                 `json:"status"`
+	StatusHistory  []StatusChangeResponse `json:"status_history"`
+	Provider       string                 `json:"provider,omitempty"`
+	CreatedAt      string                 `json:"created_at"`
+}
+
+type StatusChangeResponse struct {
+	From   string `json:"from,omitempty"`
+	To     string `json:"to"`
+	At     string `json:"at"`
+	Actor  string `json:"actor"`
+	Reason string `json:"reason,omitempty"`
 }
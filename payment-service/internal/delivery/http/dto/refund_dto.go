@@ -0,0 +1,24 @@
+package dto
+
+type CreateRefundRequest struct {
+	// Amount to refund, in the payment's currency; all that is left to
+	// refund when omitted
+	Amount Money  `json:"amount"`
+	Reason string `json:"reason"`
+	// Who is making the refund, recorded in the status history
+	Actor string `json:"actor"`
+}
+
+type RefundResponse struct {
+	ID        string `json:"id"`
+	PaymentID string `json:"payment_id"`
+	Amount    Money  `json:"amount"`
+	Reason    string `json:"reason,omitempty"`
+	Actor     string `json:"actor"`
+	CreatedAt string `json:"created_at"`
+}
+
+type CreateRefundResponse struct {
+	Refund  RefundResponse  `json:"refund"`
+	Payment PaymentResponse `json:"payment"`
+}
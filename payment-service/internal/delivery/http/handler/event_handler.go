@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"payment-service/internal/service"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	eventPollInterval = time.Second
+	eventHeartbeat    = 15 * time.Second
+	eventBatch        = 100
+)
+
+// EventHandler streams payment status changes as Server-Sent Events. It is
+// internal: the gateway follows it for each user behind GET /api/events.
+type EventHandler struct {
+	events service.EventService
+}
+
+func NewEventHandler(events service.EventService) *EventHandler {
+	return &EventHandler{events: events}
+}
+
+// Stream serves GET /events?email=. With Last-Event-ID it resumes after that
+// event, otherwise it starts from now.
+func (h *EventHandler) Stream(c echo.Context) error {
+	email := c.QueryParam("email")
+	if email == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "email is required")
+	}
+
+	cursor := h.events.Start()
+	if lastID := c.Request().Header.Get("Last-Event-ID"); lastID != "" {
+		id, err := primitive.ObjectIDFromHex(lastID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid Last-Event-ID")
+		}
+		cursor = id
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.WriteHeader(http.StatusOK)
+	// An ID-only message: tells the reader where this stream starts
+	fmt.Fprintf(res, "id: %s\n\n", cursor.Hex())
+	res.Flush()
+
+	ctx := c.Request().Context()
+	poll := time.NewTicker(eventPollInterval)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(eventHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-heartbeat.C:
+			fmt.Fprint(res, ": heartbeat\n\n")
+			res.Flush()
+		case <-poll.C:
+			events, err := h.events.After(ctx, email, cursor, eventBatch)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				log.Printf("[EVENTS] %v", err)
+				continue
+			}
+			for _, event := range events {
+				data, _ := json.Marshal(event)
+				fmt.Fprintf(res, "id: %s\nevent: %s\ndata: %s\n\n", event.ID.Hex(), event.Type, data)
+				cursor = event.ID
+			}
+			if len(events) > 0 {
+				res.Flush()
+			}
+		}
+	}
+}
@@ -1,15 +1,19 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
 	"payment-service/internal/delivery/http/dto"
 	"payment-service/internal/domain"
+	"payment-service/internal/money"
+	"payment-service/internal/provider"
 	"payment-service/internal/service"
 
 	"github.com/labstack/echo/v4"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type PaymentHandler struct {
@@ -24,7 +28,9 @@ func NewPaymentHandler(paymentService service.PaymentService) *PaymentHandler {
 
 // Create godoc
 // @Summary Create a new payment
-// @Description Create a new payment
+// @Description Create a payment and charge the card with the payment's
+// @Description provider. A declined payment is created failed; one the
+// @Description provider hasn't settled yet is pending or authorized.
 // @Tags Payments
 // @Accept json
 // @Produce json
@@ -38,26 +44,23 @@ func (h *PaymentHandler) Create(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
 	}
 
+	amount, err := money.Parse(req.Amount.AmountMinor, req.Amount.Currency)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
 	payment := &domain.Payment{
-		Email:  req.Email,
-		Amount: req.Amount,
-		Status: "success", // default status
+		Email:    req.Email,
+		Amount:   amount,
+		Provider: req.Provider,
 	}
 
-	created, err := h.paymentService.Create(c.Request().Context(), payment)
+	created, err := h.paymentService.Create(c.Request().Context(), payment, req.Card)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	res := dto.PaymentResponse{
-		ID:        created.ID.Hex(),
-		Email:     created.Email,
-		Amount:    created.Amount,
-		Status:    created.Status,
-		CreatedAt: created.CreatedAt.Format(time.RFC3339),
-	}
-
-	return c.JSON(http.StatusCreated, res)
+	return c.JSON(http.StatusCreated, toPaymentResponse(created))
 }
 
 // GetAll godoc
@@ -75,14 +78,8 @@ func (h *PaymentHandler) GetAll(c echo.Context) error {
 	}
 
 	var response []dto.PaymentResponse
-	for _, p := range payments {
-		response = append(response, dto.PaymentResponse{
-			ID:        p.ID.Hex(),
-			Email:     p.Email,
-			Amount:    p.Amount,
-			Status:    p.Status,
-			CreatedAt: p.CreatedAt.Format(time.RFC3339),
-		})
+	for i := range payments {
+		response = append(response, toPaymentResponse(&payments[i]))
 	}
 
 	return c.JSON(http.StatusOK, response)
@@ -110,20 +107,14 @@ func (h *PaymentHandler) GetByID(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusNotFound, "Payment not found")
 	}
 
-	res := dto.PaymentResponse{
-		ID:        payment.ID.Hex(),
-		Email:     payment.Email,
-		Amount:    payment.Amount,
-		Status:    payment.Status,
-		CreatedAt: payment.CreatedAt.Format(time.RFC3339),
-	}
-
-	return c.JSON(http.StatusOK, res)
+	return c.JSON(http.StatusOK, toPaymentResponse(payment))
 }
 
 // Update godoc
 // @Summary Update payment by ID
-// @Description Update payment status by ID
+// @Description Move a payment to another status. Only the transitions of the
+// @Description payment state machine are allowed, e.g. pending to captured.
+// @Description Payments with a provider can only be captured or cancelled.
 // @Tags Payments
 // @Accept json
 // @Produce json
@@ -131,6 +122,9 @@ func (h *PaymentHandler) GetByID(c echo.Context) error {
 // @Param request body dto.UpdatePaymentRequest true "Update Payment Request"
 // @Success 200 {object} dto.PaymentResponse
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 504 {object} map[string]string
 // @Router /payments/{id} [put]
 func (h *PaymentHandler) Update(c echo.Context) error {
 	idParam := c.Param("id")
@@ -144,24 +138,29 @@ func (h *PaymentHandler) Update(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
 	}
 
-	payment := &domain.Payment{
-		Status: req.Status,
-	}
-
-	updated, err := h.paymentService.Update(c.Request().Context(), objectID, payment)
+	status, err := domain.ParsePaymentStatus(req.Status)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
+	actor := req.Actor
+	if actor == "" {
+		actor = "http"
+	}
 
-	res := dto.PaymentResponse{
-		ID:        updated.ID.Hex(),
-		Email:     updated.Email,
-		Amount:    updated.Amount,
-		Status:    updated.Status,
-		CreatedAt: updated.CreatedAt.Format(time.RFC3339),
+	updated, err := h.paymentService.UpdateStatus(c.Request().Context(), objectID, status, actor)
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return echo.NewHTTPError(http.StatusNotFound, "Payment not found")
+	case errors.Is(err, domain.ErrInvalidTransition), errors.Is(err, domain.ErrStatusConflict),
+		errors.Is(err, provider.ErrRejected), errors.Is(err, provider.ErrUnknownPayment):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case errors.Is(err, provider.ErrTimeout):
+		return echo.NewHTTPError(http.StatusGatewayTimeout, err.Error())
+	case err != nil:
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
-	return c.JSON(http.StatusOK, res)
+	return c.JSON(http.StatusOK, toPaymentResponse(updated))
 }
 
 // Delete godoc
@@ -188,3 +187,30 @@ func (h *PaymentHandler) Delete(c echo.Context) error {
 
 	return c.NoContent(http.StatusNoContent)
 }
+
+func toPaymentResponse(p *domain.Payment) dto.PaymentResponse {
+	res := dto.PaymentResponse{
+		ID:             p.ID.Hex(),
+		Email:          p.Email,
+		Amount:         toMoneyResponse(p.Amount),
+		RefundedAmount: toMoneyResponse(p.Refunded()),
+		Status:         string(p.Status),
+		StatusHistory:  []dto.StatusChangeResponse{},
+		Provider:       p.Provider,
+		CreatedAt:      p.CreatedAt.Format(time.RFC3339),
+	}
+	for _, change := range p.StatusHistory {
+		res.StatusHistory = append(res.StatusHistory, dto.StatusChangeResponse{
+			From:   string(change.From),
+			To:     string(change.To),
+			At:     change.At.Format(time.RFC3339),
+			Actor:  change.Actor,
+			Reason: change.Reason,
+		})
+	}
+	return res
+}
+
+func toMoneyResponse(m money.Money) dto.Money {
+	return dto.Money{AmountMinor: m.AmountMinor, Currency: string(m.Currency)}
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"payment-service/internal/delivery/http/dto"
+	"payment-service/internal/domain"
+	"payment-service/internal/money"
+	"payment-service/internal/provider"
+	"payment-service/internal/service"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type RefundHandler struct {
+	refundService service.RefundService
+}
+
+func NewRefundHandler(refundService service.RefundService) *RefundHandler {
+	return &RefundHandler{
+		refundService: refundService,
+	}
+}
+
+// Create godoc
+// @Summary Refund a payment
+// @Description Refund all or part of a captured payment. Several partial refunds
+// @Description can be made until the whole amount is refunded; the payment moves
+// @Description to partially_refunded, then refunded.
+// @Tags Refunds
+// @Accept json
+// @Produce json
+// @Param id path string true "Payment ID"
+// @Param request body dto.CreateRefundRequest true "Create Refund Request"
+// @Success 201 {object} dto.CreateRefundResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 504 {object} map[string]string
+// @Router /payments/{id}/refunds [post]
+func (h *RefundHandler) Create(c echo.Context) error {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+
+	var req dto.CreateRefundRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	amount, err := money.Parse(req.Amount.AmountMinor, req.Amount.Currency)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	actor := req.Actor
+	if actor == "" {
+		actor = "http"
+	}
+
+	refund, payment, err := h.refundService.Refund(c.Request().Context(), objectID, amount, req.Reason, actor)
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return echo.NewHTTPError(http.StatusNotFound, "Payment not found")
+	case errors.Is(err, domain.ErrInvalidRefundAmount), errors.Is(err, money.ErrCurrencyMismatch):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, domain.ErrNotRefundable), errors.Is(err, domain.ErrStatusConflict),
+		errors.Is(err, provider.ErrRejected), errors.Is(err, provider.ErrUnknownPayment):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case errors.Is(err, provider.ErrTimeout):
+		return echo.NewHTTPError(http.StatusGatewayTimeout, err.Error())
+	case errors.Is(err, domain.ErrRefundExceedsAmount):
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	case err != nil:
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusCreated, dto.CreateRefundResponse{
+		Refund:  toRefundResponse(refund),
+		Payment: toPaymentResponse(payment),
+	})
+}
+
+// List godoc
+// @Summary List the refunds of a payment
+// @Description List the refunds of a payment, oldest first
+// @Tags Refunds
+// @Produce json
+// @Param id path string true "Payment ID"
+// @Success 200 {array} dto.RefundResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /payments/{id}/refunds [get]
+func (h *RefundHandler) List(c echo.Context) error {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+
+	refunds, err := h.refundService.List(c.Request().Context(), objectID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return echo.NewHTTPError(http.StatusNotFound, "Payment not found")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	response := []dto.RefundResponse{}
+	for i := range refunds {
+		response = append(response, toRefundResponse(&refunds[i]))
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func toRefundResponse(r *domain.Refund) dto.RefundResponse {
+	return dto.RefundResponse{
+		ID:        r.ID.Hex(),
+		PaymentID: r.PaymentID.Hex(),
+		Amount:    toMoneyResponse(r.Amount),
+		Reason:    r.Reason,
+		Actor:     r.Actor,
+		CreatedAt: r.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -1,16 +1,101 @@
 package domain
 
 import (
+	"errors"
 	"time"
 
+	"payment-service/internal/money"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // Payment represents a payment in the shopping service
 type Payment struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Email     string             `bson:"email" json:"email"`
-	Amount    float64            `bson:"amount" json:"amount"`
-	Status    string             `bson:"status" json:"status"` // success/failed/pending
-	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Email          string             `bson:"email" json:"email"`
+	Amount         money.Money        `bson:"amount" json:"amount"`
+	RefundedAmount money.Money        `bson:"refunded_amount" json:"refunded_amount"`
+	RefundReserved money.Money        `bson:"refund_reserved,omitempty" json:"refund_reserved"` // held by refunds still with the provider
+	Status         PaymentStatus      `bson:"status" json:"status"`
+	StatusHistory  []StatusChange     `bson:"status_history" json:"status_history"`
+	Provider       string             `bson:"provider,omitempty" json:"provider,omitempty"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// PaymentStatus is where a payment is in its life cycle. Statuses change
+// only along paymentTransitions.
+type PaymentStatus string
+
+const (
+	StatusPending           PaymentStatus = "pending"
+	StatusAuthorized        PaymentStatus = "authorized"
+	StatusCaptured          PaymentStatus = "captured"
+	StatusFailed            PaymentStatus = "failed"
+	StatusCancelled         PaymentStatus = "cancelled"
+	StatusRefunded          PaymentStatus = "refunded"
+	StatusPartiallyRefunded PaymentStatus = "partially_refunded"
+)
+
+// LegacyStatusSuccess is what payments were created with before statuses
+// were typed; it means captured.
+const LegacyStatusSuccess PaymentStatus = "success"
+
+// ActorSystem is the actor of the transitions the service makes itself.
+const ActorSystem = "system"
+
+var (
+	ErrInvalidStatus     = errors.New("invalid payment status")
+	ErrInvalidTransition = errors.New("payment status transition not allowed")
+	// ErrStatusConflict means the payment changed status while a transition
+	// from its previous status was being made.
+	ErrStatusConflict = errors.New("payment status changed concurrently")
+)
+
+// paymentTransitions lists, for each status, the statuses a payment in it
+// may move to. failed, cancelled and refunded are final.
+var paymentTransitions = map[PaymentStatus][]PaymentStatus{
+	StatusPending:           {StatusAuthorized, StatusCaptured, StatusFailed, StatusCancelled},
+	StatusAuthorized:        {StatusCaptured, StatusFailed, StatusCancelled},
+	StatusCaptured:          {StatusPartiallyRefunded, StatusRefunded},
+	StatusPartiallyRefunded: {StatusRefunded},
+}
+
+// ParsePaymentStatus returns the status named s.
+func ParsePaymentStatus(s string) (PaymentStatus, error) {
+	status := PaymentStatus(s)
+	if !status.Valid() {
+		return "", ErrInvalidStatus
+	}
+	return status, nil
+}
+
+// Valid reports whether s is one of the known statuses.
+func (s PaymentStatus) Valid() bool {
+	switch s {
+	case StatusPending, StatusAuthorized, StatusCaptured, StatusFailed,
+		StatusCancelled, StatusRefunded, StatusPartiallyRefunded:
+		return true
+	}
+	return false
+}
+
+// CanTransitionTo reports whether a payment in s may move to next.
+func (s PaymentStatus) CanTransitionTo(next PaymentStatus) bool {
+	for _, allowed := range paymentTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusChange is one entry of a payment's status history. From is empty
+// for the status the payment was created with. Reason is the provider's
+// code for a failure.
+type StatusChange struct {
+	From   PaymentStatus `bson:"from,omitempty" json:"from,omitempty"`
+	To     PaymentStatus `bson:"to" json:"to"`
+	At     time.Time     `bson:"at" json:"at"`
+	Actor  string        `bson:"actor" json:"actor"`
+	Reason string        `bson:"reason,omitempty" json:"reason,omitempty"`
 }
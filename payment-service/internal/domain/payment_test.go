@@ -0,0 +1,48 @@
+package domain
+
+import "testing"
+
+func TestPaymentTransitions(t *testing.T) {
+	tests := []struct {
+		from, to PaymentStatus
+		allowed  bool
+	}{
+		{StatusPending, StatusAuthorized, true},
+		{StatusPending, StatusCaptured, true},
+		{StatusAuthorized, StatusCaptured, true},
+		{StatusAuthorized, StatusCancelled, true},
+		{StatusCaptured, StatusPartiallyRefunded, true},
+		{StatusPartiallyRefunded, StatusRefunded, true},
+		{StatusCaptured, StatusPending, false},
+		{StatusCaptured, StatusCancelled, false},
+		{StatusPending, StatusRefunded, false},
+		{StatusFailed, StatusCaptured, false},
+		{StatusRefunded, StatusPartiallyRefunded, false},
+	}
+	for _, tt := range tests {
+		if got := tt.from.CanTransitionTo(tt.to); got != tt.allowed {
+			t.Errorf("%s to %s: allowed = %v, want %v", tt.from, tt.to, got, tt.allowed)
+		}
+	}
+
+	// the table only leads to known statuses
+	for _, s := range []PaymentStatus{StatusPending, StatusAuthorized, StatusCaptured, StatusFailed,
+		StatusCancelled, StatusRefunded, StatusPartiallyRefunded} {
+		for _, next := range paymentTransitions[s] {
+			if !next.Valid() {
+				t.Errorf("%s lists unknown status %q", s, next)
+			}
+		}
+	}
+}
+
+func TestParsePaymentStatus(t *testing.T) {
+	if s, err := ParsePaymentStatus("partially_refunded"); err != nil || s != StatusPartiallyRefunded {
+		t.Errorf("got %q, %v", s, err)
+	}
+	for _, bad := range []string{"", "success", "CAPTURED"} {
+		if _, err := ParsePaymentStatus(bad); err != ErrInvalidStatus {
+			t.Errorf("%q: got %v", bad, err)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"payment-service/internal/money"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Refund is money given back on a captured payment. A payment can have
+// several; together they never exceed its amount.
+type Refund struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	PaymentID primitive.ObjectID `bson:"payment_id" json:"payment_id"`
+	Amount    money.Money        `bson:"amount" json:"amount"`
+	Reason    string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	Actor     string             `bson:"actor" json:"actor"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+var (
+	ErrInvalidRefundAmount = errors.New("refund amount must be positive")
+	ErrRefundExceedsAmount = errors.New("refund exceeds the amount left to refund")
+	ErrNotRefundable       = errors.New("only captured payments can be refunded")
+)
+
+// Refundable reports whether refunds can be made on a payment in s.
+func (s PaymentStatus) Refundable() bool {
+	return s == StatusCaptured || s == StatusPartiallyRefunded
+}
+
+// RefundStatus is the status of p once refunded has been given back on it
+// in total: refunded when that is all of it.
+func (p *Payment) RefundStatus(refunded money.Money) PaymentStatus {
+	if c, err := refunded.Cmp(p.Amount); err == nil && c >= 0 {
+		return StatusRefunded
+	}
+	return StatusPartiallyRefunded
+}
+
+// Refunded is the sum of p's refunds, in its currency.
+func (p *Payment) Refunded() money.Money {
+	if p.RefundedAmount.Currency == "" {
+		return money.New(p.RefundedAmount.AmountMinor, p.Amount.Currency)
+	}
+	return p.RefundedAmount
+}
+
+// Reserved is what the refunds in progress on p hold, in its currency.
+func (p *Payment) Reserved() money.Money {
+	if p.RefundReserved.Currency == "" {
+		return money.New(p.RefundReserved.AmountMinor, p.Amount.Currency)
+	}
+	return p.RefundReserved
+}
+
+// RefundableAmount is what is left to refund on p, less what refunds in
+// progress hold.
+func (p *Payment) RefundableAmount() (money.Money, error) {
+	left, err := p.Amount.Sub(p.Refunded())
+	if err != nil {
+		return money.Money{}, err
+	}
+	return left.Sub(p.Reserved())
+}
+
+// CheckRefund tells whether amount can be refunded on p now.
+func (p *Payment) CheckRefund(amount money.Money) error {
+	if !p.Status.Refundable() {
+		return fmt.Errorf("%w: payment is %s", ErrNotRefundable, p.Status)
+	}
+	if !amount.IsPositive() {
+		return ErrInvalidRefundAmount
+	}
+	left, err := p.RefundableAmount()
+	if err != nil {
+		return err
+	}
+	if c, err := amount.Cmp(left); err != nil {
+		return err
+	} else if c > 0 {
+		return fmt.Errorf("%w: %s left", ErrRefundExceedsAmount, left)
+	}
+	return nil
+}
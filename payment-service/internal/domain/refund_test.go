@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"payment-service/internal/money"
+)
+
+func TestRefunds(t *testing.T) {
+	usd := func(minor int64) money.Money { return money.New(minor, "USD") }
+	p := &Payment{Amount: usd(30), Status: StatusCaptured}
+
+	if err := p.CheckRefund(usd(0)); !errors.Is(err, ErrInvalidRefundAmount) {
+		t.Errorf("zero refund: %v", err)
+	}
+	if err := p.CheckRefund(usd(31)); !errors.Is(err, ErrRefundExceedsAmount) {
+		t.Errorf("refund above the amount: %v", err)
+	}
+	if err := p.CheckRefund(money.New(10, "IDR")); !errors.Is(err, money.ErrCurrencyMismatch) {
+		t.Errorf("refund in another currency: %v", err)
+	}
+
+	refund := func(amount money.Money) {
+		t.Helper()
+		if err := p.CheckRefund(amount); err != nil {
+			t.Fatal(err)
+		}
+		refunded, err := p.Refunded().Add(amount)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.RefundedAmount, p.Status = refunded, p.RefundStatus(refunded)
+	}
+
+	refund(usd(10))
+	if p.Status != StatusPartiallyRefunded {
+		t.Errorf("after a partial refund: %s", p.Status)
+	}
+	refund(usd(20))
+	if p.Status != StatusRefunded {
+		t.Errorf("after refunding the rest: %s", p.Status)
+	}
+	if left, _ := p.RefundableAmount(); !left.IsZero() {
+		t.Errorf("%v left to refund", left)
+	}
+
+	if err := p.CheckRefund(usd(1)); !errors.Is(err, ErrNotRefundable) {
+		t.Errorf("refund of a refunded payment: %v", err)
+	}
+
+	// What refunds in progress hold can't be refunded again
+	p = &Payment{Amount: usd(30), Status: StatusCaptured, RefundedAmount: usd(10), RefundReserved: usd(15)}
+	if left, _ := p.RefundableAmount(); left != usd(5) {
+		t.Errorf("%v left to refund, want 5", left)
+	}
+	if err := p.CheckRefund(usd(6)); !errors.Is(err, ErrRefundExceedsAmount) {
+		t.Errorf("refund above what isn't reserved: %v", err)
+	}
+	p.Status = StatusRefunded
+	if err := p.CheckRefund(usd(1)); !errors.Is(err, ErrNotRefundable) {
+		t.Errorf("refund of a refunded payment: %v", err)
+	}
+	if err := (&Payment{Amount: usd(500), Status: StatusPending}).CheckRefund(usd(100)); !errors.Is(err, ErrNotRefundable) {
+		t.Errorf("refund of a pending payment: %v", err)
+	}
+}
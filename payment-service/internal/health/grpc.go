@@ -0,0 +1,48 @@
+package health
+
+import (
+	"context"
+	"log"
+	"time"
+
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// ServeGRPC keeps the grpc.health.v1 status of server ("" and every name in
+// services) in line with the checker, re-running it every interval until ctx
+// is done. It returns after the first run so the server starts with a real
+// status.
+func (h *Checker) ServeGRPC(ctx context.Context, server *grpchealth.Server, interval time.Duration, services ...string) {
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	update := func() {
+		result := h.Run(ctx)
+		status := healthpb.HealthCheckResponse_SERVING
+		if result.Status != "ok" {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		if status != last {
+			log.Printf("[HEALTH] gRPC status %s: %v", status, result.Checks)
+			last = status
+		}
+		server.SetServingStatus("", status)
+		for _, name := range services {
+			server.SetServingStatus(name, status)
+		}
+	}
+
+	update()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				server.Shutdown()
+				return
+			case <-ticker.C:
+				update()
+			}
+		}
+	}()
+}
@@ -0,0 +1,60 @@
+package infra
+
+import (
+	"context"
+	"log"
+	"math"
+
+	"payment-service/internal/money"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MigrateMoney converts the amounts stored as floats before amounts were
+// money into currency, the one they were all in. It does nothing to
+// documents already converted, so it runs at every start.
+func MigrateMoney(ctx context.Context, db *mongo.Database, currency money.Currency) error {
+	payments := db.Collection("payments")
+	for _, field := range []string{"amount", "refunded_amount"} {
+		if err := convertFloats(ctx, payments, field, currency); err != nil {
+			return err
+		}
+	}
+	// Nothing refunded yet, in the payment's currency
+	result, err := payments.UpdateMany(ctx,
+		bson.M{"refunded_amount": bson.M{"$exists": false}},
+		bson.A{bson.M{"$set": bson.M{"refunded_amount": bson.M{
+			"amount_minor": int64(0),
+			"currency":     "$amount.currency",
+		}}}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount > 0 {
+		log.Printf("[MIGRATE] set refunded_amount of %d payments", result.ModifiedCount)
+	}
+
+	return convertFloats(ctx, db.Collection("refunds"), "amount", currency)
+}
+
+// convertFloats turns field, where it holds a number, into money in
+// currency, rounding to the minor unit.
+func convertFloats(ctx context.Context, collection *mongo.Collection, field string, currency money.Currency) error {
+	scale := math.Pow10(currency.MinorDigits())
+	result, err := collection.UpdateMany(ctx,
+		bson.M{field: bson.M{"$type": "number"}},
+		bson.A{bson.M{"$set": bson.M{field: bson.M{
+			"amount_minor": bson.M{"$toLong": bson.M{"$round": bson.A{bson.M{"$multiply": bson.A{"$" + field, scale}}, 0}}},
+			"currency":     currency,
+		}}}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount > 0 {
+		log.Printf("[MIGRATE] converted %s of %d %s to %s minor units", field, result.ModifiedCount, collection.Name(), currency)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"payment-service/internal/provider"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type mongoFakeStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoFakeStore keeps the fake provider's payments in fake_psp_payments,
+// shared by the HTTP and gRPC servers and kept across restarts.
+func NewMongoFakeStore(db *mongo.Database) provider.FakeStore {
+	return &mongoFakeStore{collection: db.Collection("fake_psp_payments")}
+}
+
+func (s *mongoFakeStore) Get(ctx context.Context, id string) (*provider.FakePayment, error) {
+	var p provider.FakePayment
+	err := s.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&p)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("%w: %s", provider.ErrUnknownPayment, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (s *mongoFakeStore) Create(ctx context.Context, p *provider.FakePayment) error {
+	_, err := s.collection.InsertOne(ctx, p)
+	if mongo.IsDuplicateKeyError(err) {
+		return provider.ErrFakeConflict
+	}
+	return err
+}
+
+func (s *mongoFakeStore) Update(ctx context.Context, p *provider.FakePayment) error {
+	result, err := s.collection.ReplaceOne(ctx, bson.M{"_id": p.ID, "version": p.Version - 1}, p)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return provider.ErrFakeConflict
+	}
+	return nil
+}
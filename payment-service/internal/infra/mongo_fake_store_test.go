@@ -0,0 +1,39 @@
+package infra_test
+
+import (
+	"context"
+	"testing"
+
+	"payment-service/internal/domain"
+	"payment-service/internal/infra"
+	"payment-service/internal/money"
+	"payment-service/internal/provider"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeStore(t *testing.T) {
+	db, cleanup := setupTestMongo(t)
+	defer cleanup()
+	defer db.Collection("fake_psp_payments").Drop(context.Background())
+
+	ctx := context.Background()
+	store := infra.NewMongoFakeStore(db)
+
+	_, err := store.Get(ctx, "p1")
+	assert.ErrorIs(t, err, provider.ErrUnknownPayment)
+
+	p := &provider.FakePayment{ID: "p1", Amount: money.New(500, "IDR"), Status: domain.StatusAuthorized}
+	assert.NoError(t, store.Create(ctx, p))
+	assert.ErrorIs(t, store.Create(ctx, p), provider.ErrFakeConflict)
+
+	p.Status, p.Version = domain.StatusCaptured, 1
+	assert.NoError(t, store.Update(ctx, p))
+	// Written over already: this copy is stale
+	assert.ErrorIs(t, store.Update(ctx, p), provider.ErrFakeConflict)
+
+	got, err := store.Get(ctx, "p1")
+	assert.NoError(t, err)
+	assert.Equal(t, p.Status, got.Status)
+	assert.Equal(t, int64(1), got.Version)
+}
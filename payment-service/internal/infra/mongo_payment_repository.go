@@ -2,24 +2,35 @@ package infra
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"payment-service/internal/domain"
+	"payment-service/internal/money"
 	"payment-service/internal/repository"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type mongoPaymentRepository struct {
 	collection *mongo.Collection
 }
 
-func NewMongoPaymentRepository(db *mongo.Database) repository.PaymentRepository {
-	return &mongoPaymentRepository{
-		collection: db.Collection("payments"),
+// NewMongoPaymentRepository stores payments in payments. Payments written
+// before statuses were typed are moved from "success" to captured.
+func NewMongoPaymentRepository(ctx context.Context, db *mongo.Database) (repository.PaymentRepository, error) {
+	collection := db.Collection("payments")
+	_, err := collection.UpdateMany(ctx,
+		bson.M{"status": domain.LegacyStatusSuccess},
+		bson.M{"$set": bson.M{"status": domain.StatusCaptured}},
+	)
+	if err != nil {
+		return nil, err
 	}
+	return &mongoPaymentRepository{collection: collection}, nil
 }
 
 func (r *mongoPaymentRepository) GetAll(ctx context.Context) ([]domain.Payment, error) {
@@ -51,6 +62,18 @@ func (r *mongoPaymentRepository) GetByID(ctx context.Context, id primitive.Objec
 	return &payment, nil
 }
 
+func (r *mongoPaymentRepository) ListByStatus(ctx context.Context, statuses ...domain.PaymentStatus) ([]domain.Payment, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"status": bson.M{"$in": statuses}})
+	if err != nil {
+		return nil, err
+	}
+	var payments []domain.Payment
+	if err := cursor.All(ctx, &payments); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
 func (r *mongoPaymentRepository) Create(ctx context.Context, payment *domain.Payment) (*domain.Payment, error) {
 	payment.ID = primitive.NewObjectID()
 	payment.CreatedAt = time.Now()
@@ -64,24 +87,91 @@ func (r *mongoPaymentRepository) Create(ctx context.Context, payment *domain.Pay
 	return payment, nil
 }
 
-func (r *mongoPaymentRepository) Update(ctx context.Context, id primitive.ObjectID, payment *domain.Payment) (*domain.Payment, error) {
-	filter := bson.M{"_id": id}
+func (r *mongoPaymentRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, change domain.StatusChange) (*domain.Payment, error) {
+	filter := bson.M{"_id": id, "status": change.From}
 	update := bson.M{
-		"$set": bson.M{
-			"status": payment.Status,
-			"amount": payment.Amount,
-			"email":  payment.Email,
-		},
+		"$set":  bson.M{"status": change.To},
+		"$push": bson.M{"status_history": change},
 	}
 
-	_, err := r.collection.UpdateOne(ctx, filter, update)
+	result, err := r.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return nil, err
 	}
+	if result.MatchedCount == 0 {
+		return nil, domain.ErrStatusConflict
+	}
 
 	return r.GetByID(ctx, id)
 }
 
+func (r *mongoPaymentRepository) ReserveRefund(ctx context.Context, id primitive.ObjectID, amount money.Money) (*domain.Payment, error) {
+	filter := bson.M{
+		"_id":             id,
+		"status":          bson.M{"$in": bson.A{domain.StatusCaptured, domain.StatusPartiallyRefunded}},
+		"amount.currency": amount.Currency,
+		// refunded + reserved + amount <= the payment's amount
+		"$expr": bson.M{"$lte": bson.A{
+			bson.M{"$add": bson.A{
+				bson.M{"$ifNull": bson.A{"$refunded_amount.amount_minor", 0}},
+				bson.M{"$ifNull": bson.A{"$refund_reserved.amount_minor", 0}},
+				amount.AmountMinor,
+			}},
+			"$amount.amount_minor",
+		}},
+	}
+	update := bson.M{"$inc": bson.M{"refund_reserved.amount_minor": amount.AmountMinor}}
+
+	var payment domain.Payment
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&payment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrStatusConflict
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *mongoPaymentRepository) ReleaseRefund(ctx context.Context, id primitive.ObjectID, amount money.Money) error {
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$inc": bson.M{"refund_reserved.amount_minor": -amount.AmountMinor}},
+	)
+	return err
+}
+
+func (r *mongoPaymentRepository) ConfirmRefund(ctx context.Context, current *domain.Payment, amount money.Money, change *domain.StatusChange) (*domain.Payment, error) {
+	refunded, err := current.Refunded().Add(amount)
+	if err != nil {
+		return nil, err
+	}
+	filter := bson.M{
+		"_id":                          current.ID,
+		"status":                       current.Status,
+		"refunded_amount.amount_minor": current.Refunded().AmountMinor,
+	}
+	set := bson.M{"refunded_amount": refunded}
+	update := bson.M{
+		"$set": set,
+		"$inc": bson.M{"refund_reserved.amount_minor": -amount.AmountMinor},
+	}
+	if change != nil {
+		set["status"] = change.To
+		update["$push"] = bson.M{"status_history": change}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		return nil, domain.ErrStatusConflict
+	}
+
+	return r.GetByID(ctx, current.ID)
+}
+
 func (r *mongoPaymentRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
 	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
 	return err
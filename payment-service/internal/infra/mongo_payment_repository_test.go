@@ -3,9 +3,11 @@ package infra_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"payment-service/internal/domain"
 	"payment-service/internal/infra"
+	"payment-service/internal/money"
 
 	"github.com/stretchr/testify/assert"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -39,13 +41,14 @@ func TestCreatePayment(t *testing.T) {
 	db, cleanup := setupTestMongo(t)
 	defer cleanup()
 
-	repo := infra.NewMongoPaymentRepository(db)
-
 	ctx := context.Background()
+	repo, err := infra.NewMongoPaymentRepository(ctx, db)
+	assert.NoError(t, err)
+
 	payment := &domain.Payment{
-		Amount: 1000,
+		Amount: money.New(100000, "IDR"),
 		Email:  "test@example.com",
-		Status: "pending",
+		Status: domain.StatusPending,
 	}
 
 	result, err := repo.Create(ctx, payment)
@@ -57,14 +60,15 @@ func TestGetAllPayments(t *testing.T) {
 	db, cleanup := setupTestMongo(t)
 	defer cleanup()
 
-	repo := infra.NewMongoPaymentRepository(db)
-
 	ctx := context.Background()
+	repo, err := infra.NewMongoPaymentRepository(ctx, db)
+	assert.NoError(t, err)
+
 	// Insert dummy
 	payment := &domain.Payment{
-		Amount: 500,
+		Amount: money.New(50000, "IDR"),
 		Email:  "dummy@example.com",
-		Status: "success",
+		Status: domain.StatusCaptured,
 	}
 	_, _ = repo.Create(ctx, payment)
 
@@ -73,17 +77,43 @@ func TestGetAllPayments(t *testing.T) {
 	assert.True(t, len(payments) >= 1)
 }
 
-func TestGetByIDPayment(t *testing.T) {
+func TestListByStatus(t *testing.T) {
 	db, cleanup := setupTestMongo(t)
 	defer cleanup()
 
-	repo := infra.NewMongoPaymentRepository(db)
+	ctx := context.Background()
+	repo, err := infra.NewMongoPaymentRepository(ctx, db)
+	assert.NoError(t, err)
+
+	for _, status := range []domain.PaymentStatus{domain.StatusPending, domain.StatusAuthorized, domain.StatusCaptured} {
+		_, err := repo.Create(ctx, &domain.Payment{
+			Amount: money.New(50000, "IDR"),
+			Email:  "dummy@example.com",
+			Status: status,
+		})
+		assert.NoError(t, err)
+	}
+
+	payments, err := repo.ListByStatus(ctx, domain.StatusPending, domain.StatusAuthorized)
+	assert.NoError(t, err)
+	assert.Len(t, payments, 2)
+	for _, p := range payments {
+		assert.NotEqual(t, domain.StatusCaptured, p.Status)
+	}
+}
+
+func TestGetByIDPayment(t *testing.T) {
+	db, cleanup := setupTestMongo(t)
+	defer cleanup()
 
 	ctx := context.Background()
+	repo, err := infra.NewMongoPaymentRepository(ctx, db)
+	assert.NoError(t, err)
+
 	payment := &domain.Payment{
-		Amount: 750,
+		Amount: money.New(75000, "IDR"),
 		Email:  "findme@example.com",
-		Status: "pending",
+		Status: domain.StatusPending,
 	}
 	created, _ := repo.Create(ctx, payment)
 
@@ -96,41 +126,94 @@ func TestUpdatePayment(t *testing.T) {
 	db, cleanup := setupTestMongo(t)
 	defer cleanup()
 
-	repo := infra.NewMongoPaymentRepository(db)
-
 	ctx := context.Background()
+	repo, err := infra.NewMongoPaymentRepository(ctx, db)
+	assert.NoError(t, err)
+
 	payment := &domain.Payment{
-		Amount: 200,
+		Amount: money.New(20000, "IDR"),
 		Email:  "updateme@example.com",
-		Status: "pending",
+		Status: domain.StatusPending,
 	}
 	created, _ := repo.Create(ctx, payment)
 
-	// Update data
-	created.Amount = 999
-	created.Status = "paid"
-	updated, err := repo.Update(ctx, created.ID, created)
+	updated, err := repo.UpdateStatus(ctx, created.ID, domain.StatusChange{
+		From:  domain.StatusPending,
+		To:    domain.StatusCaptured,
+		At:    time.Now(),
+		Actor: "test",
+	})
 
 	assert.NoError(t, err)
-	assert.Equal(t, float64(999), updated.Amount)
-	assert.Equal(t, "paid", updated.Status)
+	assert.Equal(t, domain.StatusCaptured, updated.Status)
+	assert.Len(t, updated.StatusHistory, 1)
+
+	// Already captured: a second change from pending must not apply
+	_, err = repo.UpdateStatus(ctx, created.ID, domain.StatusChange{
+		From: domain.StatusPending,
+		To:   domain.StatusCancelled,
+		At:   time.Now(),
+	})
+	assert.ErrorIs(t, err, domain.ErrStatusConflict)
 }
 
-func TestDeletePayment(t *testing.T) {
+func TestRefundReservation(t *testing.T) {
 	db, cleanup := setupTestMongo(t)
 	defer cleanup()
 
-	repo := infra.NewMongoPaymentRepository(db)
+	ctx := context.Background()
+	repo, err := infra.NewMongoPaymentRepository(ctx, db)
+	assert.NoError(t, err)
+
+	created, _ := repo.Create(ctx, &domain.Payment{
+		Amount:         money.New(10000, "IDR"),
+		RefundedAmount: money.New(0, "IDR"),
+		Email:          "refundme@example.com",
+		Status:         domain.StatusCaptured,
+	})
+
+	reserved, err := repo.ReserveRefund(ctx, created.ID, money.New(6000, "IDR"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6000), reserved.Reserved().AmountMinor)
+
+	// Only 4000 is left that no refund holds
+	_, err = repo.ReserveRefund(ctx, created.ID, money.New(5000, "IDR"))
+	assert.ErrorIs(t, err, domain.ErrStatusConflict)
+	_, err = repo.ReserveRefund(ctx, created.ID, money.New(4000, "IDR"))
+	assert.NoError(t, err)
+	assert.NoError(t, repo.ReleaseRefund(ctx, created.ID, money.New(4000, "IDR")))
+
+	updated, err := repo.ConfirmRefund(ctx, reserved, money.New(6000, "IDR"), &domain.StatusChange{
+		From: domain.StatusCaptured,
+		To:   domain.StatusPartiallyRefunded,
+		At:   time.Now(),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, money.New(6000, "IDR"), updated.RefundedAmount)
+	assert.True(t, updated.Reserved().IsZero())
+	assert.Equal(t, domain.StatusPartiallyRefunded, updated.Status)
+
+	// A confirmation from the stale copy must not apply
+	_, err = repo.ConfirmRefund(ctx, reserved, money.New(6000, "IDR"), nil)
+	assert.ErrorIs(t, err, domain.ErrStatusConflict)
+}
+
+func TestDeletePayment(t *testing.T) {
+	db, cleanup := setupTestMongo(t)
+	defer cleanup()
 
 	ctx := context.Background()
+	repo, err := infra.NewMongoPaymentRepository(ctx, db)
+	assert.NoError(t, err)
+
 	payment := &domain.Payment{
-		Amount: 300,
+		Amount: money.New(30000, "IDR"),
 		Email:  "deleteme@example.com",
-		Status: "pending",
+		Status: domain.StatusPending,
 	}
 	created, _ := repo.Create(ctx, payment)
 
-	err := repo.Delete(ctx, created.ID)
+	err = repo.Delete(ctx, created.ID)
 	assert.NoError(t, err)
 
 	// Try get again
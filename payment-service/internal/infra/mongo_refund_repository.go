@@ -0,0 +1,63 @@
+package infra
+
+import (
+	"context"
+	"time"
+
+	"payment-service/internal/domain"
+	"payment-service/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoRefundRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoRefundRepository stores refunds in refunds, indexed by payment.
+func NewMongoRefundRepository(ctx context.Context, db *mongo.Database) (repository.RefundRepository, error) {
+	collection := db.Collection("refunds")
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "payment_id", Value: 1}, {Key: "_id", Value: 1}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &mongoRefundRepository{collection: collection}, nil
+}
+
+func (r *mongoRefundRepository) Create(ctx context.Context, refund *domain.Refund) (*domain.Refund, error) {
+	refund.ID = primitive.NewObjectID()
+	if refund.CreatedAt.IsZero() {
+		refund.CreatedAt = time.Now()
+	}
+	if _, err := r.collection.InsertOne(ctx, refund); err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+func (r *mongoRefundRepository) ListByPayment(ctx context.Context, paymentID primitive.ObjectID) ([]domain.Refund, error) {
+	cursor, err := r.collection.Find(ctx,
+		bson.M{"payment_id": paymentID},
+		options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	refunds := []domain.Refund{}
+	if err := cursor.All(ctx, &refunds); err != nil {
+		return nil, err
+	}
+	return refunds, nil
+}
+
+func (r *mongoRefundRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
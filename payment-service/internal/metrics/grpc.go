@@ -0,0 +1,14 @@
+package metrics
+
+import (
+	grpcprom "github.com/grpc-ecosystem/go-grpc-middleware/providers/prometheus"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// GRPCServer records grpc_server_* metrics (started, handled by code,
+// handling time). Chain its interceptors into grpc.NewServer.
+var GRPCServer = grpcprom.NewServerMetrics(grpcprom.WithServerHandlingTimeHistogram())
+
+func init() {
+	prometheus.MustRegister(GRPCServer)
+}
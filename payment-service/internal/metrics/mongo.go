@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+var (
+	mongoConnections = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mongo_pool_connections",
+		Help: "Open connections in the MongoDB driver pool.",
+	}, []string{"address"})
+
+	mongoInUse = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mongo_pool_connections_in_use",
+		Help: "Connections checked out of the MongoDB driver pool.",
+	}, []string{"address"})
+
+	mongoCheckoutFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "mongo_pool_checkout_failures_total",
+		Help: "Failed attempts to get a connection from the MongoDB pool.",
+	}, []string{"address"})
+)
+
+// PoolMonitor feeds the mongo_pool_* metrics; pass it to
+// options.Client().SetPoolMonitor.
+func PoolMonitor() *event.PoolMonitor {
+	return &event.PoolMonitor{
+		Event: func(e *event.PoolEvent) {
+			switch e.Type {
+			case event.ConnectionCreated:
+				mongoConnections.WithLabelValues(e.Address).Inc()
+			case event.ConnectionClosed:
+				mongoConnections.WithLabelValues(e.Address).Dec()
+			case event.GetSucceeded:
+				mongoInUse.WithLabelValues(e.Address).Inc()
+			case event.ConnectionReturned:
+				mongoInUse.WithLabelValues(e.Address).Dec()
+			case event.GetFailed:
+				mongoCheckoutFailures.WithLabelValues(e.Address).Inc()
+			}
+		},
+	}
+}
@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// PaymentsCreated counts payments created, by the status creating them
+	// ended at.
+	PaymentsCreated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "payments_created_total",
+		Help: "Payments created by status.",
+	}, []string{"status"})
+
+	// PaymentStatusUpdates counts updates by the status set.
+	PaymentStatusUpdates = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "payment_status_updates_total",
+		Help: "Payment updates by new status.",
+	}, []string{"status"})
+
+	// PaymentRefunds counts refunds made, by the status they left the
+	// payment in.
+	PaymentRefunds = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "payment_refunds_total",
+		Help: "Payment refunds by resulting payment status.",
+	}, []string{"status"})
+
+	// ProviderCalls counts the calls made to payment providers, by the
+	// status they answered or how they failed.
+	ProviderCalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "payment_provider_calls_total",
+		Help: "Payment provider calls by provider, call and outcome.",
+	}, []string{"provider", "call", "outcome"})
+)
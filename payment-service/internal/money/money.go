@@ -0,0 +1,170 @@
+// Package money represents amounts as a whole number of the minor unit
+// (cents) of an ISO 4217 currency, so that sums and products are exact.
+// Amounts in different currencies never mix: arithmetic on them fails with
+// ErrCurrencyMismatch.
+//
+// payment-service, product-service and transaction-service each have a copy
+// of this package; TestCopiesInSync fails when they differ.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+var (
+	ErrUnknownCurrency  = errors.New("unknown currency")
+	ErrCurrencyMismatch = errors.New("amounts are in different currencies")
+	ErrOverflow         = errors.New("amount out of range")
+)
+
+// Currency is an ISO 4217 code such as IDR.
+type Currency string
+
+// minorDigits has the number of digits of the minor unit of the currencies
+// accepted, from ISO 4217.
+var minorDigits = map[Currency]int{
+	"AUD": 2,
+	"CNY": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"HKD": 2,
+	"IDR": 2,
+	"INR": 2,
+	"JPY": 0,
+	"KRW": 0,
+	"MYR": 2,
+	"PHP": 2,
+	"SGD": 2,
+	"THB": 2,
+	"USD": 2,
+	"VND": 0,
+}
+
+// ParseCurrency returns the currency with code, in any case.
+func ParseCurrency(code string) (Currency, error) {
+	c := Currency(strings.ToUpper(strings.TrimSpace(code)))
+	if _, ok := minorDigits[c]; !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownCurrency, code)
+	}
+	return c, nil
+}
+
+// MinorDigits is how many digits of c's minor unit make one major unit: 2
+// for USD (100 cents to the dollar), 0 for JPY.
+func (c Currency) MinorDigits() int {
+	return minorDigits[c]
+}
+
+// Money is an amount of AmountMinor minor units of Currency. In Mongo and
+// JSON it is {"amount_minor": 1050, "currency": "USD"} for $10.50.
+type Money struct {
+	AmountMinor int64    `bson:"amount_minor" json:"amount_minor"`
+	Currency    Currency `bson:"currency" json:"currency"`
+}
+
+func New(amountMinor int64, currency Currency) Money {
+	return Money{AmountMinor: amountMinor, Currency: currency}
+}
+
+// Parse reads an amount given as minor units and a currency code, in any
+// case. An empty code leaves the currency empty, for the caller to fill in
+// its default.
+func Parse(amountMinor int64, code string) (Money, error) {
+	if code == "" {
+		return New(amountMinor, ""), nil
+	}
+	currency, err := ParseCurrency(code)
+	if err != nil {
+		return Money{}, err
+	}
+	return New(amountMinor, currency), nil
+}
+
+// FromFloat converts an amount in major units, the way amounts were stored
+// before this package, rounding to the nearest minor unit.
+func FromFloat(amount float64, currency Currency) (Money, error) {
+	minor := math.Round(amount * math.Pow10(currency.MinorDigits()))
+	if math.IsNaN(minor) || minor >= math.MaxInt64 || minor <= math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+	return New(int64(minor), currency), nil
+}
+
+// Validate checks that m's currency is known.
+func (m Money) Validate() error {
+	_, err := ParseCurrency(string(m.Currency))
+	return err
+}
+
+func (m Money) IsZero() bool     { return m.AmountMinor == 0 }
+func (m Money) IsPositive() bool { return m.AmountMinor > 0 }
+
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, mismatch(m, o)
+	}
+	sum := m.AmountMinor + o.AmountMinor
+	if (o.AmountMinor > 0 && sum < m.AmountMinor) || (o.AmountMinor < 0 && sum > m.AmountMinor) {
+		return Money{}, ErrOverflow
+	}
+	return New(sum, m.Currency), nil
+}
+
+func (m Money) Sub(o Money) (Money, error) {
+	if o.AmountMinor == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+	return m.Add(New(-o.AmountMinor, o.Currency))
+}
+
+// Mul is m times n, say a unit price times a quantity.
+func (m Money) Mul(n int64) (Money, error) {
+	if m.AmountMinor == 0 || n == 0 {
+		return New(0, m.Currency), nil
+	}
+	product := m.AmountMinor * n
+	// MinInt64 * -1 wraps around to MinInt64, which the division misses
+	if product/n != m.AmountMinor || (m.AmountMinor == math.MinInt64 && n == -1) || (m.AmountMinor == -1 && n == math.MinInt64) {
+		return Money{}, ErrOverflow
+	}
+	return New(product, m.Currency), nil
+}
+
+// Cmp compares m and o: -1 when m is less, 0 when equal, +1 when more.
+func (m Money) Cmp(o Money) (int, error) {
+	if m.Currency != o.Currency {
+		return 0, mismatch(m, o)
+	}
+	switch {
+	case m.AmountMinor < o.AmountMinor:
+		return -1, nil
+	case m.AmountMinor > o.AmountMinor:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// String formats m in major units, e.g. "10.50 USD".
+func (m Money) String() string {
+	digits := m.Currency.MinorDigits()
+	sign, minor := "", m.AmountMinor
+	if minor < 0 {
+		sign = "-"
+	}
+	if digits == 0 {
+		return fmt.Sprintf("%d %s", minor, m.Currency)
+	}
+	unit := uint64(math.Pow10(digits))
+	abs := uint64(minor)
+	if minor < 0 {
+		abs = uint64(-(minor + 1)) + 1
+	}
+	return fmt.Sprintf("%s%d.%0*d %s", sign, abs/unit, digits, abs%unit, m.Currency)
+}
+
+func mismatch(a, b Money) error {
+	return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, a.Currency, b.Currency)
+}
@@ -7,6 +7,7 @@
 package pb
 
 import (
+	_ "google.golang.org/genproto/googleapis/api/annotations"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
@@ -22,12 +23,161 @@ const (
 )
 
 // ==== Payment message ====
+// Where a payment is in its life cycle. A payment starts PENDING and only
+// moves along the transitions payment-service allows; FAILED, CANCELLED and
+// REFUNDED are final.
+type PaymentStatus int32
+
+const (
+	PaymentStatus_PAYMENT_STATUS_UNSPECIFIED        PaymentStatus = 0
+	PaymentStatus_PAYMENT_STATUS_PENDING            PaymentStatus = 1
+	PaymentStatus_PAYMENT_STATUS_AUTHORIZED         PaymentStatus = 2
+	PaymentStatus_PAYMENT_STATUS_CAPTURED           PaymentStatus = 3
+	PaymentStatus_PAYMENT_STATUS_FAILED             PaymentStatus = 4
+	PaymentStatus_PAYMENT_STATUS_CANCELLED          PaymentStatus = 5
+	PaymentStatus_PAYMENT_STATUS_REFUNDED           PaymentStatus = 6
+	PaymentStatus_PAYMENT_STATUS_PARTIALLY_REFUNDED PaymentStatus = 7
+)
+
+// Enum value maps for PaymentStatus.
+var (
+	PaymentStatus_name = map[int32]string{
+		0: "PAYMENT_STATUS_UNSPECIFIED",
+		1: "PAYMENT_STATUS_PENDING",
+		2: "PAYMENT_STATUS_AUTHORIZED",
+		3: "PAYMENT_STATUS_CAPTURED",
+		4: "PAYMENT_STATUS_FAILED",
+		5: "PAYMENT_STATUS_CANCELLED",
+		6: "PAYMENT_STATUS_REFUNDED",
+		7: "PAYMENT_STATUS_PARTIALLY_REFUNDED",
+	}
+	PaymentStatus_value = map[string]int32{
+		"PAYMENT_STATUS_UNSPECIFIED":        0,
+		"PAYMENT_STATUS_PENDING":            1,
+		"PAYMENT_STATUS_AUTHORIZED":         2,
+		"PAYMENT_STATUS_CAPTURED":           3,
+		"PAYMENT_STATUS_FAILED":             4,
+		"PAYMENT_STATUS_CANCELLED":          5,
+		"PAYMENT_STATUS_REFUNDED":           6,
+		"PAYMENT_STATUS_PARTIALLY_REFUNDED": 7,
+	}
+)
+
+func (x PaymentStatus) Enum() *PaymentStatus {
+	p := new(PaymentStatus)
+	*p = x
+	return p
+}
+
+func (x PaymentStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PaymentStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_payment_proto_enumTypes[0].Descriptor()
+}
+
+func (PaymentStatus) Type() protoreflect.EnumType {
+	return &file_payment_proto_enumTypes[0]
+}
+
+func (x PaymentStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PaymentStatus.Descriptor instead.
+func (PaymentStatus) EnumDescriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{0}
+}
+
+type StatusChange struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          PaymentStatus          `protobuf:"varint,1,opt,name=from,proto3,enum=payment.PaymentStatus" json:"from,omitempty"` // UNSPECIFIED for the status it was created with
+	To            PaymentStatus          `protobuf:"varint,2,opt,name=to,proto3,enum=payment.PaymentStatus" json:"to,omitempty"`
+	At            string                 `protobuf:"bytes,3,opt,name=at,proto3" json:"at,omitempty"`
+	Actor         string                 `protobuf:"bytes,4,opt,name=actor,proto3" json:"actor,omitempty"`
+	Reason        string                 `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"` // the provider's code for a failure, e.g. card_declined
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusChange) Reset() {
+	*x = StatusChange{}
+	mi := &file_payment_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusChange) ProtoMessage() {}
+
+func (x *StatusChange) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusChange.ProtoReflect.Descriptor instead.
+func (*StatusChange) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *StatusChange) GetFrom() PaymentStatus {
+	if x != nil {
+		return x.From
+	}
+	return PaymentStatus_PAYMENT_STATUS_UNSPECIFIED
+}
+
+func (x *StatusChange) GetTo() PaymentStatus {
+	if x != nil {
+		return x.To
+	}
+	return PaymentStatus_PAYMENT_STATUS_UNSPECIFIED
+}
+
+func (x *StatusChange) GetAt() string {
+	if x != nil {
+		return x.At
+	}
+	return ""
+}
+
+func (x *StatusChange) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *StatusChange) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// Amounts are whole minor units (cents) of currency, an ISO 4217 code:
+// amount_minor 1050 with currency USD is $10.50.
 type Payment struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
 	Email         string                 `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
-	Amount        float64                `protobuf:"fixed64,3,opt,name=amount,proto3" json:"amount,omitempty"`
-	Status        string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+	AmountMinor   int64                  `protobuf:"varint,9,opt,name=amount_minor,json=amountMinor,proto3" json:"amount_minor,omitempty"`
+	Currency      string                 `protobuf:"bytes,10,opt,name=currency,proto3" json:"currency,omitempty"`
+	Status        PaymentStatus          `protobuf:"varint,6,opt,name=status,proto3,enum=payment.PaymentStatus" json:"status,omitempty"`
+	StatusHistory []*StatusChange        `protobuf:"bytes,7,rep,name=status_history,json=statusHistory,proto3" json:"status_history,omitempty"`
+	RefundedMinor int64                  `protobuf:"varint,11,opt,name=refunded_minor,json=refundedMinor,proto3" json:"refunded_minor,omitempty"` // sum of the payment's refunds
+	Provider      string                 `protobuf:"bytes,12,opt,name=provider,proto3" json:"provider,omitempty"`                                 // the payment service provider charged
 	CreatedAt     string                 `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -35,7 +185,7 @@ type Payment struct {
 
 func (x *Payment) Reset() {
 	*x = Payment{}
-	mi := &file_payment_proto_msgTypes[0]
+	mi := &file_payment_proto_msgTypes[1]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -47,7 +197,7 @@ func (x *Payment) String() string {
 func (*Payment) ProtoMessage() {}
 
 func (x *Payment) ProtoReflect() protoreflect.Message {
-	mi := &file_payment_proto_msgTypes[0]
+	mi := &file_payment_proto_msgTypes[1]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -60,7 +210,7 @@ func (x *Payment) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Payment.ProtoReflect.Descriptor instead.
 func (*Payment) Descriptor() ([]byte, []int) {
-	return file_payment_proto_rawDescGZIP(), []int{0}
+	return file_payment_proto_rawDescGZIP(), []int{1}
 }
 
 func (x *Payment) GetId() string {
@@ -77,17 +227,45 @@ func (x *Payment) GetEmail() string {
 	return ""
 }
 
-func (x *Payment) GetAmount() float64 {
+func (x *Payment) GetAmountMinor() int64 {
 	if x != nil {
-		return x.Amount
+		return x.AmountMinor
 	}
 	return 0
 }
 
-func (x *Payment) GetStatus() string {
+func (x *Payment) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *Payment) GetStatus() PaymentStatus {
 	if x != nil {
 		return x.Status
 	}
+	return PaymentStatus_PAYMENT_STATUS_UNSPECIFIED
+}
+
+func (x *Payment) GetStatusHistory() []*StatusChange {
+	if x != nil {
+		return x.StatusHistory
+	}
+	return nil
+}
+
+func (x *Payment) GetRefundedMinor() int64 {
+	if x != nil {
+		return x.RefundedMinor
+	}
+	return 0
+}
+
+func (x *Payment) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
 	return ""
 }
 
@@ -98,18 +276,113 @@ func (x *Payment) GetCreatedAt() string {
 	return ""
 }
 
+type Refund struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	PaymentId     string                 `protobuf:"bytes,2,opt,name=payment_id,json=paymentId,proto3" json:"payment_id,omitempty"`
+	AmountMinor   int64                  `protobuf:"varint,7,opt,name=amount_minor,json=amountMinor,proto3" json:"amount_minor,omitempty"`
+	Currency      string                 `protobuf:"bytes,8,opt,name=currency,proto3" json:"currency,omitempty"`
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	Actor         string                 `protobuf:"bytes,5,opt,name=actor,proto3" json:"actor,omitempty"`
+	CreatedAt     string                 `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Refund) Reset() {
+	*x = Refund{}
+	mi := &file_payment_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Refund) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Refund) ProtoMessage() {}
+
+func (x *Refund) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Refund.ProtoReflect.Descriptor instead.
+func (*Refund) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Refund) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Refund) GetPaymentId() string {
+	if x != nil {
+		return x.PaymentId
+	}
+	return ""
+}
+
+func (x *Refund) GetAmountMinor() int64 {
+	if x != nil {
+		return x.AmountMinor
+	}
+	return 0
+}
+
+func (x *Refund) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *Refund) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *Refund) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *Refund) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
 // ==== Request ====
 type CreatePaymentRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Email         string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
-	Amount        float64                `protobuf:"fixed64,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	AmountMinor   int64                  `protobuf:"varint,3,opt,name=amount_minor,json=amountMinor,proto3" json:"amount_minor,omitempty"`
+	Currency      string                 `protobuf:"bytes,4,opt,name=currency,proto3" json:"currency,omitempty"` // the service's default currency when empty
+	Card          string                 `protobuf:"bytes,5,opt,name=card,proto3" json:"card,omitempty"`         // card number, passed to the provider and never stored
+	Provider      string                 `protobuf:"bytes,6,opt,name=provider,proto3" json:"provider,omitempty"` // the service's default provider when empty
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *CreatePaymentRequest) Reset() {
 	*x = CreatePaymentRequest{}
-	mi := &file_payment_proto_msgTypes[1]
+	mi := &file_payment_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -121,7 +394,7 @@ func (x *CreatePaymentRequest) String() string {
 func (*CreatePaymentRequest) ProtoMessage() {}
 
 func (x *CreatePaymentRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_payment_proto_msgTypes[1]
+	mi := &file_payment_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -134,7 +407,7 @@ func (x *CreatePaymentRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreatePaymentRequest.ProtoReflect.Descriptor instead.
 func (*CreatePaymentRequest) Descriptor() ([]byte, []int) {
-	return file_payment_proto_rawDescGZIP(), []int{1}
+	return file_payment_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *CreatePaymentRequest) GetEmail() string {
@@ -144,24 +417,46 @@ func (x *CreatePaymentRequest) GetEmail() string {
 	return ""
 }
 
-func (x *CreatePaymentRequest) GetAmount() float64 {
+func (x *CreatePaymentRequest) GetAmountMinor() int64 {
 	if x != nil {
-		return x.Amount
+		return x.AmountMinor
 	}
 	return 0
 }
 
+func (x *CreatePaymentRequest) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *CreatePaymentRequest) GetCard() string {
+	if x != nil {
+		return x.Card
+	}
+	return ""
+}
+
+func (x *CreatePaymentRequest) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
 type UpdatePaymentRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Status        PaymentStatus          `protobuf:"varint,3,opt,name=status,proto3,enum=payment.PaymentStatus" json:"status,omitempty"`
+	Actor         string                 `protobuf:"bytes,4,opt,name=actor,proto3" json:"actor,omitempty"` // who is making the change, recorded in the history
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *UpdatePaymentRequest) Reset() {
 	*x = UpdatePaymentRequest{}
-	mi := &file_payment_proto_msgTypes[2]
+	mi := &file_payment_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -173,7 +468,7 @@ func (x *UpdatePaymentRequest) String() string {
 func (*UpdatePaymentRequest) ProtoMessage() {}
 
 func (x *UpdatePaymentRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_payment_proto_msgTypes[2]
+	mi := &file_payment_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -186,7 +481,7 @@ func (x *UpdatePaymentRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdatePaymentRequest.ProtoReflect.Descriptor instead.
 func (*UpdatePaymentRequest) Descriptor() ([]byte, []int) {
-	return file_payment_proto_rawDescGZIP(), []int{2}
+	return file_payment_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *UpdatePaymentRequest) GetId() string {
@@ -196,10 +491,93 @@ func (x *UpdatePaymentRequest) GetId() string {
 	return ""
 }
 
-func (x *UpdatePaymentRequest) GetStatus() string {
+func (x *UpdatePaymentRequest) GetStatus() PaymentStatus {
 	if x != nil {
 		return x.Status
 	}
+	return PaymentStatus_PAYMENT_STATUS_UNSPECIFIED
+}
+
+func (x *UpdatePaymentRequest) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+type RefundRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`                                       // of the payment
+	AmountMinor   int64                  `protobuf:"varint,5,opt,name=amount_minor,json=amountMinor,proto3" json:"amount_minor,omitempty"` // 0 refunds all that is left to refund
+	Currency      string                 `protobuf:"bytes,6,opt,name=currency,proto3" json:"currency,omitempty"`                           // must be the payment's; empty is the payment's
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	Actor         string                 `protobuf:"bytes,4,opt,name=actor,proto3" json:"actor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RefundRequest) Reset() {
+	*x = RefundRequest{}
+	mi := &file_payment_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefundRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefundRequest) ProtoMessage() {}
+
+func (x *RefundRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefundRequest.ProtoReflect.Descriptor instead.
+func (*RefundRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *RefundRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *RefundRequest) GetAmountMinor() int64 {
+	if x != nil {
+		return x.AmountMinor
+	}
+	return 0
+}
+
+func (x *RefundRequest) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *RefundRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *RefundRequest) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
 	return ""
 }
 
@@ -212,7 +590,7 @@ type GetByIDRequest struct {
 
 func (x *GetByIDRequest) Reset() {
 	*x = GetByIDRequest{}
-	mi := &file_payment_proto_msgTypes[3]
+	mi := &file_payment_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -224,7 +602,7 @@ func (x *GetByIDRequest) String() string {
 func (*GetByIDRequest) ProtoMessage() {}
 
 func (x *GetByIDRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_payment_proto_msgTypes[3]
+	mi := &file_payment_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -237,7 +615,7 @@ func (x *GetByIDRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetByIDRequest.ProtoReflect.Descriptor instead.
 func (*GetByIDRequest) Descriptor() ([]byte, []int) {
-	return file_payment_proto_rawDescGZIP(), []int{3}
+	return file_payment_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *GetByIDRequest) GetId() string {
@@ -255,7 +633,7 @@ type Empty struct {
 
 func (x *Empty) Reset() {
 	*x = Empty{}
-	mi := &file_payment_proto_msgTypes[4]
+	mi := &file_payment_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -267,7 +645,7 @@ func (x *Empty) String() string {
 func (*Empty) ProtoMessage() {}
 
 func (x *Empty) ProtoReflect() protoreflect.Message {
-	mi := &file_payment_proto_msgTypes[4]
+	mi := &file_payment_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -280,7 +658,7 @@ func (x *Empty) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Empty.ProtoReflect.Descriptor instead.
 func (*Empty) Descriptor() ([]byte, []int) {
-	return file_payment_proto_rawDescGZIP(), []int{4}
+	return file_payment_proto_rawDescGZIP(), []int{7}
 }
 
 // ==== Response ====
@@ -293,7 +671,7 @@ type PaymentList struct {
 
 func (x *PaymentList) Reset() {
 	*x = PaymentList{}
-	mi := &file_payment_proto_msgTypes[5]
+	mi := &file_payment_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -305,7 +683,7 @@ func (x *PaymentList) String() string {
 func (*PaymentList) ProtoMessage() {}
 
 func (x *PaymentList) ProtoReflect() protoreflect.Message {
-	mi := &file_payment_proto_msgTypes[5]
+	mi := &file_payment_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -318,7 +696,7 @@ func (x *PaymentList) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PaymentList.ProtoReflect.Descriptor instead.
 func (*PaymentList) Descriptor() ([]byte, []int) {
-	return file_payment_proto_rawDescGZIP(), []int{5}
+	return file_payment_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *PaymentList) GetPayments() []*Payment {
@@ -328,35 +706,179 @@ func (x *PaymentList) GetPayments() []*Payment {
 	return nil
 }
 
+type RefundResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Refund        *Refund                `protobuf:"bytes,1,opt,name=refund,proto3" json:"refund,omitempty"`
+	Payment       *Payment               `protobuf:"bytes,2,opt,name=payment,proto3" json:"payment,omitempty"` // after the refund
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RefundResult) Reset() {
+	*x = RefundResult{}
+	mi := &file_payment_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefundResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefundResult) ProtoMessage() {}
+
+func (x *RefundResult) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefundResult.ProtoReflect.Descriptor instead.
+func (*RefundResult) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *RefundResult) GetRefund() *Refund {
+	if x != nil {
+		return x.Refund
+	}
+	return nil
+}
+
+func (x *RefundResult) GetPayment() *Payment {
+	if x != nil {
+		return x.Payment
+	}
+	return nil
+}
+
+type RefundList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Refunds       []*Refund              `protobuf:"bytes,1,rep,name=refunds,proto3" json:"refunds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RefundList) Reset() {
+	*x = RefundList{}
+	mi := &file_payment_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefundList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefundList) ProtoMessage() {}
+
+func (x *RefundList) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefundList.ProtoReflect.Descriptor instead.
+func (*RefundList) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *RefundList) GetRefunds() []*Refund {
+	if x != nil {
+		return x.Refunds
+	}
+	return nil
+}
+
 var File_payment_proto protoreflect.FileDescriptor
 
 const file_payment_proto_rawDesc = "" +
 	"\n" +
-	"\rpayment.proto\x12\apayment\"~\n" +
+	"\rpayment.proto\x12\apayment\x1a\x1cgoogle/api/annotations.proto\"\xa0\x01\n" +
+	"\fStatusChange\x12*\n" +
+	"\x04from\x18\x01 \x01(\x0e2\x16.payment.PaymentStatusR\x04from\x12&\n" +
+	"\x02to\x18\x02 \x01(\x0e2\x16.payment.PaymentStatusR\x02to\x12\x0e\n" +
+	"\x02at\x18\x03 \x01(\tR\x02at\x12\x14\n" +
+	"\x05actor\x18\x04 \x01(\tR\x05actor\x12\x16\n" +
+	"\x06reason\x18\x05 \x01(\tR\x06reason\"\xd0\x02\n" +
 	"\aPayment\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
-	"\x05email\x18\x02 \x01(\tR\x05email\x12\x16\n" +
-	"\x06amount\x18\x03 \x01(\x01R\x06amount\x12\x16\n" +
-	"\x06status\x18\x04 \x01(\tR\x06status\x12\x1d\n" +
+	"\x05email\x18\x02 \x01(\tR\x05email\x12!\n" +
+	"\famount_minor\x18\t \x01(\x03R\vamountMinor\x12\x1a\n" +
+	"\bcurrency\x18\n" +
+	" \x01(\tR\bcurrency\x12.\n" +
+	"\x06status\x18\x06 \x01(\x0e2\x16.payment.PaymentStatusR\x06status\x12<\n" +
+	"\x0estatus_history\x18\a \x03(\v2\x15.payment.StatusChangeR\rstatusHistory\x12%\n" +
+	"\x0erefunded_minor\x18\v \x01(\x03R\rrefundedMinor\x12\x1a\n" +
+	"\bprovider\x18\f \x01(\tR\bprovider\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\tR\tcreatedAtJ\x04\b\x03\x10\x04J\x04\b\b\x10\tJ\x04\b\x04\x10\x05\"\xc9\x01\n" +
+	"\x06Refund\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1d\n" +
 	"\n" +
-	"created_at\x18\x05 \x01(\tR\tcreatedAt\"D\n" +
+	"payment_id\x18\x02 \x01(\tR\tpaymentId\x12!\n" +
+	"\famount_minor\x18\a \x01(\x03R\vamountMinor\x12\x1a\n" +
+	"\bcurrency\x18\b \x01(\tR\bcurrency\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\x12\x14\n" +
+	"\x05actor\x18\x05 \x01(\tR\x05actor\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\tR\tcreatedAtJ\x04\b\x03\x10\x04\"\xa1\x01\n" +
 	"\x14CreatePaymentRequest\x12\x14\n" +
-	"\x05email\x18\x01 \x01(\tR\x05email\x12\x16\n" +
-	"\x06amount\x18\x02 \x01(\x01R\x06amount\">\n" +
+	"\x05email\x18\x01 \x01(\tR\x05email\x12!\n" +
+	"\famount_minor\x18\x03 \x01(\x03R\vamountMinor\x12\x1a\n" +
+	"\bcurrency\x18\x04 \x01(\tR\bcurrency\x12\x12\n" +
+	"\x04card\x18\x05 \x01(\tR\x04card\x12\x1a\n" +
+	"\bprovider\x18\x06 \x01(\tR\bproviderJ\x04\b\x02\x10\x03\"r\n" +
 	"\x14UpdatePaymentRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
-	"\x06status\x18\x02 \x01(\tR\x06status\" \n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12.\n" +
+	"\x06status\x18\x03 \x01(\x0e2\x16.payment.PaymentStatusR\x06status\x12\x14\n" +
+	"\x05actor\x18\x04 \x01(\tR\x05actorJ\x04\b\x02\x10\x03\"\x92\x01\n" +
+	"\rRefundRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12!\n" +
+	"\famount_minor\x18\x05 \x01(\x03R\vamountMinor\x12\x1a\n" +
+	"\bcurrency\x18\x06 \x01(\tR\bcurrency\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\x12\x14\n" +
+	"\x05actor\x18\x04 \x01(\tR\x05actorJ\x04\b\x02\x10\x03\" \n" +
 	"\x0eGetByIDRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\"\a\n" +
 	"\x05Empty\";\n" +
 	"\vPaymentList\x12,\n" +
-	"\bpayments\x18\x01 \x03(\v2\x10.payment.PaymentR\bpayments2\xc3\x02\n" +
-	"\x0ePaymentService\x12@\n" +
-	"\rCreatePayment\x12\x1d.payment.CreatePaymentRequest\x1a\x10.payment.Payment\x126\n" +
-	"\x0eGetAllPayments\x12\x0e.payment.Empty\x1a\x14.payment.PaymentList\x12;\n" +
-	"\x0eGetPaymentByID\x12\x17.payment.GetByIDRequest\x1a\x10.payment.Payment\x12@\n" +
-	"\rUpdatePayment\x12\x1d.payment.UpdatePaymentRequest\x1a\x10.payment.Payment\x128\n" +
-	"\rDeletePayment\x12\x17.payment.GetByIDRequest\x1a\x0e.payment.EmptyB\n" +
+	"\bpayments\x18\x01 \x03(\v2\x10.payment.PaymentR\bpayments\"c\n" +
+	"\fRefundResult\x12'\n" +
+	"\x06refund\x18\x01 \x01(\v2\x0f.payment.RefundR\x06refund\x12*\n" +
+	"\apayment\x18\x02 \x01(\v2\x10.payment.PaymentR\apayment\"7\n" +
+	"\n" +
+	"RefundList\x12)\n" +
+	"\arefunds\x18\x01 \x03(\v2\x0f.payment.RefundR\arefunds*\x84\x02\n" +
+	"\rPaymentStatus\x12\x1e\n" +
+	"\x1aPAYMENT_STATUS_UNSPECIFIED\x10\x00\x12\x1a\n" +
+	"\x16PAYMENT_STATUS_PENDING\x10\x01\x12\x1d\n" +
+	"\x19PAYMENT_STATUS_AUTHORIZED\x10\x02\x12\x1b\n" +
+	"\x17PAYMENT_STATUS_CAPTURED\x10\x03\x12\x19\n" +
+	"\x15PAYMENT_STATUS_FAILED\x10\x04\x12\x1c\n" +
+	"\x18PAYMENT_STATUS_CANCELLED\x10\x05\x12\x1b\n" +
+	"\x17PAYMENT_STATUS_REFUNDED\x10\x06\x12%\n" +
+	"!PAYMENT_STATUS_PARTIALLY_REFUNDED\x10\a2\xaf\x05\n" +
+	"\x0ePaymentService\x12_\n" +
+	"\rCreatePayment\x12\x1d.payment.CreatePaymentRequest\x1a\x10.payment.Payment\"\x1d\x82\xd3\xe4\x93\x02\x17:\x01*\"\x12/api/payments-grpc\x12R\n" +
+	"\x0eGetAllPayments\x12\x0e.payment.Empty\x1a\x14.payment.PaymentList\"\x1a\x82\xd3\xe4\x93\x02\x14\x12\x12/api/payments-grpc\x12\\\n" +
+	"\x0eGetPaymentByID\x12\x17.payment.GetByIDRequest\x1a\x10.payment.Payment\"\x1f\x82\xd3\xe4\x93\x02\x19\x12\x17/api/payments-grpc/{id}\x12d\n" +
+	"\rUpdatePayment\x12\x1d.payment.UpdatePaymentRequest\x1a\x10.payment.Payment\"\"\x82\xd3\xe4\x93\x02\x1c:\x01*\x1a\x17/api/payments-grpc/{id}\x12Y\n" +
+	"\rDeletePayment\x12\x17.payment.GetByIDRequest\x1a\x0e.payment.Empty\"\x1f\x82\xd3\xe4\x93\x02\x19*\x17/api/payments-grpc/{id}\x12c\n" +
+	"\x06Refund\x12\x16.payment.RefundRequest\x1a\x15.payment.RefundResult\"*\x82\xd3\xe4\x93\x02$:\x01*\"\x1f/api/payments-grpc/{id}/refunds\x12d\n" +
+	"\vListRefunds\x12\x17.payment.GetByIDRequest\x1a\x13.payment.RefundList\"'\x82\xd3\xe4\x93\x02!\x12\x1f/api/payments-grpc/{id}/refundsB\n" +
 	"Z\b../pb;pbb\x06proto3"
 
 var (
@@ -371,32 +893,51 @@ func file_payment_proto_rawDescGZIP() []byte {
 	return file_payment_proto_rawDescData
 }
 
-var file_payment_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_payment_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_payment_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
 var file_payment_proto_goTypes = []any{
-	(*Payment)(nil),              // 0: payment.Payment
-	(*CreatePaymentRequest)(nil), // 1: payment.CreatePaymentRequest
-	(*UpdatePaymentRequest)(nil), // 2: payment.UpdatePaymentRequest
-	(*GetByIDRequest)(nil),       // 3: payment.GetByIDRequest
-	(*Empty)(nil),                // 4: payment.Empty
-	(*PaymentList)(nil),          // 5: payment.PaymentList
+	(PaymentStatus)(0),           // 0: payment.PaymentStatus
+	(*StatusChange)(nil),         // 1: payment.StatusChange
+	(*Payment)(nil),              // 2: payment.Payment
+	(*Refund)(nil),               // 3: payment.Refund
+	(*CreatePaymentRequest)(nil), // 4: payment.CreatePaymentRequest
+	(*UpdatePaymentRequest)(nil), // 5: payment.UpdatePaymentRequest
+	(*RefundRequest)(nil),        // 6: payment.RefundRequest
+	(*GetByIDRequest)(nil),       // 7: payment.GetByIDRequest
+	(*Empty)(nil),                // 8: payment.Empty
+	(*PaymentList)(nil),          // 9: payment.PaymentList
+	(*RefundResult)(nil),         // 10: payment.RefundResult
+	(*RefundList)(nil),           // 11: payment.RefundList
 }
 var file_payment_proto_depIdxs = []int32{
-	0, // 0: payment.PaymentList.payments:type_name -> payment.Payment
-	1, // 1: payment.PaymentService.CreatePayment:input_type -> payment.CreatePaymentRequest
-	4, // 2: payment.PaymentService.GetAllPayments:input_type -> payment.Empty
-	3, // 3: payment.PaymentService.GetPaymentByID:input_type -> payment.GetByIDRequest
-	2, // 4: payment.PaymentService.UpdatePayment:input_type -> payment.UpdatePaymentRequest
-	3, // 5: payment.PaymentService.DeletePayment:input_type -> payment.GetByIDRequest
-	0, // 6: payment.PaymentService.CreatePayment:output_type -> payment.Payment
-	5, // 7: payment.PaymentService.GetAllPayments:output_type -> payment.PaymentList
-	0, // 8: payment.PaymentService.GetPaymentByID:output_type -> payment.Payment
-	0, // 9: payment.PaymentService.UpdatePayment:output_type -> payment.Payment
-	4, // 10: payment.PaymentService.DeletePayment:output_type -> payment.Empty
-	6, // [6:11] is the sub-list for method output_type
-	1, // [1:6] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	0,  // 0: payment.StatusChange.from:type_name -> payment.PaymentStatus
+	0,  // 1: payment.StatusChange.to:type_name -> payment.PaymentStatus
+	0,  // 2: payment.Payment.status:type_name -> payment.PaymentStatus
+	1,  // 3: payment.Payment.status_history:type_name -> payment.StatusChange
+	0,  // 4: payment.UpdatePaymentRequest.status:type_name -> payment.PaymentStatus
+	2,  // 5: payment.PaymentList.payments:type_name -> payment.Payment
+	3,  // 6: payment.RefundResult.refund:type_name -> payment.Refund
+	2,  // 7: payment.RefundResult.payment:type_name -> payment.Payment
+	3,  // 8: payment.RefundList.refunds:type_name -> payment.Refund
+	4,  // 9: payment.PaymentService.CreatePayment:input_type -> payment.CreatePaymentRequest
+	8,  // 10: payment.PaymentService.GetAllPayments:input_type -> payment.Empty
+	7,  // 11: payment.PaymentService.GetPaymentByID:input_type -> payment.GetByIDRequest
+	5,  // 12: payment.PaymentService.UpdatePayment:input_type -> payment.UpdatePaymentRequest
+	7,  // 13: payment.PaymentService.DeletePayment:input_type -> payment.GetByIDRequest
+	6,  // 14: payment.PaymentService.Refund:input_type -> payment.RefundRequest
+	7,  // 15: payment.PaymentService.ListRefunds:input_type -> payment.GetByIDRequest
+	2,  // 16: payment.PaymentService.CreatePayment:output_type -> payment.Payment
+	9,  // 17: payment.PaymentService.GetAllPayments:output_type -> payment.PaymentList
+	2,  // 18: payment.PaymentService.GetPaymentByID:output_type -> payment.Payment
+	2,  // 19: payment.PaymentService.UpdatePayment:output_type -> payment.Payment
+	8,  // 20: payment.PaymentService.DeletePayment:output_type -> payment.Empty
+	10, // 21: payment.PaymentService.Refund:output_type -> payment.RefundResult
+	11, // 22: payment.PaymentService.ListRefunds:output_type -> payment.RefundList
+	16, // [16:23] is the sub-list for method output_type
+	9,  // [9:16] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
 }
 
 func init() { file_payment_proto_init() }
@@ -409,13 +950,14 @@ func file_payment_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_payment_proto_rawDesc), len(file_payment_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   6,
+			NumEnums:      1,
+			NumMessages:   11,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_payment_proto_goTypes,
 		DependencyIndexes: file_payment_proto_depIdxs,
+		EnumInfos:         file_payment_proto_enumTypes,
 		MessageInfos:      file_payment_proto_msgTypes,
 	}.Build()
 	File_payment_proto = out.File
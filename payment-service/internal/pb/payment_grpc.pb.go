@@ -24,6 +24,8 @@ const (
 	PaymentService_GetPaymentByID_FullMethodName = "/payment.PaymentService/GetPaymentByID"
 	PaymentService_UpdatePayment_FullMethodName  = "/payment.PaymentService/UpdatePayment"
 	PaymentService_DeletePayment_FullMethodName  = "/payment.PaymentService/DeletePayment"
+	PaymentService_Refund_FullMethodName         = "/payment.PaymentService/Refund"
+	PaymentService_ListRefunds_FullMethodName    = "/payment.PaymentService/ListRefunds"
 )
 
 // PaymentServiceClient is the client API for PaymentService service.
@@ -32,11 +34,20 @@ const (
 //
 // ==== Service ====
 type PaymentServiceClient interface {
+	// Creates a payment and charges the card with its provider. A declined
+	// payment is created FAILED; one the provider hasn't settled yet is
+	// PENDING or AUTHORIZED.
 	CreatePayment(ctx context.Context, in *CreatePaymentRequest, opts ...grpc.CallOption) (*Payment, error)
 	GetAllPayments(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*PaymentList, error)
 	GetPaymentByID(ctx context.Context, in *GetByIDRequest, opts ...grpc.CallOption) (*Payment, error)
+	// Sets the status of the payment with the id in the path. Payments with
+	// a provider can only be CAPTURED or CANCELLED.
 	UpdatePayment(ctx context.Context, in *UpdatePaymentRequest, opts ...grpc.CallOption) (*Payment, error)
 	DeletePayment(ctx context.Context, in *GetByIDRequest, opts ...grpc.CallOption) (*Empty, error)
+	// Refunds all or part of a captured payment, moving it to
+	// PARTIALLY_REFUNDED or REFUNDED.
+	Refund(ctx context.Context, in *RefundRequest, opts ...grpc.CallOption) (*RefundResult, error)
+	ListRefunds(ctx context.Context, in *GetByIDRequest, opts ...grpc.CallOption) (*RefundList, error)
 }
 
 type paymentServiceClient struct {
@@ -97,17 +108,46 @@ func (c *paymentServiceClient) DeletePayment(ctx context.Context, in *GetByIDReq
 	return out, nil
 }
 
+func (c *paymentServiceClient) Refund(ctx context.Context, in *RefundRequest, opts ...grpc.CallOption) (*RefundResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RefundResult)
+	err := c.cc.Invoke(ctx, PaymentService_Refund_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) ListRefunds(ctx context.Context, in *GetByIDRequest, opts ...grpc.CallOption) (*RefundList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RefundList)
+	err := c.cc.Invoke(ctx, PaymentService_ListRefunds_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PaymentServiceServer is the server API for PaymentService service.
 // All implementations must embed UnimplementedPaymentServiceServer
 // for forward compatibility.
 //
 // ==== Service ====
 type PaymentServiceServer interface {
+	// Creates a payment and charges the card with its provider. A declined
+	// payment is created FAILED; one the provider hasn't settled yet is
+	// PENDING or AUTHORIZED.
 	CreatePayment(context.Context, *CreatePaymentRequest) (*Payment, error)
 	GetAllPayments(context.Context, *Empty) (*PaymentList, error)
 	GetPaymentByID(context.Context, *GetByIDRequest) (*Payment, error)
+	// Sets the status of the payment with the id in the path. Payments with
+	// a provider can only be CAPTURED or CANCELLED.
 	UpdatePayment(context.Context, *UpdatePaymentRequest) (*Payment, error)
 	DeletePayment(context.Context, *GetByIDRequest) (*Empty, error)
+	// Refunds all or part of a captured payment, moving it to
+	// PARTIALLY_REFUNDED or REFUNDED.
+	Refund(context.Context, *RefundRequest) (*RefundResult, error)
+	ListRefunds(context.Context, *GetByIDRequest) (*RefundList, error)
 	mustEmbedUnimplementedPaymentServiceServer()
 }
 
@@ -133,6 +173,12 @@ func (UnimplementedPaymentServiceServer) UpdatePayment(context.Context, *UpdateP
 func (UnimplementedPaymentServiceServer) DeletePayment(context.Context, *GetByIDRequest) (*Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeletePayment not implemented")
 }
+func (UnimplementedPaymentServiceServer) Refund(context.Context, *RefundRequest) (*RefundResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Refund not implemented")
+}
+func (UnimplementedPaymentServiceServer) ListRefunds(context.Context, *GetByIDRequest) (*RefundList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRefunds not implemented")
+}
 func (UnimplementedPaymentServiceServer) mustEmbedUnimplementedPaymentServiceServer() {}
 func (UnimplementedPaymentServiceServer) testEmbeddedByValue()                        {}
 
@@ -244,6 +290,42 @@ func _PaymentService_DeletePayment_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PaymentService_Refund_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefundRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).Refund(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_Refund_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).Refund(ctx, req.(*RefundRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_ListRefunds_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetByIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).ListRefunds(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_ListRefunds_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).ListRefunds(ctx, req.(*GetByIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // PaymentService_ServiceDesc is the grpc.ServiceDesc for PaymentService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -271,6 +353,14 @@ var PaymentService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeletePayment",
 			Handler:    _PaymentService_DeletePayment_Handler,
 		},
+		{
+			MethodName: "Refund",
+			Handler:    _PaymentService_Refund_Handler,
+		},
+		{
+			MethodName: "ListRefunds",
+			Handler:    _PaymentService_ListRefunds_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "payment.proto",
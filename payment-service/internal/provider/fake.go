@@ -0,0 +1,274 @@
+package provider
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"payment-service/internal/domain"
+	"payment-service/internal/money"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Outcome is how the fake answers an authorization.
+type Outcome string
+
+const (
+	OutcomeApprove Outcome = "approve"
+	OutcomeDecline Outcome = "decline"
+	// OutcomeTimeout approves, but the answer never comes: the call waits
+	// for its context to end and fails with ErrTimeout.
+	OutcomeTimeout Outcome = "timeout"
+	// OutcomeAsync leaves the authorization pending, and the capture
+	// settling, for SettleAfter each; both are approved.
+	OutcomeAsync Outcome = "async"
+)
+
+// FakeRule decides the outcome of the authorizations it matches: those with
+// its card, of at least its amount, or both. A rule with neither matches
+// every authorization.
+type FakeRule struct {
+	Card string `yaml:"card"`
+	// MinAmountMinor matches amounts from it up, in Currency when it is set.
+	MinAmountMinor int64   `yaml:"min_amount_minor"`
+	Currency       string  `yaml:"currency"`
+	Outcome        Outcome `yaml:"outcome"`
+	// Reason is the failure code of a decline, card_declined when empty.
+	Reason string `yaml:"reason"`
+}
+
+// FakeConfig is the fake's rules, tried in order; the first that matches
+// decides and an authorization none matches is approved.
+type FakeConfig struct {
+	Rules       []FakeRule    `yaml:"rules"`
+	SettleAfter time.Duration `yaml:"settle_after"`
+}
+
+// DefaultFakeConfig declines, times out or settles later the magic card
+// numbers below; all else is approved.
+func DefaultFakeConfig() FakeConfig {
+	return FakeConfig{
+		Rules: []FakeRule{
+			{Card: "4000000000000002", Outcome: OutcomeDecline, Reason: "card_declined"},
+			{Card: "4000000000009995", Outcome: OutcomeDecline, Reason: "insufficient_funds"},
+			{Card: "4000000000000119", Outcome: OutcomeTimeout},
+			{Card: "4000000000003220", Outcome: OutcomeAsync},
+		},
+		SettleAfter: 10 * time.Second,
+	}
+}
+
+// LoadFakeConfig reads the YAML (or JSON) rules file at path.
+func LoadFakeConfig(path string) (FakeConfig, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return FakeConfig{}, err
+	}
+	var cfg FakeConfig
+	if err := yaml.Unmarshal(raw, &cfg); err != nil {
+		return FakeConfig{}, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	for i, rule := range cfg.Rules {
+		switch rule.Outcome {
+		case OutcomeApprove, OutcomeDecline, OutcomeTimeout, OutcomeAsync:
+		default:
+			return FakeConfig{}, fmt.Errorf("%s: rule %d: unknown outcome %q", filepath.Base(path), i+1, rule.Outcome)
+		}
+	}
+	return cfg, nil
+}
+
+func (r FakeRule) matches(charge Charge) bool {
+	if r.Card != "" && r.Card != charge.Card {
+		return false
+	}
+	if r.MinAmountMinor > 0 {
+		if r.Currency != "" && money.Currency(r.Currency) != charge.Amount.Currency {
+			return false
+		}
+		if charge.Amount.AmountMinor < r.MinAmountMinor {
+			return false
+		}
+	}
+	return true
+}
+
+// Fake is a provider that decides payments by its rules. The payments it
+// knows are those in its store.
+type Fake struct {
+	name   string
+	config FakeConfig
+	store  FakeStore
+	now    func() time.Time
+}
+
+// fakeUpdateAttempts bounds the retries of a change to a payment that other
+// processes changed at the same time.
+const fakeUpdateAttempts = 5
+
+func NewFake(name string, config FakeConfig, store FakeStore) *Fake {
+	return &Fake{name: name, config: config, store: store, now: time.Now}
+}
+
+func (f *Fake) Name() string { return f.name }
+
+func (f *Fake) Authorize(ctx context.Context, charge Charge) (Result, error) {
+	// Retried: where the first authorization got to
+	if p, err := f.payment(ctx, charge.ID); err == nil {
+		return p.result(), nil
+	} else if !errors.Is(err, ErrUnknownPayment) {
+		return Result{}, err
+	}
+
+	outcome := OutcomeApprove
+	var reason string
+	for _, rule := range f.config.Rules {
+		if rule.matches(charge) {
+			outcome, reason = rule.Outcome, rule.Reason
+			break
+		}
+	}
+	p := &FakePayment{
+		ID:       charge.ID,
+		Amount:   charge.Amount,
+		Status:   domain.StatusAuthorized,
+		Refunded: money.New(0, charge.Amount.Currency),
+	}
+	switch outcome {
+	case OutcomeDecline:
+		p.Status, p.Reason = domain.StatusFailed, cmp.Or(reason, "card_declined")
+	case OutcomeAsync:
+		p.Status, p.Async = domain.StatusPending, true
+		p.SettleAt = f.now().Add(f.config.SettleAfter)
+	}
+	if err := f.store.Create(ctx, p); errors.Is(err, ErrFakeConflict) {
+		// Authorized at the same time through another process
+		if p, err = f.payment(ctx, charge.ID); err != nil {
+			return Result{}, err
+		}
+	} else if err != nil {
+		return Result{}, err
+	}
+
+	if outcome == OutcomeTimeout {
+		<-ctx.Done()
+		return Result{}, fmt.Errorf("%w: %v", ErrTimeout, ctx.Err())
+	}
+	return p.result(), nil
+}
+
+func (f *Fake) Capture(ctx context.Context, id string) (Result, error) {
+	p, err := f.update(ctx, id, func(p *FakePayment) error {
+		if p.Status != domain.StatusAuthorized {
+			return fmt.Errorf("%w: capture of a %s payment", ErrRejected, p.Status)
+		}
+		switch {
+		case p.Capturing:
+		case p.Async:
+			p.Capturing = true
+			p.SettleAt = f.now().Add(f.config.SettleAfter)
+		default:
+			p.Status = domain.StatusCaptured
+		}
+		return nil
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return p.result(), nil
+}
+
+func (f *Fake) Void(ctx context.Context, id string) (Result, error) {
+	p, err := f.update(ctx, id, func(p *FakePayment) error {
+		if (p.Status != domain.StatusPending && p.Status != domain.StatusAuthorized) || p.Capturing {
+			return fmt.Errorf("%w: void of a %s payment", ErrRejected, p.Status)
+		}
+		p.Status = domain.StatusCancelled
+		return nil
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return p.result(), nil
+}
+
+func (f *Fake) Refund(ctx context.Context, id, refundID string, amount money.Money) error {
+	_, err := f.update(ctx, id, func(p *FakePayment) error {
+		if slices.Contains(p.Refunds, refundID) {
+			return nil
+		}
+		if p.Status != domain.StatusCaptured {
+			return fmt.Errorf("%w: refund of a %s payment", ErrRejected, p.Status)
+		}
+		refunded, err := p.Refunded.Add(amount)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrRejected, err)
+		}
+		if over, _ := refunded.Cmp(p.Amount); over > 0 || !amount.IsPositive() {
+			return fmt.Errorf("%w: refund of %s, %s refunded of %s", ErrRejected, amount, p.Refunded, p.Amount)
+		}
+		p.Refunded = refunded
+		p.Refunds = append(p.Refunds, refundID)
+		return nil
+	})
+	return err
+}
+
+func (f *Fake) Status(ctx context.Context, id string) (Result, error) {
+	p, err := f.payment(ctx, id)
+	if err != nil {
+		return Result{}, err
+	}
+	return p.result(), nil
+}
+
+// payment returns payment id, settled up to now.
+func (f *Fake) payment(ctx context.Context, id string) (*FakePayment, error) {
+	p, err := f.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !p.SettleAt.IsZero() && !f.now().Before(p.SettleAt) {
+		p.SettleAt = time.Time{}
+		switch {
+		case p.Status == domain.StatusPending:
+			p.Status = domain.StatusAuthorized
+		case p.Capturing:
+			p.Status, p.Capturing = domain.StatusCaptured, false
+		}
+	}
+	return p, nil
+}
+
+// update applies change to payment id, settled up to now, and stores it;
+// again on a fresh copy when another process changed it in the meantime.
+func (f *Fake) update(ctx context.Context, id string, change func(p *FakePayment) error) (*FakePayment, error) {
+	for attempt := 1; ; attempt++ {
+		p, err := f.payment(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := change(p); err != nil {
+			return nil, err
+		}
+		p.Version++
+		err = f.store.Update(ctx, p)
+		if errors.Is(err, ErrFakeConflict) && attempt < fakeUpdateAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+}
+
+func (p *FakePayment) result() Result {
+	return Result{Status: p.Status, Reason: p.Reason}
+}
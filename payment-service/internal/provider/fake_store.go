@@ -0,0 +1,96 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"payment-service/internal/domain"
+	"payment-service/internal/money"
+)
+
+// ErrFakeConflict means a fake payment changed since it was read.
+var ErrFakeConflict = errors.New("fake payment changed concurrently")
+
+// FakePayment is a payment as the fake knows it.
+type FakePayment struct {
+	ID        string               `bson:"_id"`
+	Amount    money.Money          `bson:"amount"`
+	Status    domain.PaymentStatus `bson:"status"`
+	Reason    string               `bson:"reason,omitempty"`
+	Async     bool                 `bson:"async"`
+	Capturing bool                 `bson:"capturing"`
+	SettleAt  time.Time            `bson:"settle_at,omitempty"` // of the pending authorization or the capture
+	Refunded  money.Money          `bson:"refunded"`
+	Refunds   []string             `bson:"refunds"` // IDs of the refunds made
+	// Version counts the changes made, for Update to tell when another
+	// process made one first.
+	Version int64 `bson:"version"`
+}
+
+// FakeStore keeps the fake's payments. One shared by every process of the
+// service (see infra.NewMongoFakeStore) lets a payment authorized through
+// one be captured, voided or refunded through another, or after a restart.
+type FakeStore interface {
+	// Get returns payment id, failing with ErrUnknownPayment when there is
+	// none.
+	Get(ctx context.Context, id string) (*FakePayment, error)
+	// Create stores a new payment. It fails with ErrFakeConflict when one
+	// with its ID exists.
+	Create(ctx context.Context, p *FakePayment) error
+	// Update stores p over the copy of version p.Version-1. It fails with
+	// ErrFakeConflict when the stored copy is no longer that one.
+	Update(ctx context.Context, p *FakePayment) error
+}
+
+// MemoryFakeStore is a FakeStore for a single process: what it knows is
+// lost on restart.
+type MemoryFakeStore struct {
+	mu       sync.Mutex
+	payments map[string]FakePayment
+}
+
+func NewMemoryFakeStore() *MemoryFakeStore {
+	return &MemoryFakeStore{payments: make(map[string]FakePayment)}
+}
+
+func (s *MemoryFakeStore) Get(_ context.Context, id string) (*FakePayment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.payments[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPayment, id)
+	}
+	p.Refunds = slices.Clone(p.Refunds)
+	return &p, nil
+}
+
+func (s *MemoryFakeStore) Create(_ context.Context, p *FakePayment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.payments[p.ID]; ok {
+		return ErrFakeConflict
+	}
+	stored := *p
+	stored.Refunds = slices.Clone(p.Refunds)
+	s.payments[p.ID] = stored
+	return nil
+}
+
+func (s *MemoryFakeStore) Update(_ context.Context, p *FakePayment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if current, ok := s.payments[p.ID]; !ok || current.Version != p.Version-1 {
+		return ErrFakeConflict
+	}
+	stored := *p
+	stored.Refunds = slices.Clone(p.Refunds)
+	s.payments[p.ID] = stored
+	return nil
+}
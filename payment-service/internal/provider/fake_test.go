@@ -0,0 +1,219 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"payment-service/internal/domain"
+	"payment-service/internal/money"
+)
+
+func charge(id, card string, amountMinor int64) Charge {
+	return Charge{ID: id, Amount: money.New(amountMinor, "IDR"), Email: "a@b.c", Card: card}
+}
+
+func TestFakeRules(t *testing.T) {
+	config := DefaultFakeConfig()
+	config.Rules = append(config.Rules, FakeRule{MinAmountMinor: 1_000_000, Currency: "IDR", Outcome: OutcomeDecline, Reason: "amount_limit"})
+	fake := NewFake("fake", config, NewMemoryFakeStore())
+	ctx := context.Background()
+
+	cases := []struct {
+		charge Charge
+		want   Result
+	}{
+		{charge("p1", "4242424242424242", 500), Result{Status: domain.StatusAuthorized}},
+		{charge("p2", "4000000000000002", 500), Result{Status: domain.StatusFailed, Reason: "card_declined"}},
+		{charge("p3", "4000000000009995", 500), Result{Status: domain.StatusFailed, Reason: "insufficient_funds"}},
+		{charge("p4", "4242424242424242", 1_000_000), Result{Status: domain.StatusFailed, Reason: "amount_limit"}},
+		{Charge{ID: "p5", Amount: money.New(1_000_000, "USD")}, Result{Status: domain.StatusAuthorized}},
+	}
+	for _, tc := range cases {
+		got, err := fake.Authorize(ctx, tc.charge)
+		if err != nil || got != tc.want {
+			t.Errorf("%s: got %+v, %v, want %+v", tc.charge.ID, got, err, tc.want)
+		}
+	}
+
+	// A retry gets the first answer, whatever the card
+	if got, _ := fake.Authorize(ctx, charge("p2", "4242424242424242", 500)); got.Status != domain.StatusFailed {
+		t.Errorf("retried authorization: %+v", got)
+	}
+	if _, err := fake.Capture(ctx, "p2"); !errors.Is(err, ErrRejected) {
+		t.Errorf("capture of a declined payment: %v", err)
+	}
+	if got, err := fake.Capture(ctx, "p1"); err != nil || got.Status != domain.StatusCaptured {
+		t.Errorf("capture: %+v, %v", got, err)
+	}
+	if _, err := fake.Status(ctx, "nope"); !errors.Is(err, ErrUnknownPayment) {
+		t.Errorf("status of an unknown payment: %v", err)
+	}
+}
+
+func TestFakeTimeout(t *testing.T) {
+	fake := NewFake("fake", DefaultFakeConfig(), NewMemoryFakeStore())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := fake.Authorize(ctx, charge("p1", "4000000000000119", 500)); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+	// The answer was lost, not the authorization
+	if got, err := fake.Status(context.Background(), "p1"); err != nil || got.Status != domain.StatusAuthorized {
+		t.Errorf("status after the timeout: %+v, %v", got, err)
+	}
+}
+
+func TestFakeAsyncSettlement(t *testing.T) {
+	fake := NewFake("fake", DefaultFakeConfig(), NewMemoryFakeStore())
+	now := time.Now()
+	fake.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	status := func() domain.PaymentStatus {
+		t.Helper()
+		got, err := fake.Status(ctx, "p1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got.Status
+	}
+
+	got, _ := fake.Authorize(ctx, charge("p1", "4000000000003220", 500))
+	if got.Status != domain.StatusPending {
+		t.Fatalf("authorization: %+v", got)
+	}
+	now = now.Add(10 * time.Second)
+	if s := status(); s != domain.StatusAuthorized {
+		t.Fatalf("after settling: %s", s)
+	}
+
+	if got, _ := fake.Capture(ctx, "p1"); got.Status != domain.StatusAuthorized {
+		t.Fatalf("capture: %+v", got)
+	}
+	if _, err := fake.Void(ctx, "p1"); !errors.Is(err, ErrRejected) {
+		t.Errorf("void while capturing: %v", err)
+	}
+	now = now.Add(5 * time.Second)
+	if s := status(); s != domain.StatusAuthorized {
+		t.Errorf("half-way through the capture: %s", s)
+	}
+	now = now.Add(5 * time.Second)
+	if s := status(); s != domain.StatusCaptured {
+		t.Errorf("after the capture settled: %s", s)
+	}
+}
+
+func TestFakeVoidAndRefund(t *testing.T) {
+	fake := NewFake("fake", FakeConfig{}, NewMemoryFakeStore())
+	ctx := context.Background()
+
+	fake.Authorize(ctx, charge("voided", "", 500))
+	if got, err := fake.Void(ctx, "voided"); err != nil || got.Status != domain.StatusCancelled {
+		t.Errorf("void: %+v, %v", got, err)
+	}
+	if err := fake.Refund(ctx, "voided", "r0", money.New(100, "IDR")); !errors.Is(err, ErrRejected) {
+		t.Errorf("refund of a voided payment: %v", err)
+	}
+
+	fake.Authorize(ctx, charge("p1", "", 500))
+	fake.Capture(ctx, "p1")
+	if err := fake.Refund(ctx, "p1", "r1", money.New(300, "IDR")); err != nil {
+		t.Fatal(err)
+	}
+	// Retried with its key: not refunded twice
+	if err := fake.Refund(ctx, "p1", "r1", money.New(300, "IDR")); err != nil {
+		t.Errorf("retried refund: %v", err)
+	}
+	if err := fake.Refund(ctx, "p1", "r2", money.New(300, "IDR")); !errors.Is(err, ErrRejected) {
+		t.Errorf("refund of more than is left: %v", err)
+	}
+	if err := fake.Refund(ctx, "p1", "r3", money.New(200, "USD")); !errors.Is(err, ErrRejected) {
+		t.Errorf("refund in another currency: %v", err)
+	}
+	if err := fake.Refund(ctx, "p1", "r4", money.New(200, "IDR")); err != nil {
+		t.Errorf("refund of the rest: %v", err)
+	}
+}
+
+func TestFakeSharedStore(t *testing.T) {
+	// Two processes of the service, one store
+	store := NewMemoryFakeStore()
+	httpFake, grpcFake := NewFake("fake", FakeConfig{}, store), NewFake("fake", FakeConfig{}, store)
+	ctx := context.Background()
+
+	httpFake.Authorize(ctx, charge("p1", "", 500))
+	if got, err := grpcFake.Capture(ctx, "p1"); err != nil || got.Status != domain.StatusCaptured {
+		t.Fatalf("capture through the other process: %+v, %v", got, err)
+	}
+	if err := httpFake.Refund(ctx, "p1", "r1", money.New(500, "IDR")); err != nil {
+		t.Fatalf("refund through the first: %v", err)
+	}
+	if err := grpcFake.Refund(ctx, "p1", "r2", money.New(1, "IDR")); !errors.Is(err, ErrRejected) {
+		t.Errorf("refund past the amount through the other: %v", err)
+	}
+
+	// A stale copy is not written over the one stored
+	stale, _ := store.Get(ctx, "p1")
+	stale.Version++
+	if err := store.Update(ctx, stale); err != nil {
+		t.Fatal(err)
+	}
+	stale.Refunds = nil
+	if err := store.Update(ctx, stale); !errors.Is(err, ErrFakeConflict) {
+		t.Errorf("update of a stale copy: %v", err)
+	}
+}
+
+func TestLoadFakeConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "fake.yaml")
+	os.WriteFile(path, []byte(`
+settle_after: 2s
+rules:
+  - card: "4000000000000002"
+    outcome: decline
+  - min_amount_minor: 100000
+    outcome: async
+`), 0o600)
+
+	cfg, err := LoadFakeConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.SettleAfter != 2*time.Second || len(cfg.Rules) != 2 || cfg.Rules[1].Outcome != OutcomeAsync {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+
+	os.WriteFile(path, []byte("rules: [{outcome: explode}]"), 0o600)
+	if _, err := LoadFakeConfig(path); err == nil {
+		t.Error("expected an unknown outcome to be rejected")
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	if _, err := NewRegistry("acme", NewFake("fake", FakeConfig{}, NewMemoryFakeStore())); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("unknown fallback: %v", err)
+	}
+	if _, err := NewRegistry("fake", NewFake("fake", FakeConfig{}, NewMemoryFakeStore()), NewFake("fake", FakeConfig{}, NewMemoryFakeStore())); err == nil {
+		t.Error("expected a provider registered twice to be rejected")
+	}
+
+	r, err := NewRegistry("fake", NewFake("fake", FakeConfig{}, NewMemoryFakeStore()), NewFake("other", FakeConfig{}, NewMemoryFakeStore()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Default() != "fake" {
+		t.Errorf("default %q", r.Default())
+	}
+	if p, err := r.Get("other"); err != nil || p.Name() != "other" {
+		t.Errorf("Get: %v, %v", p, err)
+	}
+	if _, err := r.Get("acme"); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("unknown provider: %v", err)
+	}
+}
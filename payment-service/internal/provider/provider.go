@@ -0,0 +1,61 @@
+// Package provider adapts the payment service providers (PSPs) that move the
+// money behind payments to one interface, PaymentProvider. A Registry holds
+// the providers configured; Fake is one that runs in-process, for working
+// offline.
+package provider
+
+import (
+	"context"
+	"errors"
+
+	"payment-service/internal/domain"
+	"payment-service/internal/money"
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown payment provider")
+	// ErrUnknownPayment means the provider has no record of the payment.
+	ErrUnknownPayment = errors.New("payment unknown to the provider")
+	// ErrRejected means the provider refused the call for the payment as it
+	// stands there, e.g. a capture before authorization.
+	ErrRejected = errors.New("payment provider rejected the request")
+	// ErrTimeout means no answer came in time. The provider may still have
+	// acted on the call; its Status tells.
+	ErrTimeout = errors.New("payment provider timed out")
+)
+
+// Charge is what a provider is asked to authorize.
+type Charge struct {
+	// ID is the payment's. It is the idempotency key of the charge, so that
+	// a retried authorization isn't made twice, and names the payment in
+	// the provider's other calls.
+	ID     string
+	Amount money.Money
+	Email  string
+	// Card is the card number. It goes to the provider and is never stored.
+	Card string
+}
+
+// Result is where a payment stands at the provider.
+type Result struct {
+	Status domain.PaymentStatus
+	// Reason is the provider's code for a failure, e.g. card_declined.
+	Reason string
+}
+
+// PaymentProvider is a PSP. Authorize answers authorized, failed when the
+// payment is declined, or pending when the provider decides later. Capture
+// answers captured, or authorized while the capture settles. Void answers
+// cancelled. Status is what the provider knows now, to follow payments it
+// left pending or settling.
+type PaymentProvider interface {
+	// Name is what payments record to find their provider again.
+	Name() string
+	Authorize(ctx context.Context, charge Charge) (Result, error)
+	Capture(ctx context.Context, id string) (Result, error)
+	Void(ctx context.Context, id string) (Result, error)
+	// Refund gives amount of the captured payment id back. refundID is the
+	// idempotency key of the refund.
+	Refund(ctx context.Context, id, refundID string, amount money.Money) error
+	Status(ctx context.Context, id string) (Result, error)
+}
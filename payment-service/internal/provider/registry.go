@@ -0,0 +1,115 @@
+package provider
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"payment-service/internal/metrics"
+	"payment-service/internal/money"
+)
+
+// Registry holds the providers payments can be made with, by name.
+type Registry struct {
+	providers map[string]PaymentProvider
+	fallback  string
+}
+
+// NewRegistry makes a registry of providers in which new payments that name
+// no provider go to fallback.
+func NewRegistry(fallback string, providers ...PaymentProvider) (*Registry, error) {
+	r := &Registry{providers: make(map[string]PaymentProvider), fallback: fallback}
+	for _, p := range providers {
+		if _, ok := r.providers[p.Name()]; ok {
+			return nil, fmt.Errorf("payment provider %q registered twice", p.Name())
+		}
+		r.providers[p.Name()] = instrumented{p}
+	}
+	if _, err := r.Get(fallback); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// FromEnv makes the registry of the providers built in, with PAYMENT_PROVIDER
+// (default fake) for the payments that name none. FAKE_PSP_CONFIG is the
+// path of the fake's rules; without it the fake uses DefaultFakeConfig. The
+// fake keeps its payments in fakeStore.
+func FromEnv(fakeStore FakeStore) (*Registry, error) {
+	fakeConfig := DefaultFakeConfig()
+	if path := os.Getenv("FAKE_PSP_CONFIG"); path != "" {
+		var err error
+		if fakeConfig, err = LoadFakeConfig(path); err != nil {
+			return nil, err
+		}
+	}
+	return NewRegistry(cmp.Or(os.Getenv("PAYMENT_PROVIDER"), "fake"), NewFake("fake", fakeConfig, fakeStore))
+}
+
+// Default is the provider of payments that name none.
+func (r *Registry) Default() string {
+	return r.fallback
+}
+
+func (r *Registry) Get(name string) (PaymentProvider, error) {
+	p, ok := r.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownProvider, name)
+	}
+	return p, nil
+}
+
+// instrumented counts the calls made to a provider by how they ended.
+type instrumented struct {
+	PaymentProvider
+}
+
+func (p instrumented) Authorize(ctx context.Context, charge Charge) (Result, error) {
+	result, err := p.PaymentProvider.Authorize(ctx, charge)
+	p.observe("authorize", result, err)
+	return result, err
+}
+
+func (p instrumented) Capture(ctx context.Context, id string) (Result, error) {
+	result, err := p.PaymentProvider.Capture(ctx, id)
+	p.observe("capture", result, err)
+	return result, err
+}
+
+func (p instrumented) Void(ctx context.Context, id string) (Result, error) {
+	result, err := p.PaymentProvider.Void(ctx, id)
+	p.observe("void", result, err)
+	return result, err
+}
+
+func (p instrumented) Refund(ctx context.Context, id, refundID string, amount money.Money) error {
+	err := p.PaymentProvider.Refund(ctx, id, refundID, amount)
+	p.observe("refund", Result{}, err)
+	return err
+}
+
+func (p instrumented) Status(ctx context.Context, id string) (Result, error) {
+	result, err := p.PaymentProvider.Status(ctx, id)
+	p.observe("status", result, err)
+	return result, err
+}
+
+// observe labels a call by the status it answered, or by its error.
+func (p instrumented) observe(call string, result Result, err error) {
+	outcome := string(result.Status)
+	switch {
+	case errors.Is(err, ErrTimeout):
+		outcome = "timeout"
+	case errors.Is(err, ErrRejected):
+		outcome = "rejected"
+	case errors.Is(err, ErrUnknownPayment):
+		outcome = "unknown"
+	case err != nil:
+		outcome = "error"
+	case outcome == "":
+		outcome = "ok"
+	}
+	metrics.ProviderCalls.WithLabelValues(p.Name(), call, outcome).Inc()
+}
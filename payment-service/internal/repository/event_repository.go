@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"payment-service/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EventRepository is an append-only log of status events.
+type EventRepository interface {
+	Append(ctx context.Context, event *domain.StatusEvent) error
+	// After returns up to limit events of email with an ID after after and
+	// before until, oldest first.
+	After(ctx context.Context, email string, after, until primitive.ObjectID, limit int) ([]domain.StatusEvent, error)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"payment-service/internal/domain"
+	"payment-service/internal/money"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -12,7 +13,22 @@ import (
 type PaymentRepository interface {
 	GetAll(ctx context.Context) ([]domain.Payment, error)
 	GetByID(ctx context.Context, id primitive.ObjectID) (*domain.Payment, error)
+	ListByStatus(ctx context.Context, statuses ...domain.PaymentStatus) ([]domain.Payment, error)
 	Create(ctx context.Context, payment *domain.Payment) (*domain.Payment, error)
-	Update(ctx context.Context, id primitive.ObjectID, payment *domain.Payment) (*domain.Payment, error)
+	// UpdateStatus moves the payment from change.From to change.To and
+	// appends change to its history. It fails with domain.ErrStatusConflict
+	// when the payment is no longer in change.From.
+	UpdateStatus(ctx context.Context, id primitive.ObjectID, change domain.StatusChange) (*domain.Payment, error)
+	// ReserveRefund holds amount of payment id for a refund, so that no
+	// other refund can take it. It fails with domain.ErrStatusConflict when
+	// the payment can't be refunded or less than amount is left to refund.
+	ReserveRefund(ctx context.Context, id primitive.ObjectID, amount money.Money) (*domain.Payment, error)
+	// ReleaseRefund gives back amount reserved by a refund that wasn't made.
+	ReleaseRefund(ctx context.Context, id primitive.ObjectID, amount money.Money) error
+	// ConfirmRefund moves amount, reserved by a refund that was made, to the
+	// refunded amount of current and, when change isn't nil, makes that
+	// status change too. It fails with domain.ErrStatusConflict when the
+	// payment's status or refunded amount are no longer those of current.
+	ConfirmRefund(ctx context.Context, current *domain.Payment, amount money.Money, change *domain.StatusChange) (*domain.Payment, error)
 	Delete(ctx context.Context, id primitive.ObjectID) error
 }
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"payment-service/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RefundRepository interface {
+	Create(ctx context.Context, refund *domain.Refund) (*domain.Refund, error)
+	// ListByPayment returns the refunds of a payment, oldest first.
+	ListByPayment(ctx context.Context, paymentID primitive.ObjectID) ([]domain.Refund, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
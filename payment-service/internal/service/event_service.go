@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"payment-service/internal/domain"
+	"payment-service/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// eventSettle is how old an event must be before it is handed out. IDs are
+// taken before the insert, so a reader that waits a moment can't move past
+// an event another process is still inserting.
+const eventSettle = time.Second
+
+type EventService interface {
+	// Publish records event. A failure is logged, never returned: a status
+	// change must not fail because nobody could be told about it.
+	Publish(ctx context.Context, event domain.StatusEvent)
+	// After returns the settled events of email that follow after.
+	After(ctx context.Context, email string, after primitive.ObjectID, limit int) ([]domain.StatusEvent, error)
+	// Start is the cursor for a client that wants only events from now on.
+	Start() primitive.ObjectID
+}
+
+type eventService struct {
+	eventRepo repository.EventRepository
+}
+
+func NewEventService(eventRepo repository.EventRepository) EventService {
+	return &eventService{eventRepo: eventRepo}
+}
+
+func (s *eventService) Publish(ctx context.Context, event domain.StatusEvent) {
+	if event.Email == "" {
+		return
+	}
+	if err := s.eventRepo.Append(ctx, &event); err != nil {
+		log.Printf("[EVENTS] failed to record %s for %s: %v", event.Type, event.ResourceID, err)
+	}
+}
+
+func (s *eventService) After(ctx context.Context, email string, after primitive.ObjectID, limit int) ([]domain.StatusEvent, error) {
+	return s.eventRepo.After(ctx, email, after, s.Start(), limit)
+}
+
+func (s *eventService) Start() primitive.ObjectID {
+	return primitive.NewObjectIDFromTimestamp(time.Now().Add(-eventSettle))
+}
@@ -1,11 +1,17 @@
 package service
 
 import (
+	"cmp"
 	"context"
 	"errors"
+	"fmt"
+	"log"
 	"time"
 
 	"payment-service/internal/domain"
+	"payment-service/internal/metrics"
+	"payment-service/internal/money"
+	"payment-service/internal/provider"
 	"payment-service/internal/repository"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -14,19 +20,40 @@ import (
 type PaymentService interface {
 	GetAll(ctx context.Context) ([]domain.Payment, error)
 	GetByID(ctx context.Context, id primitive.ObjectID) (*domain.Payment, error)
-	Create(ctx context.Context, payment *domain.Payment) (*domain.Payment, error)
-	Update(ctx context.Context, id primitive.ObjectID, payment *domain.Payment) (*domain.Payment, error)
+	// Create stores payment and charges card with its provider, the
+	// default one when it names none: the payment is authorized and then
+	// captured, or failed when declined. A payment the provider hasn't
+	// decided on, or didn't answer for, is returned pending or authorized
+	// for Reconcile to take further.
+	Create(ctx context.Context, payment *domain.Payment, card string) (*domain.Payment, error)
+	// UpdateStatus moves the payment to status on behalf of actor, failing
+	// with domain.ErrInvalidTransition when its current status doesn't
+	// allow that. Asking for the status it is already in changes nothing.
+	// Only refunds (see RefundService) move payments to the refund statuses.
+	// A payment with a provider can only be captured or cancelled, which its
+	// provider does; a capture still settling leaves it authorized.
+	UpdateStatus(ctx context.Context, id primitive.ObjectID, status domain.PaymentStatus, actor string) (*domain.Payment, error)
 	Delete(ctx context.Context, id primitive.ObjectID) error
+	// Reconcile asks the providers where the payments they left pending or
+	// authorized stand, and follows them there.
+	Reconcile(ctx context.Context) error
 }
 
 type paymentService struct {
 	paymentRepo repository.PaymentRepository
+	events      EventService
+	providers   *provider.Registry
+	currency    money.Currency
 	timeout     time.Duration
 }
 
-func NewPaymentService(paymentRepo repository.PaymentRepository, timeout time.Duration) PaymentService {
+// NewPaymentService creates payments in currency when they don't name one.
+func NewPaymentService(paymentRepo repository.PaymentRepository, events EventService, providers *provider.Registry, currency money.Currency, timeout time.Duration) PaymentService {
 	return &paymentService{
 		paymentRepo: paymentRepo,
+		events:      events,
+		providers:   providers,
+		currency:    currency,
 		timeout:     timeout,
 	}
 }
@@ -45,29 +72,181 @@ func (u *paymentService) GetByID(ctx context.Context, id primitive.ObjectID) (*d
 	return u.paymentRepo.GetByID(ctx, id)
 }
 
-func (u *paymentService) Create(ctx context.Context, payment *domain.Payment) (*domain.Payment, error) {
+func (u *paymentService) Create(ctx context.Context, payment *domain.Payment, card string) (*domain.Payment, error) {
 	ctx, cancel := context.WithTimeout(ctx, u.timeout)
 	defer cancel()
 
-	if payment.Email == "" || payment.Amount <= 0 {
+	if payment.Amount.Currency == "" {
+		payment.Amount.Currency = u.currency
+	}
+	if payment.Email == "" || !payment.Amount.IsPositive() {
 		return nil, errors.New("invalid payment data")
 	}
+	if err := payment.Amount.Validate(); err != nil {
+		return nil, err
+	}
+	payment.Provider = cmp.Or(payment.Provider, u.providers.Default())
+	psp, err := u.providers.Get(payment.Provider)
+	if err != nil {
+		return nil, err
+	}
 
-	payment.Status = "success"
+	payment.RefundedAmount = money.New(0, payment.Amount.Currency)
+	payment.Status = domain.StatusPending
 	payment.CreatedAt = time.Now()
+	payment.StatusHistory = []domain.StatusChange{
+		{To: payment.Status, At: payment.CreatedAt, Actor: domain.ActorSystem},
+	}
+
+	created, err := u.paymentRepo.Create(ctx, payment)
+	if err != nil {
+		return nil, err
+	}
+	u.publishStatus(ctx, created, "")
 
-	return u.paymentRepo.Create(ctx, payment)
+	result, err := psp.Authorize(ctx, provider.Charge{
+		ID:     created.ID.Hex(),
+		Amount: created.Amount,
+		Email:  created.Email,
+		Card:   card,
+	})
+	if err != nil {
+		log.Printf("[PSP] authorizing payment %s with %s: %v", created.ID.Hex(), psp.Name(), err)
+	} else {
+		created = u.follow(ctx, psp, created, result)
+	}
+	// By where Create leaves it: captured, failed, or pending on the provider
+	metrics.PaymentsCreated.WithLabelValues(string(created.Status)).Inc()
+	return created, nil
 }
 
-func (u *paymentService) Update(ctx context.Context, id primitive.ObjectID, payment *domain.Payment) (*domain.Payment, error) {
+func (u *paymentService) UpdateStatus(ctx context.Context, id primitive.ObjectID, status domain.PaymentStatus, actor string) (*domain.Payment, error) {
 	ctx, cancel := context.WithTimeout(ctx, u.timeout)
 	defer cancel()
 
-	if payment.Status == "" {
-		return nil, errors.New("invalid payment status")
+	if !status.Valid() {
+		return nil, domain.ErrInvalidStatus
+	}
+
+	current, err := u.paymentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if current.Status == status {
+		return current, nil
 	}
+	if status == domain.StatusRefunded || status == domain.StatusPartiallyRefunded {
+		return nil, fmt.Errorf("%w: %s is set by refunding the payment", domain.ErrInvalidTransition, status)
+	}
+	if !current.Status.CanTransitionTo(status) {
+		return nil, fmt.Errorf("%w: %s to %s", domain.ErrInvalidTransition, current.Status, status)
+	}
+	if current.Provider == "" {
+		// Made before payments had providers
+		return u.transition(ctx, current, domain.StatusChange{To: status, Actor: actor})
+	}
+
+	psp, err := u.providers.Get(current.Provider)
+	if err != nil {
+		return nil, err
+	}
+	var result provider.Result
+	switch status {
+	case domain.StatusCaptured:
+		result, err = psp.Capture(ctx, id.Hex())
+	case domain.StatusCancelled:
+		result, err = psp.Void(ctx, id.Hex())
+	default:
+		return nil, fmt.Errorf("%w: %s is set by the payment provider", domain.ErrInvalidTransition, status)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return u.apply(ctx, current, result, actor)
+}
+
+func (u *paymentService) Reconcile(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	payments, err := u.paymentRepo.ListByStatus(ctx, domain.StatusPending, domain.StatusAuthorized)
+	if err != nil {
+		return err
+	}
+	for i := range payments {
+		payment := &payments[i]
+		psp, err := u.providers.Get(payment.Provider)
+		if err != nil {
+			continue // made before payments had providers
+		}
+		result, err := psp.Status(ctx, payment.ID.Hex())
+		if errors.Is(err, provider.ErrUnknownPayment) {
+			// The authorization never reached the provider, e.g. it failed
+			// before the provider recorded it; nothing will settle it
+			log.Printf("[PSP] payment %s is unknown to %s, left %s", payment.ID.Hex(), psp.Name(), payment.Status)
+			continue
+		}
+		if err != nil {
+			log.Printf("[PSP] status of payment %s at %s: %v", payment.ID.Hex(), psp.Name(), err)
+			continue
+		}
+		u.follow(ctx, psp, payment, result)
+	}
+	return ctx.Err()
+}
+
+// follow moves payment to result, what psp said of it, and captures it once
+// authorized. Failures are logged: the payment is left where it got to, for
+// Reconcile to take further.
+func (u *paymentService) follow(ctx context.Context, psp provider.PaymentProvider, payment *domain.Payment, result provider.Result) *domain.Payment {
+	payment, err := u.apply(ctx, payment, result, psp.Name())
+	if err == nil && payment.Status == domain.StatusAuthorized {
+		if result, err = psp.Capture(ctx, payment.ID.Hex()); err == nil {
+			payment, err = u.apply(ctx, payment, result, psp.Name())
+		}
+	}
+	if err != nil {
+		log.Printf("[PSP] payment %s at %s: %v", payment.ID.Hex(), psp.Name(), err)
+	}
+	return payment
+}
+
+// apply moves payment to the status its provider gave, on behalf of actor.
+func (u *paymentService) apply(ctx context.Context, payment *domain.Payment, result provider.Result, actor string) (*domain.Payment, error) {
+	if result.Status == payment.Status {
+		return payment, nil
+	}
+	if !payment.Status.CanTransitionTo(result.Status) {
+		return payment, fmt.Errorf("%w: provider has it %s, payment is %s", domain.ErrInvalidTransition, result.Status, payment.Status)
+	}
+	updated, err := u.transition(ctx, payment, domain.StatusChange{To: result.Status, Actor: actor, Reason: result.Reason})
+	if err != nil {
+		return payment, err
+	}
+	return updated, nil
+}
+
+// transition makes change, from the status current is in now.
+func (u *paymentService) transition(ctx context.Context, current *domain.Payment, change domain.StatusChange) (*domain.Payment, error) {
+	change.From = current.Status
+	change.At = time.Now()
+	updated, err := u.paymentRepo.UpdateStatus(ctx, current.ID, change)
+	if err != nil {
+		return nil, err
+	}
+	metrics.PaymentStatusUpdates.WithLabelValues(string(change.To)).Inc()
+	u.publishStatus(ctx, updated, current.Status)
+	return updated, nil
+}
 
-	return u.paymentRepo.Update(ctx, id, payment)
+func (u *paymentService) publishStatus(ctx context.Context, payment *domain.Payment, previous domain.PaymentStatus) {
+	u.events.Publish(ctx, domain.StatusEvent{
+		Type:           domain.EventPaymentStatusChanged,
+		ResourceID:     payment.ID.Hex(),
+		Email:          payment.Email,
+		Status:         string(payment.Status),
+		PreviousStatus: string(previous),
+	})
 }
 
 func (u *paymentService) Delete(ctx context.Context, id primitive.ObjectID) error {
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.opentelemetry.io/otel"
+)
+
+// RunReconciler reconciles payments with their providers every interval
+// until ctx ends.
+func RunReconciler(ctx context.Context, payments PaymentService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Root span so a run's provider and Mongo calls show up as one trace
+			runCtx, span := otel.Tracer("payment-service/reconciler").Start(ctx, "reconcile payments")
+			if err := payments.Reconcile(runCtx); err != nil {
+				log.Printf("[PSP] reconciling payments: %v", err)
+			}
+			span.End()
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"payment-service/internal/domain"
+	"payment-service/internal/metrics"
+	"payment-service/internal/money"
+	"payment-service/internal/provider"
+	"payment-service/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// confirmAttempts bounds the retries of a refund's confirmation that other
+// refunds confirmed at the same time got in the way of.
+const confirmAttempts = 5
+
+type RefundService interface {
+	// Refund gives amount back on the payment, all that is left to refund
+	// when amount is 0, and moves the payment to partially_refunded or
+	// refunded. amount is in the payment's currency when it names none. The
+	// payment's provider, when it has one, gives the money back. It returns
+	// the refund and the updated payment.
+	Refund(ctx context.Context, paymentID primitive.ObjectID, amount money.Money, reason, actor string) (*domain.Refund, *domain.Payment, error)
+	List(ctx context.Context, paymentID primitive.ObjectID) ([]domain.Refund, error)
+}
+
+type refundService struct {
+	paymentRepo repository.PaymentRepository
+	refundRepo  repository.RefundRepository
+	events      EventService
+	providers   *provider.Registry
+	timeout     time.Duration
+}
+
+func NewRefundService(paymentRepo repository.PaymentRepository, refundRepo repository.RefundRepository, events EventService, providers *provider.Registry, timeout time.Duration) RefundService {
+	return &refundService{
+		paymentRepo: paymentRepo,
+		refundRepo:  refundRepo,
+		events:      events,
+		providers:   providers,
+		timeout:     timeout,
+	}
+}
+
+func (s *refundService) Refund(ctx context.Context, paymentID primitive.ObjectID, amount money.Money, reason, actor string) (*domain.Refund, *domain.Payment, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	payment, err := s.paymentRepo.GetByID(ctx, paymentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if amount.Currency == "" {
+		amount.Currency = payment.Amount.Currency
+	}
+	if amount.IsZero() {
+		if amount, err = payment.RefundableAmount(); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := payment.CheckRefund(amount); err != nil {
+		return nil, nil, err
+	}
+
+	var psp provider.PaymentProvider
+	if payment.Provider != "" {
+		if psp, err = s.providers.Get(payment.Provider); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// The amount is reserved on the payment first, so that refunds made at
+	// the same time can't give back more than was captured between them.
+	// The refund is stored next, so that a payment never shows money
+	// refunded without the refund behind it. If the provider refuses it,
+	// both are taken back; once the provider has given the money back the
+	// reservation is confirmed, or kept if that fails.
+	if _, err := s.paymentRepo.ReserveRefund(ctx, paymentID, amount); err != nil {
+		return nil, nil, err
+	}
+	refund, err := s.refundRepo.Create(ctx, &domain.Refund{
+		PaymentID: paymentID,
+		Amount:    amount,
+		Reason:    reason,
+		Actor:     actor,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		s.releaseRefund(ctx, paymentID, amount)
+		return nil, nil, err
+	}
+	if psp != nil {
+		if err := psp.Refund(ctx, paymentID.Hex(), refund.ID.Hex(), amount); err != nil {
+			s.removeRefund(ctx, refund)
+			s.releaseRefund(ctx, paymentID, amount)
+			return nil, nil, err
+		}
+	}
+
+	updated, change, err := s.confirmRefund(ctx, paymentID, amount, refund.CreatedAt, actor)
+	if err != nil {
+		if psp != nil {
+			log.Printf("[REFUND] refund %s of payment %s made by %s but not confirmed, its amount stays reserved: %v", refund.ID.Hex(), paymentID.Hex(), psp.Name(), err)
+		} else {
+			s.removeRefund(ctx, refund)
+			s.releaseRefund(ctx, paymentID, amount)
+		}
+		return nil, nil, err
+	}
+
+	metrics.PaymentRefunds.WithLabelValues(string(updated.Status)).Inc()
+	if change != nil {
+		metrics.PaymentStatusUpdates.WithLabelValues(string(change.To)).Inc()
+		s.events.Publish(ctx, domain.StatusEvent{
+			Type:           domain.EventPaymentStatusChanged,
+			ResourceID:     updated.ID.Hex(),
+			Email:          updated.Email,
+			Status:         string(updated.Status),
+			PreviousStatus: string(change.From),
+		})
+	}
+	return refund, updated, nil
+}
+
+// confirmRefund adds amount, reserved for a refund that was made, to the
+// refunded amount of the payment, again on a fresh copy when another refund
+// was confirmed in the meantime.
+func (s *refundService) confirmRefund(ctx context.Context, paymentID primitive.ObjectID, amount money.Money, at time.Time, actor string) (*domain.Payment, *domain.StatusChange, error) {
+	for attempt := 1; ; attempt++ {
+		payment, err := s.paymentRepo.GetByID(ctx, paymentID)
+		if err != nil {
+			return nil, nil, err
+		}
+		refunded, err := payment.Refunded().Add(amount)
+		if err != nil {
+			return nil, nil, err
+		}
+		var change *domain.StatusChange
+		if next := payment.RefundStatus(refunded); next != payment.Status {
+			change = &domain.StatusChange{From: payment.Status, To: next, At: at, Actor: actor}
+		}
+		updated, err := s.paymentRepo.ConfirmRefund(ctx, payment, amount, change)
+		if errors.Is(err, domain.ErrStatusConflict) && attempt < confirmAttempts {
+			continue
+		}
+		return updated, change, err
+	}
+}
+
+func (s *refundService) releaseRefund(ctx context.Context, paymentID primitive.ObjectID, amount money.Money) {
+	if err := s.paymentRepo.ReleaseRefund(context.WithoutCancel(ctx), paymentID, amount); err != nil {
+		log.Printf("[REFUND] failed to release %s reserved on payment %s: %v", amount, paymentID.Hex(), err)
+	}
+}
+
+func (s *refundService) removeRefund(ctx context.Context, refund *domain.Refund) {
+	if err := s.refundRepo.Delete(context.WithoutCancel(ctx), refund.ID); err != nil {
+		log.Printf("[REFUND] failed to remove refund %s of payment %s: %v", refund.ID.Hex(), refund.PaymentID.Hex(), err)
+	}
+}
+
+func (s *refundService) List(ctx context.Context, paymentID primitive.ObjectID) ([]domain.Refund, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	if _, err := s.paymentRepo.GetByID(ctx, paymentID); err != nil {
+		return nil, err
+	}
+	return s.refundRepo.ListByPayment(ctx, paymentID)
+}
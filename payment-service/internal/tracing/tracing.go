@@ -0,0 +1,102 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Init installs the global tracer provider and the W3C traceparent
+// propagator. OTEL_TRACES_EXPORTER picks where spans go:
+//
+//	otlp   - OTLP/gRPC to OTEL_EXPORTER_OTLP_ENDPOINT (default localhost:4317)
+//	stdout - pretty-printed to stdout
+//	file   - JSON lines appended to OTEL_TRACES_FILE (default traces.json)
+//	none   - not exported; trace IDs are still generated and propagated
+//
+// The returned func flushes pending spans and must be called on shutdown.
+func Init(ctx context.Context, serviceName string) (func(context.Context) error, error) {
+	exporter, closeOutput, err := newExporter(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	if exporter != nil {
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	provider := sdktrace.NewTracerProvider(opts...)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closeOutput != nil {
+			closeOutput.Close()
+		}
+		return err
+	}, nil
+}
+
+func newExporter(ctx context.Context) (sdktrace.SpanExporter, io.Closer, error) {
+	switch kind := os.Getenv("OTEL_TRACES_EXPORTER"); kind {
+	case "", "none":
+		return nil, nil, nil
+	case "otlp":
+		// Reads OTEL_EXPORTER_OTLP_ENDPOINT / _INSECURE itself
+		exp, err := otlptracegrpc.New(ctx)
+		return exp, nil, err
+	case "stdout":
+		exp, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		return exp, nil, err
+	case "file":
+		path := os.Getenv("OTEL_TRACES_FILE")
+		if path == "" {
+			path = "traces.json"
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, err
+		}
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		return exp, f, err
+	default:
+		return nil, nil, fmt.Errorf("unknown OTEL_TRACES_EXPORTER %q", kind)
+	}
+}
+
+// RequestID echoes the current trace ID back in the X-Request-ID header so
+// clients can quote it when reporting a problem. Must run after otelecho.
+func RequestID() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if sc := trace.SpanContextFromContext(c.Request().Context()); sc.HasTraceID() {
+				c.Response().Header().Set(echo.HeaderXRequestID, sc.TraceID().String())
+			}
+			return next(c)
+		}
+	}
+}
@@ -1,17 +1,27 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
 	docs "product-service/docs"
+	"syscall"
 
 	echoSwagger "github.com/swaggo/echo-swagger"
 
 	"product-service/internal/delivery/http/handler"
+	"product-service/internal/health"
 	"product-service/internal/infra"
+	"product-service/internal/metrics"
+	"product-service/internal/money"
 	"product-service/internal/service"
+	"product-service/internal/tlsconfig"
+	"product-service/internal/tracing"
 
 	"time"
 
@@ -22,17 +32,20 @@ import (
 	echoMiddleware "github.com/labstack/echo/v4/middleware"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
 )
 
 func main() {
-	docs.SwaggerInfo.Host = "34.101.41.221:8084"
-	docs.SwaggerInfo.Schemes = []string{"http"}
-
 	// Load ENV
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, using environment variables")
 	}
 
+	// Empty host: Swagger UI calls whatever host served it (e.g. through the gateway)
+	docs.SwaggerInfo.Host = os.Getenv("SWAGGER_HOST")
+
 	port := os.Getenv("PORT")
 	mongoURI := os.Getenv("MONGO_URI")
 	mongoDBName := os.Getenv("MONGO_DB")
@@ -41,8 +54,16 @@ func main() {
 		log.Fatal("Missing required environment variables")
 	}
 
+	shutdownTracing, err := tracing.Init(context.Background(), "product-service")
+	if err != nil {
+		log.Fatalf("Failed to init tracing: %v", err)
+	}
+
 	// Connect MongoDB
-	client, err := mongo.NewClient(options.Client().ApplyURI(mongoURI))
+	client, err := mongo.NewClient(options.Client().
+		ApplyURI(mongoURI).
+		SetMonitor(otelmongo.NewMonitor()).
+		SetPoolMonitor(metrics.PoolMonitor()))
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -58,18 +79,34 @@ func main() {
 
 	db := client.Database(mongoDBName)
 
+	// New products that name no currency are priced in DEFAULT_CURRENCY, and
+	// so were all prices stored before they had one
+	currency, err := money.ParseCurrency(cmp.Or(os.Getenv("DEFAULT_CURRENCY"), "IDR"))
+	if err != nil {
+		log.Fatalf("Invalid DEFAULT_CURRENCY: %v", err)
+	}
+	if err := infra.MigrateMoney(ctx, db, currency); err != nil {
+		log.Fatalf("Failed to migrate prices: %v", err)
+	}
+
 	// Init Repository
 	productRepo := infra.NewMongoProductRepository(db)
 
 	// Init Service
-	productService := service.NewProductService(productRepo, 5*time.Second)
+	productService := service.NewProductService(productRepo, currency, 5*time.Second)
 
 	// Init Handler
 	productHandler := handler.NewProductHandler(productService)
 
+	checker := health.NewChecker(2*time.Second).
+		Add("mongo", func(ctx context.Context) error { return client.Ping(ctx, readpref.Primary()) })
+
 	// Init Echo
 	e := echo.New()
 	e.Use(echoMiddleware.CORS()) // ini WAJIB untuk Swagger!
+	e.Use(otelecho.Middleware("product-service"))
+	e.Use(tracing.RequestID())
+	e.Use(metrics.Middleware())
 
 	// Routes
 
@@ -79,11 +116,41 @@ func main() {
 	e.PUT("/products/:id", productHandler.Update)
 	e.DELETE("/products/:id", productHandler.Delete)
 	e.GET("/products/swagger/*", echoSwagger.WrapHandler)
+	e.GET("/metrics", metrics.Handler())
+	e.GET("/healthz", checker.Liveness)
+	e.GET("/readyz", checker.Readiness)
+
+	// TLS_CERT_FILE and TLS_KEY_FILE turn on TLS; with TLS_CA_FILE clients
+	// must present a certificate signed by that CA
+	serverTLS, err := tlsconfig.FromEnv("TLS").Server()
+	if err != nil {
+		log.Fatalf("Invalid TLS config: %v", err)
+	}
+	// Swagger UI calls back with the scheme the service is served over
+	docs.SwaggerInfo.Schemes = []string{"http"}
+	if serverTLS != nil {
+		docs.SwaggerInfo.Schemes = []string{"https"}
+	}
 
 	// Start server
 	address := fmt.Sprintf(":%s", port)
-	log.Printf("Starting Shopping Service at %s...", address)
-	if err := e.Start(address); err != nil {
-		log.Fatal(err)
+	go func() {
+		log.Printf("Starting Shopping Service at %s...", address)
+		if err := tlsconfig.StartEcho(e, address, serverTLS); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	<-quit
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer shutdownCancel()
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Shutdown: %v", err)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Printf("Tracing shutdown: %v", err)
 	}
 }
@@ -239,13 +239,31 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "price": {
-                    "type": "number"
+                    "description": "The currency defaults to the service's (DEFAULT_CURRENCY)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dto.Money"
+                        }
+                    ]
                 },
                 "stock": {
                     "type": "integer"
                 }
             }
         },
+        "dto.Money": {
+            "type": "object",
+            "properties": {
+                "amount_minor": {
+                    "type": "integer",
+                    "example": 1050
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "IDR"
+                }
+            }
+        },
         "dto.ProductResponse": {
             "type": "object",
             "properties": {
@@ -256,7 +274,7 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "price": {
-                    "type": "number"
+                    "$ref": "#/definitions/dto.Money"
                 },
                 "stock": {
                     "type": "integer"
@@ -275,7 +293,12 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "price": {
-                    "type": "number"
+                    "description": "The currency defaults to the service's (DEFAULT_CURRENCY)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dto.Money"
+                        }
+                    ]
                 },
                 "stock": {
                     "type": "integer"
@@ -1,20 +1,29 @@
 package dto
 
+// Money is an amount in whole minor units (cents) of currency, an ISO 4217
+// code: {"amount_minor": 1050, "currency": "USD"} is $10.50.
+type Money struct {
+	AmountMinor int64  `json:"amount_minor" example:"1050"`
+	Currency    string `json:"currency" example:"IDR"`
+}
+
 type CreateProductRequest struct {
-	Name  string  `json:"name" binding:"required"`
-	Price float64 `json:"price" binding:"required"`
-	Stock int     `json:"stock" binding:"required"`
+	Name string `json:"name" binding:"required"`
+	// The currency defaults to the service's (DEFAULT_CURRENCY)
+	Price Money `json:"price" binding:"required"`
+	Stock int   `json:"stock" binding:"required"`
 }
 
 type UpdateProductRequest struct {
-	Name  string  `json:"name" binding:"required"`
-	Price float64 `json:"price" binding:"required"`
-	Stock int     `json:"stock" binding:"required"`
+	Name string `json:"name" binding:"required"`
+	// The currency defaults to the service's (DEFAULT_CURRENCY)
+	Price Money `json:"price" binding:"required"`
+	Stock int   `json:"stock" binding:"required"`
 }
 
 type ProductResponse struct {
-	ID    string  `json:"id"`
-	Name  string  `json:"name"`
-	Price float64 `json:"price"`
-	Stock int     `json:"stock"`
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Price Money  `json:"price"`
+	Stock int    `json:"stock"`
 }
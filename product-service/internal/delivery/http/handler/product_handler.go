@@ -5,6 +5,7 @@ import (
 
 	"product-service/internal/delivery/http/dto"
 	"product-service/internal/domain"
+	"product-service/internal/money"
 	"product-service/internal/service"
 
 	"github.com/labstack/echo/v4"
@@ -40,7 +41,7 @@ func (h *ProductHandler) GetAll(c echo.Context) error {
 		response = append(response, dto.ProductResponse{
 			ID:    p.ID.Hex(),
 			Name:  p.Name,
-			Price: p.Price,
+			Price: toMoneyResponse(p.Price),
 			Stock: p.Stock,
 		})
 	}
@@ -73,7 +74,7 @@ func (h *ProductHandler) GetByID(c echo.Context) error {
 	response := dto.ProductResponse{
 		ID:    product.ID.Hex(),
 		Name:  product.Name,
-		Price: product.Price,
+		Price: toMoneyResponse(product.Price),
 		Stock: product.Stock,
 	}
 
@@ -96,11 +97,16 @@ func (h *ProductHandler) Create(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
 	}
 
+	price, err := money.Parse(req.Price.AmountMinor, req.Price.Currency)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
 	product, err := h.productService.Create(
 		c.Request().Context(),
 		&domain.Product{
 			Name:  req.Name,
-			Price: req.Price,
+			Price: price,
 			Stock: req.Stock,
 		},
 	)
@@ -111,7 +117,7 @@ func (h *ProductHandler) Create(c echo.Context) error {
 	response := dto.ProductResponse{
 		ID:    product.ID.Hex(),
 		Name:  product.Name,
-		Price: product.Price,
+		Price: toMoneyResponse(product.Price),
 		Stock: product.Stock,
 	}
 
@@ -141,12 +147,17 @@ func (h *ProductHandler) Update(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
 	}
 
+	price, err := money.Parse(req.Price.AmountMinor, req.Price.Currency)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
 	product, err := h.productService.Update(
 		c.Request().Context(),
 		objectID,
 		&domain.Product{
 			Name:  req.Name,
-			Price: req.Price,
+			Price: price,
 			Stock: req.Stock,
 		},
 	)
@@ -157,7 +168,7 @@ func (h *ProductHandler) Update(c echo.Context) error {
 	response := dto.ProductResponse{
 		ID:    product.ID.Hex(),
 		Name:  product.Name,
-		Price: product.Price,
+		Price: toMoneyResponse(product.Price),
 		Stock: product.Stock,
 	}
 
@@ -188,3 +199,7 @@ func (h *ProductHandler) Delete(c echo.Context) error {
 
 	return c.NoContent(http.StatusNoContent)
 }
+
+func toMoneyResponse(m money.Money) dto.Money {
+	return dto.Money{AmountMinor: m.AmountMinor, Currency: string(m.Currency)}
+}
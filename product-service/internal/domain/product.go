@@ -3,6 +3,8 @@ package domain
 import (
 	"time"
 
+	"product-service/internal/money"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -10,7 +12,7 @@ import (
 type Product struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	Name      string             `bson:"name" json:"name"`
-	Price     float64            `bson:"price" json:"price"`
+	Price     money.Money        `bson:"price" json:"price"`
 	Stock     int                `bson:"stock" json:"stock"`
 	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
 }
@@ -0,0 +1,33 @@
+package infra
+
+import (
+	"context"
+	"log"
+	"math"
+
+	"product-service/internal/money"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MigrateMoney converts the prices stored as floats before prices were
+// money into currency, the one they were all in. It does nothing to
+// products already converted, so it runs at every start.
+func MigrateMoney(ctx context.Context, db *mongo.Database, currency money.Currency) error {
+	scale := math.Pow10(currency.MinorDigits())
+	result, err := db.Collection("products").UpdateMany(ctx,
+		bson.M{"price": bson.M{"$type": "number"}},
+		bson.A{bson.M{"$set": bson.M{"price": bson.M{
+			"amount_minor": bson.M{"$toLong": bson.M{"$round": bson.A{bson.M{"$multiply": bson.A{"$price", scale}}, 0}}},
+			"currency":     currency,
+		}}}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount > 0 {
+		log.Printf("[MIGRATE] converted price of %d products to %s minor units", result.ModifiedCount, currency)
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"product-service/internal/domain"
 	"product-service/internal/infra"
+	"product-service/internal/money"
 
 	"github.com/stretchr/testify/assert"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -44,7 +45,7 @@ func TestCreateProduct(t *testing.T) {
 	ctx := context.Background()
 	product := &domain.Product{
 		Name:  "Sample Product",
-		Price: 99.99,
+		Price: money.New(9999, "IDR"),
 		Stock: 10,
 	}
 
@@ -64,7 +65,7 @@ func TestGetAllProducts(t *testing.T) {
 	// Insert dummy
 	product := &domain.Product{
 		Name:  "Another Product",
-		Price: 49.99,
+		Price: money.New(4999, "IDR"),
 		Stock: 5,
 	}
 	_, _ = repo.Create(ctx, product)
@@ -83,7 +84,7 @@ func TestGetByIDProduct(t *testing.T) {
 	ctx := context.Background()
 	product := &domain.Product{
 		Name:  "Find Me",
-		Price: 20.00,
+		Price: money.New(2000, "IDR"),
 		Stock: 2,
 	}
 	created, _ := repo.Create(ctx, product)
@@ -102,19 +103,19 @@ func TestUpdateProduct(t *testing.T) {
 	ctx := context.Background()
 	product := &domain.Product{
 		Name:  "To Update",
-		Price: 15.00,
+		Price: money.New(1500, "IDR"),
 		Stock: 3,
 	}
 	created, _ := repo.Create(ctx, product)
 
 	created.Name = "Updated Name"
-	created.Price = 99.00
+	created.Price = money.New(9900, "IDR")
 	created.Stock = 100
 
 	updated, err := repo.Update(ctx, created.ID, created)
 	assert.NoError(t, err)
 	assert.Equal(t, "Updated Name", updated.Name)
-	assert.Equal(t, float64(99.00), updated.Price)
+	assert.Equal(t, money.New(9900, "IDR"), updated.Price)
 	assert.Equal(t, 100, updated.Stock)
 }
 
@@ -127,7 +128,7 @@ func TestDeleteProduct(t *testing.T) {
 	ctx := context.Background()
 	product := &domain.Product{
 		Name:  "Delete Me",
-		Price: 5.00,
+		Price: money.New(500, "IDR"),
 		Stock: 1,
 	}
 	created, _ := repo.Create(ctx, product)
@@ -0,0 +1,74 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestArithmeticIsExact(t *testing.T) {
+	// 0.1 + 0.2 != 0.3 in float64
+	a, _ := FromFloat(0.1, "USD")
+	b, _ := FromFloat(0.2, "USD")
+	sum, err := a.Add(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := FromFloat(0.3, "USD")
+	if sum != want {
+		t.Errorf("0.1 + 0.2 = %v, want %v", sum, want)
+	}
+
+	total, err := New(10, "USD").Mul(3)
+	if err != nil || total != New(30, "USD") {
+		t.Errorf("0.10 * 3 = %v, %v", total, err)
+	}
+	if left, _ := want.Sub(sum); !left.IsZero() {
+		t.Errorf("0.3 - 0.3 = %v", left)
+	}
+}
+
+func TestMixedCurrencies(t *testing.T) {
+	usd, idr := New(100, "USD"), New(100, "IDR")
+	if _, err := usd.Add(idr); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Add: %v", err)
+	}
+	if _, err := usd.Sub(idr); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Sub: %v", err)
+	}
+	if _, err := usd.Cmp(idr); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Cmp: %v", err)
+	}
+}
+
+func TestOverflow(t *testing.T) {
+	if _, err := New(math.MaxInt64, "USD").Add(New(1, "USD")); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Add: %v", err)
+	}
+	if _, err := New(math.MaxInt64/2+1, "USD").Mul(2); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Mul: %v", err)
+	}
+	if _, err := FromFloat(1e30, "USD"); !errors.Is(err, ErrOverflow) {
+		t.Errorf("FromFloat: %v", err)
+	}
+}
+
+func TestCurrencies(t *testing.T) {
+	if c, err := ParseCurrency(" idr"); err != nil || c != "IDR" {
+		t.Errorf("ParseCurrency: %q, %v", c, err)
+	}
+	if _, err := ParseCurrency("XXX"); !errors.Is(err, ErrUnknownCurrency) {
+		t.Errorf("unknown currency: %v", err)
+	}
+
+	jpy, _ := FromFloat(1500, "JPY")
+	for m, want := range map[Money]string{
+		New(1050, "USD"): "10.50 USD",
+		New(-5, "USD"):   "-0.05 USD",
+		jpy:              "1500 JPY",
+	} {
+		if got := m.String(); got != want {
+			t.Errorf("%#v: %q, want %q", m, got, want)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"product-service/internal/app"
 	"product-service/internal/domain"
+	"product-service/internal/money"
 	"product-service/internal/repository"
 	"time"
 
@@ -20,12 +21,15 @@ type ProductService interface {
 
 type productService struct {
 	productRepo repository.ProductRepository
+	currency    money.Currency
 	timeout     time.Duration
 }
 
-func NewProductService(productRepo repository.ProductRepository, timeout time.Duration) ProductService {
+// NewProductService prices products that name no currency in currency.
+func NewProductService(productRepo repository.ProductRepository, currency money.Currency, timeout time.Duration) ProductService {
 	return &productService{
 		productRepo: productRepo,
+		currency:    currency,
 		timeout:     timeout,
 	}
 }
@@ -49,8 +53,8 @@ func (u *productService) Create(ctx context.Context, product *domain.Product) (*
 	defer cancel()
 
 	// Optional: Validasi bisnis
-	if product.Name == "" || product.Price <= 0 || product.Stock < 0 {
-		return nil, app.ErrInvalidProductData // Buat error custom
+	if err := u.validate(product); err != nil {
+		return nil, err
 	}
 
 	product.CreatedAt = time.Now()
@@ -63,8 +67,8 @@ func (u *productService) Update(ctx context.Context, id primitive.ObjectID, prod
 	defer cancel()
 
 	// Optional: Validasi bisnis lagi
-	if product.Name == "" || product.Price <= 0 || product.Stock < 0 {
-		return nil, app.ErrInvalidProductData
+	if err := u.validate(product); err != nil {
+		return nil, err
 	}
 
 	return u.productRepo.Update(ctx, id, product)
@@ -76,3 +80,14 @@ func (u *productService) Delete(ctx context.Context, id primitive.ObjectID) erro
 
 	return u.productRepo.Delete(ctx, id)
 }
+
+// validate fills in the default currency of the price and checks product.
+func (u *productService) validate(product *domain.Product) error {
+	if product.Price.Currency == "" {
+		product.Price.Currency = u.currency
+	}
+	if product.Name == "" || !product.Price.IsPositive() || product.Stock < 0 {
+		return app.ErrInvalidProductData
+	}
+	return product.Price.Validate()
+}
@@ -0,0 +1,38 @@
+package tracing
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// copies are the services that have this package. Each is built from its
+// own directory (the Docker build context in docker-compose.yml), so they
+// can't import a shared module; the copies are kept the same instead.
+var copies = []string{"gateway-service", "payment-service", "product-service", "transaction-service"}
+
+func TestCopiesInSync(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, service := range copies {
+		dir := filepath.Join("..", "..", "..", service, "internal", "tracing")
+		theirs, err := filepath.Glob(filepath.Join(dir, "*.go"))
+		if err != nil || len(theirs) == 0 {
+			// Not checked out next to this one, e.g. in a service's image
+			t.Skipf("%s not found", dir)
+		}
+		if len(theirs) != len(files) {
+			t.Errorf("%s has %d files, this copy %d; change the copies together", dir, len(theirs), len(files))
+		}
+		for _, name := range files {
+			ours, _ := os.ReadFile(name)
+			other, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil || !bytes.Equal(ours, other) {
+				t.Errorf("%s differs from this copy; change the copies together", filepath.Join(dir, name))
+			}
+		}
+	}
+}
@@ -1,16 +1,27 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	docs "transaction-service/docs"
 	"transaction-service/internal/delivery/http/handler"
+	"transaction-service/internal/health"
 	"transaction-service/internal/infra"
+	"transaction-service/internal/metrics"
+	"transaction-service/internal/money"
 	"transaction-service/internal/service"
+	"transaction-service/internal/tlsconfig"
+	"transaction-service/internal/tracing"
 
+	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
 	echoMiddleware "github.com/labstack/echo/v4/middleware"
 	_ "github.com/swaggo/echo-swagger"
@@ -18,12 +29,20 @@ import (
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
+	"go.opentelemetry.io/otel"
 )
 
 func main() {
-	// Setup Swagger
-	docs.SwaggerInfo.Host = "34.101.41.221:8084"
-	docs.SwaggerInfo.Schemes = []string{"http"}
+	// Load ENV
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+
+	// Setup Swagger. Empty host: Swagger UI calls whatever host served it
+	docs.SwaggerInfo.Host = os.Getenv("SWAGGER_HOST")
 
 	// Load ENV or default fallback
 	mongoURI := os.Getenv("MONGO_URI")
@@ -41,26 +60,78 @@ func main() {
 		paymentURL = "http://localhost:8082"
 	}
 
+	shutdownTracing, err := tracing.Init(context.Background(), "transaction-service")
+	if err != nil {
+		log.Fatalf("Failed to init tracing: %v", err)
+	}
+
 	// Connect to MongoDB
-	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(mongoURI))
+	client, err := mongo.Connect(context.Background(), options.Client().
+		ApplyURI(mongoURI).
+		SetMonitor(otelmongo.NewMonitor()).
+		SetPoolMonitor(metrics.PoolMonitor()))
 	if err != nil {
 		log.Fatal("Failed to connect MongoDB:", err)
 	}
 	db := client.Database("transaction_db")
 
+	// Totals stored before they had a currency were all in DEFAULT_CURRENCY
+	currency, err := money.ParseCurrency(cmp.Or(os.Getenv("DEFAULT_CURRENCY"), "IDR"))
+	if err != nil {
+		log.Fatalf("Invalid DEFAULT_CURRENCY: %v", err)
+	}
+	if err := infra.MigrateMoney(context.Background(), db, currency); err != nil {
+		log.Fatalf("Failed to migrate totals: %v", err)
+	}
+
 	// Init repository & service
 	transactionRepo := infra.NewMongoTransactionRepository(db)
-	transactionService := service.NewTransactionService(transactionRepo, productURL, paymentURL, 5*time.Second)
+	eventRepo, err := infra.NewMongoEventRepository(context.Background(), db)
+	if err != nil {
+		log.Fatalf("Failed to set up event log: %v", err)
+	}
+	eventService := service.NewEventService(eventRepo)
+
+	// TLS_CERT_FILE and TLS_KEY_FILE turn on TLS; with TLS_CA_FILE clients
+	// must present a certificate signed by that CA. The same files verify
+	// https product/payment URLs and identify this service to them.
+	serverTLS, err := tlsconfig.FromEnv("TLS").Server()
+	if err != nil {
+		log.Fatalf("Invalid TLS config: %v", err)
+	}
+	// Swagger UI calls back with the scheme the service is served over
+	docs.SwaggerInfo.Schemes = []string{"http"}
+	if serverTLS != nil {
+		docs.SwaggerInfo.Schemes = []string{"https"}
+	}
+
+	clientTLS, err := tlsconfig.FromEnv("TLS").Client()
+	if err != nil {
+		log.Fatalf("Invalid TLS config: %v", err)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = clientTLS
+
+	transactionService := service.NewTransactionService(transactionRepo, eventService, productURL, paymentURL, 5*time.Second, transport)
 
 	// ✅ Start cron job di background
 	startCron(transactionService)
 
 	// Init handler
 	transactionHandler := handler.NewTransactionHandler(transactionService)
+	eventHandler := handler.NewEventHandler(eventService)
+
+	checker := health.NewChecker(2*time.Second).
+		Add("mongo", func(ctx context.Context) error { return client.Ping(ctx, readpref.Primary()) }).
+		Add("product-service", health.HTTP(&http.Client{Transport: transport}, productURL+"/healthz")).
+		Add("payment-service", health.HTTP(&http.Client{Transport: transport}, paymentURL+"/healthz"))
 
 	// Setup Echo
 	e := echo.New()
 	e.Use(echoMiddleware.CORS())
+	e.Use(otelecho.Middleware("transaction-service"))
+	e.Use(tracing.RequestID())
+	e.Use(metrics.Middleware())
 
 	// Routes
 	e.GET("/transactions", transactionHandler.GetAll)
@@ -69,15 +140,34 @@ func main() {
 	e.PUT("/transactions/:id", transactionHandler.Update)
 	e.DELETE("/transactions/:id", transactionHandler.Delete)
 	e.GET("/transactions/swagger/*", echoSwagger.WrapHandler)
+	e.GET("/events", eventHandler.Stream) // internal, followed by the gateway
+	e.GET("/metrics", metrics.Handler())
+	e.GET("/healthz", checker.Liveness)
+	e.GET("/readyz", checker.Readiness)
 
 	// Run server
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8082"
 	}
-	log.Println("Transaction Service running at port:", port)
-	if err := e.Start(":" + port); err != nil {
-		log.Fatal(err)
+	go func() {
+		log.Println("Transaction Service running at port:", port)
+		if err := tlsconfig.StartEcho(e, ":"+port, serverTLS); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	<-quit
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := e.Shutdown(ctx); err != nil {
+		log.Printf("Shutdown: %v", err)
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		log.Printf("Tracing shutdown: %v", err)
 	}
 }
 
@@ -90,11 +180,16 @@ func startCron(transactionService service.TransactionService) {
 		for {
 			select {
 			case <-ticker.C:
-				ctx := context.Background()
+				// Root span so the sweep's Mongo calls show up as one trace
+				ctx, span := otel.Tracer("transaction-service/cron").Start(context.Background(), "cron MarkExpiredPendingTransactions")
 				err := transactionService.MarkExpiredPendingTransactions(ctx)
+				span.End()
 				if err != nil {
+					metrics.ExpirySweeps.WithLabelValues("error").Inc()
 					log.Printf("[CRON] Error marking expired transactions: %v", err)
 				} else {
+					metrics.ExpirySweeps.WithLabelValues("success").Inc()
+					metrics.ExpirySweepLastSuccess.SetToCurrentTime()
 					log.Println("[CRON] Expired pending transactions marked successfully")
 				}
 			}
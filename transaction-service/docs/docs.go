@@ -246,6 +246,19 @@ const docTemplate = `{
                 }
             }
         },
+        "dto.Money": {
+            "type": "object",
+            "properties": {
+                "amount_minor": {
+                    "type": "integer",
+                    "example": 1050
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "IDR"
+                }
+            }
+        },
         "dto.TransactionResponse": {
             "type": "object",
             "properties": {
@@ -268,7 +281,7 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "total": {
-                    "type": "number"
+                    "$ref": "#/definitions/dto.Money"
                 }
             }
         },
@@ -1,5 +1,12 @@
 package dto
 
+// Money is an amount in whole minor units (cents) of currency, an ISO 4217
+// code: {"amount_minor": 1050, "currency": "USD"} is $10.50.
+type Money struct {
+	AmountMinor int64  `json:"amount_minor" example:"1050"`
+	Currency    string `json:"currency" example:"IDR"`
+}
+
 type CreateTransactionRequest struct {
 	ProductID string `json:"product_id" binding:"required"`
 	PaymentID string `json:"payment_id" binding:"required"`
@@ -11,11 +18,11 @@ type UpdateTransactionRequest struct {
 }
 
 type TransactionResponse struct {
-	ID        string  `json:"id"`
-	ProductID string  `json:"product_id"`
-	PaymentID string  `json:"payment_id"`
-	Quantity  int     `json:"quantity"`
-	Total     float64 `json:"total"`
-	Status    string  `json:"status"`
-	CreatedAt string  `json:"created_at"`
+	ID        string `json:"id"`
+	ProductID string `json:"product_id"`
+	PaymentID string `json:"payment_id"`
+	Quantity  int    `json:"quantity"`
+	Total     Money  `json:"total"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"created_at"`
 }
@@ -7,6 +7,7 @@ import (
 
 	"transaction-service/internal/delivery/http/dto"
 	"transaction-service/internal/domain"
+	"transaction-service/internal/money"
 	"transaction-service/internal/service"
 
 	"github.com/labstack/echo/v4"
@@ -45,7 +46,7 @@ func (h *TransactionHandler) GetAll(c echo.Context) error {
 			ProductID: t.ProductID.Hex(),
 			PaymentID: t.PaymentID.Hex(),
 			Quantity:  t.Quantity,
-			Total:     t.Total,
+			Total:     toMoneyResponse(t.Total),
 			Status:    t.Status,
 			CreatedAt: t.CreatedAt.Format(time.RFC3339),
 		})
@@ -82,7 +83,7 @@ func (h *TransactionHandler) GetByID(c echo.Context) error {
 		ProductID: t.ProductID.Hex(),
 		PaymentID: t.PaymentID.Hex(),
 		Quantity:  t.Quantity,
-		Total:     t.Total,
+		Total:     toMoneyResponse(t.Total),
 		Status:    t.Status,
 		CreatedAt: t.CreatedAt.Format(time.RFC3339),
 	}
@@ -136,7 +137,7 @@ func (h *TransactionHandler) Create(c echo.Context) error {
 		ProductID: created.ProductID.Hex(),
 		PaymentID: created.PaymentID.Hex(),
 		Quantity:  created.Quantity,
-		Total:     created.Total,
+		Total:     toMoneyResponse(created.Total),
 		Status:    created.Status,
 		CreatedAt: created.CreatedAt.Format(time.RFC3339),
 	}
@@ -181,7 +182,7 @@ func (h *TransactionHandler) Update(c echo.Context) error {
 		ProductID: updated.ProductID.Hex(),
 		PaymentID: updated.PaymentID.Hex(),
 		Quantity:  updated.Quantity,
-		Total:     updated.Total,
+		Total:     toMoneyResponse(updated.Total),
 		Status:    updated.Status,
 		CreatedAt: updated.CreatedAt.Format(time.RFC3339),
 	}
@@ -213,3 +214,7 @@ func (h *TransactionHandler) Delete(c echo.Context) error {
 
 	return c.NoContent(http.StatusNoContent)
 }
+
+func toMoneyResponse(m money.Money) dto.Money {
+	return dto.Money{AmountMinor: m.AmountMinor, Currency: string(m.Currency)}
+}
@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const EventTransactionStatusChanged = "transaction.status_changed"
+
+// StatusEvent records a status change, for clients following them live.
+// The ObjectID orders events and is the SSE event ID.
+type StatusEvent struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Type           string             `bson:"type" json:"type"`
+	ResourceID     string             `bson:"resource_id" json:"resource_id"`
+	Email          string             `bson:"email" json:"-"` // whose event it is
+	Status         string             `bson:"status" json:"status"`
+	PreviousStatus string             `bson:"previous_status,omitempty" json:"previous_status,omitempty"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,33 @@
+package infra
+
+import (
+	"context"
+	"log"
+	"math"
+
+	"transaction-service/internal/money"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MigrateMoney converts the totals stored as floats before totals were
+// money into currency, the one they were all in. It does nothing to
+// transactions already converted, so it runs at every start.
+func MigrateMoney(ctx context.Context, db *mongo.Database, currency money.Currency) error {
+	scale := math.Pow10(currency.MinorDigits())
+	result, err := db.Collection("transactions").UpdateMany(ctx,
+		bson.M{"total": bson.M{"$type": "number"}},
+		bson.A{bson.M{"$set": bson.M{"total": bson.M{
+			"amount_minor": bson.M{"$toLong": bson.M{"$round": bson.A{bson.M{"$multiply": bson.A{"$total", scale}}, 0}}},
+			"currency":     currency,
+		}}}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount > 0 {
+		log.Printf("[MIGRATE] converted total of %d transactions to %s minor units", result.ModifiedCount, currency)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package infra
+
+import (
+	"context"
+	"time"
+
+	"transaction-service/internal/domain"
+	"transaction-service/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EventRetention is how long status events are kept for clients to resume
+// from.
+const EventRetention = 24 * time.Hour
+
+type mongoEventRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoEventRepository stores events in transaction_events, with a TTL index
+// dropping them after EventRetention.
+func NewMongoEventRepository(ctx context.Context, db *mongo.Database) (repository.EventRepository, error) {
+	collection := db.Collection("transaction_events")
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "created_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(int32(EventRetention.Seconds()))},
+		{Keys: bson.D{{Key: "email", Value: 1}, {Key: "_id", Value: 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &mongoEventRepository{collection: collection}, nil
+}
+
+func (r *mongoEventRepository) Append(ctx context.Context, event *domain.StatusEvent) error {
+	if event.ID.IsZero() {
+		event.ID = primitive.NewObjectID()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	_, err := r.collection.InsertOne(ctx, event)
+	return err
+}
+
+func (r *mongoEventRepository) After(ctx context.Context, email string, after, until primitive.ObjectID, limit int) ([]domain.StatusEvent, error) {
+	filter := bson.M{
+		"email": email,
+		"_id":   bson.M{"$gt": after, "$lt": until},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var events []domain.StatusEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
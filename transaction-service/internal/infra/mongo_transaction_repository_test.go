@@ -7,6 +7,7 @@ import (
 
 	"transaction-service/internal/domain"
 	"transaction-service/internal/infra"
+	"transaction-service/internal/money"
 
 	"github.com/stretchr/testify/assert"
 	"go.mongodb.org/mongo-driver/bson"
@@ -48,7 +49,7 @@ func TestCreateTransaction(t *testing.T) {
 		ProductID: primitive.NewObjectID(),
 		PaymentID: primitive.NewObjectID(),
 		Quantity:  2,
-		Total:     100.0,
+		Total:     money.New(10000, "IDR"),
 		Status:    "pending",
 	}
 
@@ -70,7 +71,7 @@ func TestGetAllTransactions(t *testing.T) {
 		ProductID: primitive.NewObjectID(),
 		PaymentID: primitive.NewObjectID(),
 		Quantity:  2,
-		Total:     100,
+		Total:     money.New(10000, "IDR"),
 		Status:    "pending",
 	}
 	created, _ := repo.Create(ctx, tx)
@@ -96,7 +97,7 @@ func TestGetByIDTransaction(t *testing.T) {
 		ProductID: primitive.NewObjectID(),
 		PaymentID: primitive.NewObjectID(),
 		Quantity:  3,
-		Total:     150.0,
+		Total:     money.New(15000, "IDR"),
 		Status:    "success",
 	}
 	created, _ := repo.Create(ctx, tx)
@@ -117,7 +118,7 @@ func TestUpdateTransaction(t *testing.T) {
 		ProductID: primitive.NewObjectID(),
 		PaymentID: primitive.NewObjectID(),
 		Quantity:  1,
-		Total:     25.0,
+		Total:     money.New(2500, "IDR"),
 		Status:    "pending",
 	}
 	created, _ := repo.Create(ctx, tx)
@@ -140,7 +141,7 @@ func TestDeleteTransaction(t *testing.T) {
 		ProductID: primitive.NewObjectID(),
 		PaymentID: primitive.NewObjectID(),
 		Quantity:  1,
-		Total:     10.0,
+		Total:     money.New(1000, "IDR"),
 		Status:    "pending",
 	}
 	created, _ := repo.Create(ctx, tx)
@@ -166,7 +167,7 @@ func TestMarkExpiredPendingTransactions(t *testing.T) {
 		ProductID: primitive.NewObjectID(),
 		PaymentID: primitive.NewObjectID(),
 		Quantity:  2,
-		Total:     100,
+		Total:     money.New(10000, "IDR"),
 		Status:    "pending",
 	}
 	created, _ := repo.Create(ctx, tx)
@@ -177,8 +178,9 @@ func TestMarkExpiredPendingTransactions(t *testing.T) {
 		bson.M{"$set": bson.M{"created_at": time.Now().UTC().Add(-2 * time.Hour)}},
 	)
 
-	err := repo.MarkExpiredPendingTransactions(ctx)
+	expired, err := repo.MarkExpiredPendingTransactions(ctx)
 	assert.NoError(t, err)
+	assert.Len(t, expired, 1)
 
 	updated, _ := repo.GetByID(ctx, created.ID)
 	assert.Equal(t, "failed", updated.Status)
@@ -2,7 +2,7 @@ package infra
 
 import (
 	"context"
-	"fmt"
+	"log"
 	"time"
 
 	"transaction-service/internal/domain"
@@ -11,6 +11,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type mongoTransactionRepository struct {
@@ -88,7 +89,17 @@ func (r *mongoTransactionRepository) Delete(ctx context.Context, id primitive.Ob
 	return err
 }
 
-func (r *mongoTransactionRepository) MarkExpiredPendingTransactions(ctx context.Context) error {
+const (
+	// expireBatchSize pending transactions are read at a time, and at most
+	// expireSweepLimit are failed per sweep; the rest wait for the next one.
+	expireBatchSize  = 500
+	expireSweepLimit = 5000
+)
+
+// MarkExpiredPendingTransactions fails the transactions pending for more
+// than 30 minutes, oldest first and up to expireSweepLimit, and returns the
+// ones it changed.
+func (r *mongoTransactionRepository) MarkExpiredPendingTransactions(ctx context.Context) ([]domain.Transaction, error) {
 	threshold := time.Now().Add(-30 * time.Minute)
 	filter := bson.M{
 		"status": "pending",
@@ -96,10 +107,41 @@ func (r *mongoTransactionRepository) MarkExpiredPendingTransactions(ctx context.
 			"$lt": threshold,
 		},
 	}
-	fmt.Println("Threshold:", threshold) // Debug
-	update := bson.M{"$set": bson.M{"status": "failed"}}
-
-	res, err := r.collection.UpdateMany(ctx, filter, update)
-	fmt.Println("Matched:", res.MatchedCount, "Modified:", res.ModifiedCount)
-	return err
+	page := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(expireBatchSize)
+
+	var expired []domain.Transaction
+	for scanned := 0; scanned < expireSweepLimit; {
+		cursor, err := r.collection.Find(ctx, filter, page)
+		if err != nil {
+			return expired, err
+		}
+		var pending []domain.Transaction
+		if err := cursor.All(ctx, &pending); err != nil {
+			return expired, err
+		}
+		scanned += len(pending)
+
+		// One by one, still requiring "pending": a transaction settled since
+		// the Find is left alone and not reported
+		for _, tx := range pending {
+			res, err := r.collection.UpdateOne(ctx,
+				bson.M{"_id": tx.ID, "status": "pending"},
+				bson.M{"$set": bson.M{"status": "failed", "updated_at": time.Now()}},
+			)
+			if err != nil {
+				return expired, err
+			}
+			if res.ModifiedCount == 1 {
+				tx.Status = "failed"
+				expired = append(expired, tx)
+			}
+		}
+		if len(pending) < expireBatchSize {
+			break
+		}
+	}
+	log.Printf("[CRON] %d expired pending transactions marked failed", len(expired))
+	return expired, nil
 }
@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// copies are the files of this package that are kept the same across
+// services, and the services that have them. Each service is built from its
+// own directory (the Docker build context in docker-compose.yml), so they
+// can't import a shared module. Files not listed are the service's own.
+var copies = map[string][]string{
+	"copies_test.go": {"payment-service", "product-service", "transaction-service"},
+	"http.go":        {"payment-service", "product-service", "transaction-service"},
+	"mongo.go":       {"payment-service", "product-service", "transaction-service"},
+}
+
+func TestCopiesInSync(t *testing.T) {
+	for name, services := range copies {
+		ours, err := os.ReadFile(name)
+		if err != nil {
+			// One this service has no copy of
+			continue
+		}
+		for _, service := range services {
+			dir := filepath.Join("..", "..", "..", service, "internal", "metrics")
+			if _, err := os.Stat(dir); err != nil {
+				// Not checked out next to this one, e.g. in a service's image
+				t.Skipf("%s not found", dir)
+			}
+			other, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil || !bytes.Equal(ours, other) {
+				t.Errorf("%s differs from this copy; change the copies together", filepath.Join(dir, name))
+			}
+		}
+	}
+}
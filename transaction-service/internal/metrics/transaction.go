@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// TransactionsCreated counts transactions stored, by their initial status.
+	TransactionsCreated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "transactions_created_total",
+		Help: "Transactions created by status.",
+	}, []string{"status"})
+
+	// TransactionStatusUpdates counts updates by the status set.
+	TransactionStatusUpdates = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "transaction_status_updates_total",
+		Help: "Transaction updates by new status.",
+	}, []string{"status"})
+
+	// ExpirySweeps counts runs of the cron job that fails stale pending
+	// transactions, by result (success/error).
+	ExpirySweeps = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "transaction_expiry_sweeps_total",
+		Help: "Expired-transaction sweeps by result.",
+	}, []string{"result"})
+
+	// ExpirySweepLastSuccess is the unix time of the last successful sweep.
+	ExpirySweepLastSuccess = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "transaction_expiry_sweep_last_success_timestamp_seconds",
+		Help: "Unix time of the last successful expired-transaction sweep.",
+	})
+)
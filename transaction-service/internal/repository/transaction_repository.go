@@ -16,6 +16,6 @@ type TransactionRepository interface {
 	Update(ctx context.Context, id primitive.ObjectID, transaction *domain.Transaction) (*domain.Transaction, error)
 	Delete(ctx context.Context, id primitive.ObjectID) error
 
-	MarkExpiredPendingTransactions(ctx context.Context) error // ✅ Tambahkan ini!
-
+	// MarkExpiredPendingTransactions returns the transactions it marked failed
+	MarkExpiredPendingTransactions(ctx context.Context) ([]domain.Transaction, error)
 }
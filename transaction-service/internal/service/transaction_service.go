@@ -4,13 +4,18 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"time"
 
 	"transaction-service/internal/domain"
+	"transaction-service/internal/metrics"
+	"transaction-service/internal/money"
 	"transaction-service/internal/repository"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 type TransactionService interface {
@@ -25,22 +30,29 @@ type TransactionService interface {
 
 type transactionService struct {
 	transactionRepo repository.TransactionRepository
+	events          EventService
 	productURL      string
 	paymentURL      string
 	timeout         time.Duration
+	httpClient      *http.Client
 }
 
 func NewTransactionService(
 	transactionRepo repository.TransactionRepository,
+	events EventService,
 	productURL string,
 	paymentURL string,
 	timeout time.Duration,
+	transport http.RoundTripper,
 ) TransactionService {
 	return &transactionService{
 		transactionRepo: transactionRepo,
+		events:          events,
 		productURL:      productURL,
 		paymentURL:      paymentURL,
 		timeout:         timeout,
+		// Propagates the trace to product/payment service
+		httpClient: &http.Client{Transport: otelhttp.NewTransport(transport)},
 	}
 }
 
@@ -60,33 +72,71 @@ func (s *transactionService) GetByID(ctx context.Context, id primitive.ObjectID)
 
 func (s *transactionService) Create(ctx context.Context, transaction *domain.Transaction) (*domain.Transaction, error) {
 	// 1️⃣ Validasi Product
-	productResp, err := http.Get(s.productURL + "/products/" + transaction.ProductID.Hex())
-	if err != nil || productResp.StatusCode != http.StatusOK {
+	productResp, err := s.get(ctx, s.productURL+"/products/"+transaction.ProductID.Hex())
+	if err != nil {
 		return nil, errors.New("product not found or unreachable")
 	}
 	defer productResp.Body.Close()
 
 	// 2️⃣ Validasi Payment
-	paymentResp, err := http.Get(s.paymentURL + "/payments/" + transaction.PaymentID.Hex())
-	if err != nil || paymentResp.StatusCode != http.StatusOK {
+	paymentResp, err := s.get(ctx, s.paymentURL+"/payments/"+transaction.PaymentID.Hex())
+	if err != nil {
 		return nil, errors.New("payment not found or unreachable")
 	}
 	defer paymentResp.Body.Close()
 
 	// 3️⃣ Hitung total
 	var product struct {
-		ID    string  `json:"id"`
-		Price float64 `json:"price"`
+		ID    string      `json:"id"`
+		Price money.Money `json:"price"`
 	}
 	if err := json.NewDecoder(productResp.Body).Decode(&product); err != nil {
 		return nil, errors.New("failed to decode product")
 	}
 
-	transaction.Total = float64(transaction.Quantity) * product.Price
+	// The payer owns the transaction: their email routes its events
+	var payment struct {
+		Email  string      `json:"email"`
+		Amount money.Money `json:"amount"`
+	}
+	if err := json.NewDecoder(paymentResp.Body).Decode(&payment); err != nil {
+		return nil, errors.New("failed to decode payment")
+	}
+
+	// A payment only pays for products priced in its own currency
+	if product.Price.Currency != payment.Amount.Currency {
+		return nil, fmt.Errorf("%w: product in %s, payment in %s", money.ErrCurrencyMismatch, product.Price.Currency, payment.Amount.Currency)
+	}
+	if transaction.Total, err = product.Price.Mul(int64(transaction.Quantity)); err != nil {
+		return nil, err
+	}
 	transaction.Status = "success"
 	transaction.CreatedAt = time.Now()
 
-	return s.transactionRepo.Create(ctx, transaction)
+	created, err := s.transactionRepo.Create(ctx, transaction)
+	if err != nil {
+		return nil, err
+	}
+	metrics.TransactionsCreated.WithLabelValues(created.Status).Inc()
+	s.publishStatus(ctx, created, "", payment.Email)
+	return created, nil
+}
+
+// get fetches url and returns the response only when it is 200 OK.
+func (s *transactionService) get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.New(resp.Status)
+	}
+	return resp, nil
 }
 
 func (s *transactionService) Update(ctx context.Context, id primitive.ObjectID, transaction *domain.Transaction) (*domain.Transaction, error) {
@@ -97,7 +147,20 @@ func (s *transactionService) Update(ctx context.Context, id primitive.ObjectID,
 		return nil, errors.New("invalid status")
 	}
 
-	return s.transactionRepo.Update(ctx, id, transaction)
+	var previous string
+	if current, err := s.transactionRepo.GetByID(ctx, id); err == nil {
+		previous = current.Status
+	}
+
+	updated, err := s.transactionRepo.Update(ctx, id, transaction)
+	if err != nil {
+		return nil, err
+	}
+	metrics.TransactionStatusUpdates.WithLabelValues(transaction.Status).Inc()
+	if updated.Status != previous {
+		s.publishStatus(ctx, updated, previous, s.ownerEmail(ctx, updated.PaymentID))
+	}
+	return updated, nil
 }
 
 func (s *transactionService) Delete(ctx context.Context, id primitive.ObjectID) error {
@@ -111,5 +174,47 @@ func (s *transactionService) MarkExpiredPendingTransactions(ctx context.Context)
 	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
-	return s.transactionRepo.MarkExpiredPendingTransactions(ctx)
+	expired, err := s.transactionRepo.MarkExpiredPendingTransactions(ctx)
+
+	// Report what did change even when the sweep stopped half-way
+	owners := make(map[primitive.ObjectID]string)
+	for i := range expired {
+		tx := &expired[i]
+		email, ok := owners[tx.PaymentID]
+		if !ok {
+			email = s.ownerEmail(ctx, tx.PaymentID)
+			owners[tx.PaymentID] = email
+		}
+		s.publishStatus(ctx, tx, "pending", email)
+	}
+	return err
+}
+
+// ownerEmail looks up the email of the payment a transaction belongs to; ""
+// when the payment service can't say.
+func (s *transactionService) ownerEmail(ctx context.Context, paymentID primitive.ObjectID) string {
+	resp, err := s.get(ctx, s.paymentURL+"/payments/"+paymentID.Hex())
+	if err != nil {
+		log.Printf("[EVENTS] owner of payment %s unknown: %v", paymentID.Hex(), err)
+		return ""
+	}
+	defer resp.Body.Close()
+
+	var payment struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payment); err != nil {
+		return ""
+	}
+	return payment.Email
+}
+
+func (s *transactionService) publishStatus(ctx context.Context, tx *domain.Transaction, previous, email string) {
+	s.events.Publish(ctx, domain.StatusEvent{
+		Type:           domain.EventTransactionStatusChanged,
+		ResourceID:     tx.ID.Hex(),
+		Email:          email,
+		Status:         tx.Status,
+		PreviousStatus: previous,
+	})
 }